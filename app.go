@@ -1,8 +1,19 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	goruntime "runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 	"github.com/zoubingwu/tidb-desktop/services"
@@ -15,7 +26,24 @@ type App struct {
 	configService      *services.ConfigService
 	metadataService    *services.MetadataService
 	activeConnection   *services.ConnectionDetails
-	activeConnectionID string // Store the ID of the active connection
+	activeConnectionID string       // Store the ID of the active connection
+	connMu             sync.RWMutex // Guards activeConnection, activeConnectionID and stopPoolStats
+	stopPoolStats      context.CancelFunc
+	// Last connection test outcome per saved connection ID, for diagnostic bundles
+	testResults map[string]services.ConnectionTestRecord
+	testMu      sync.Mutex
+	// Cancel funcs of running editor queries by query ID, see CancelQuery
+	queries     map[string]context.CancelFunc
+	queryMu     sync.Mutex
+	nextQueryID atomic.Uint64
+	queryCache  *services.QueryCache
+	// Context shared by running metadata extractions, see CancelMetadataExtraction
+	extractionCtx    context.Context
+	cancelExtraction context.CancelFunc
+	extractionMu     sync.Mutex
+	// Transaction holding uncommitted writes while the active connection has autocommit off
+	pendingTxID string
+	pendingMu   sync.Mutex
 }
 
 // NewApp creates a new App application struct
@@ -40,6 +68,9 @@ func NewApp() *App {
 		dbService:       dbService,
 		configService:   configService,
 		metadataService: metadataService,
+		testResults:     make(map[string]services.ConnectionTestRecord),
+		queries:         make(map[string]context.CancelFunc),
+		queryCache:      services.NewQueryCache(),
 		// activeConnection starts as nil
 	}
 }
@@ -70,6 +101,21 @@ func (a *App) startup(ctx context.Context) {
 		}
 	}
 
+	// Let the frontend know when an abandoned transaction was rolled back
+	a.dbService.OnTxExpired = func(txID string) {
+		a.pendingMu.Lock()
+		if a.pendingTxID == txID {
+			a.pendingTxID = ""
+			runtime.EventsEmit(a.ctx, "transaction:pending", false)
+		}
+		a.pendingMu.Unlock()
+		runtime.EventsEmit(a.ctx, "transaction:expired", txID)
+	}
+
+	a.metadataService.OnExtractionProgress = func(progress services.ExtractionProgress) {
+		runtime.EventsEmit(a.ctx, "metadata:extraction:progress", progress)
+	}
+
 	// Subscribe to metadata extraction events
 	runtime.EventsOn(a.ctx, "metadata:extraction:start", func(optionalData ...interface{}) {
 		connectionID := optionalData[0].(string)
@@ -79,23 +125,25 @@ func (a *App) startup(ctx context.Context) {
 		services.LogInfo("Metadata extraction started for connection ID '%s' and force extraction: %v", connectionID, force)
 
 		if connectionID == "" {
-			connectionID = a.activeConnectionID
+			connectionID = a.getActiveConnectionID()
 		}
 
 		var metadata *services.ConnectionMetadata
 		var err error
 
-		if force {
-			if dbName != "" {
-				metadata, err = a.metadataService.ExtractMetadata(a.ctx, connectionID, dbName)
-			} else {
-				metadata, err = a.metadataService.ExtractMetadata(a.ctx, connectionID)
-			}
+		// Rapid repeated events share the in-flight load or extraction rather than stacking new ones
+		if a.configService.IsBackgroundActivityEnabled() {
+			metadata, err = a.metadataService.AutoMetadata(a.extractionContext(), connectionID, dbName, force)
 		} else {
+			// While background activity is paused, serve cached metadata instead of hitting the database
+			services.LogInfo("Background activity is disabled, serving cached metadata for connection ID '%s'", connectionID)
 			metadata, err = a.metadataService.GetMetadata(a.ctx, connectionID)
 		}
 
-		if err != nil {
+		if errors.Is(err, context.Canceled) {
+			services.LogInfo("Metadata extraction cancelled for connection ID '%s'", connectionID)
+			runtime.EventsEmit(a.ctx, "metadata:extraction:cancelled", connectionID)
+		} else if err != nil {
 			services.LogError("Background metadata extraction failed for connection ID '%s': %v", connectionID, err)
 			runtime.EventsEmit(a.ctx, "metadata:extraction:failed", err.Error())
 		} else {
@@ -109,6 +157,23 @@ func (a *App) startup(ctx context.Context) {
 	})
 }
 
+// domReady is called once the frontend has loaded and can receive events.
+func (a *App) domReady(ctx context.Context) {
+	// Open the default connection in the background; on failure the normal connection picker is shown.
+	// A reloaded frontend keeps the session it already has.
+	if a.getActiveConnection() != nil {
+		return
+	}
+	if connectionID := a.configService.GetDefaultConnection(); connectionID != "" {
+		go func() {
+			services.LogInfo("Auto-connecting to default connection ID '%s'", connectionID)
+			if _, err := a.ConnectUsingSaved(connectionID); err != nil {
+				services.LogWarning("Auto-connect to default connection failed: %v", err)
+			}
+		}()
+	}
+}
+
 // shutdown is called when the app terminates.
 func (a *App) shutdown(ctx context.Context) {
 	// Save current window state
@@ -132,6 +197,8 @@ func (a *App) shutdown(ctx context.Context) {
 
 	// Perform other cleanup here if needed
 	runtime.EventsOff(a.ctx, "metadata:extraction:start")
+	a.CancelMetadataExtraction()
+	a.dbService.Close()
 }
 
 // --- Exposed Methods ---
@@ -142,7 +209,85 @@ func (a *App) TestConnection(details services.ConnectionDetails) (bool, error) {
 	if a.ctx == nil {
 		return false, fmt.Errorf("app context not initialized")
 	}
-	return a.dbService.TestConnection(a.ctx, details)
+	if err := services.ValidateConnectionDetails(details); err != nil {
+		a.recordConnectionTest(details.ID, false, err)
+		return false, err
+	}
+	success, err := a.dbService.TestConnection(a.ctx, details)
+	a.recordConnectionTest(details.ID, success, err)
+	return success, err
+}
+
+// recordConnectionTest remembers the outcome of testing a saved connection. Unsaved connections
+// (empty ID) are ignored.
+func (a *App) recordConnectionTest(connectionID string, success bool, err error) {
+	if connectionID == "" {
+		return
+	}
+	record := services.ConnectionTestRecord{Success: success && err == nil, TestedAt: time.Now()}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	a.testMu.Lock()
+	a.testResults[connectionID] = record
+	a.testMu.Unlock()
+}
+
+// TestConnectionAutoTLS tests the connection and, on a TLS handshake failure, retries with TLS toggled.
+// The returned details carry the TLS setting that worked, so they can be saved as-is.
+func (a *App) TestConnectionAutoTLS(details services.ConnectionDetails) (*services.ConnectionTestResult, error) {
+	if a.ctx == nil {
+		return nil, fmt.Errorf("app context not initialized")
+	}
+	return a.dbService.TestConnectionAutoTLS(a.ctx, details)
+}
+
+// ListResourceGroups returns the resource groups available on the server described by details.
+// An empty list is returned when the server doesn't support resource groups.
+func (a *App) ListResourceGroups(details services.ConnectionDetails) ([]string, error) {
+	if a.ctx == nil {
+		return nil, fmt.Errorf("app context not initialized")
+	}
+	groups, supported, err := a.dbService.ListResourceGroups(a.ctx, details)
+	if err != nil {
+		return nil, err
+	}
+	if !supported {
+		services.LogInfo("Server %s does not support resource groups", details.Host)
+	}
+	return groups, nil
+}
+
+// GetCurrentUserGrants returns the active connection user's grant statements together with their parsed
+// privileges and scopes, so the UI can hide features the user lacks privileges for.
+func (a *App) GetCurrentUserGrants() (*services.UserGrants, error) {
+	if a.ctx == nil {
+		return nil, fmt.Errorf("app context not initialized")
+	}
+	conn := a.getActiveConnection()
+	if conn == nil {
+		return nil, fmt.Errorf("no active connection")
+	}
+
+	raw, err := a.dbService.GetCurrentUserGrants(a.ctx, *conn)
+	if err != nil {
+		return nil, err
+	}
+	return services.ParseGrants(raw), nil
+}
+
+// CanPerform reports whether the active connection's user can run an operation (select, insert, update
+// or delete) on a table, by probing it without changing any data.
+func (a *App) CanPerform(operation string, dbName string, tableName string) (bool, error) {
+	if a.ctx == nil {
+		return false, fmt.Errorf("app context not initialized")
+	}
+	conn := a.getActiveConnection()
+	if conn == nil {
+		return false, fmt.Errorf("no active connection")
+	}
+
+	return a.dbService.CanPerform(a.ctx, *conn, operation, dbName, tableName)
 }
 
 // ConnectUsingSaved establishes the *current active* connection using a saved connection ID.
@@ -162,6 +307,7 @@ func (a *App) ConnectUsingSaved(connectionID string) (*services.ConnectionDetail
 
 	// Test the retrieved connection
 	success, err := a.dbService.TestConnection(a.ctx, details)
+	a.recordConnectionTest(connectionID, success, err)
 	if err != nil {
 		return nil, fmt.Errorf("connection test failed for saved connection '%s': %w", details.Name, err)
 	}
@@ -169,9 +315,16 @@ func (a *App) ConnectUsingSaved(connectionID string) (*services.ConnectionDetail
 		return nil, fmt.Errorf("connection test reported failure for saved connection '%s'", details.Name)
 	}
 
-	// Store as the *active* connection for this session
-	a.activeConnection = &details
-	a.activeConnectionID = connectionID
+	// Uncommitted changes belong to the previous connection
+	if txID := a.takePendingTx(); txID != "" {
+		if err := a.dbService.RollbackTx(txID); err != nil {
+			services.LogError("Failed to roll back pending changes of the previous connection: %v", err)
+		}
+	}
+
+	// Store a copy as the *active* connection for this session
+	active := details
+	a.setActiveConnection(&active, connectionID)
 	services.LogInfo("Connection '%s' activated successfully", details.Name)
 
 	// Debug: Log the connectionID and details.ID to check for discrepancies
@@ -198,6 +351,7 @@ func (a *App) ConnectUsingSaved(connectionID string) (*services.ConnectionDetail
 
 	// Emit event to notify frontend the active session is ready
 	runtime.EventsEmit(a.ctx, "connection:established", details)
+	a.notifyCommandsChanged()
 
 	return &details, nil
 }
@@ -205,15 +359,131 @@ func (a *App) ConnectUsingSaved(connectionID string) (*services.ConnectionDetail
 // Disconnect clears the active connection details for the current session.
 func (a *App) Disconnect() {
 	services.LogInfo("Disconnecting session...")
-	a.activeConnection = nil
-	a.activeConnectionID = ""
+	a.CancelMetadataExtraction()
+	a.dbService.RollbackAllTx()
+	a.takePendingTx()
+	services.CloseSSHTunnels()
+	a.setActiveConnection(nil, "")
 	// Optionally emit an event if the frontend needs to react specifically
 	runtime.EventsEmit(a.ctx, "connection:disconnected") // Notify frontend
+	a.notifyCommandsChanged()
+}
+
+// extractionContext returns the context metadata extractions run under, starting a new one after a cancel.
+func (a *App) extractionContext() context.Context {
+	a.extractionMu.Lock()
+	defer a.extractionMu.Unlock()
+
+	if a.extractionCtx == nil || a.extractionCtx.Err() != nil {
+		a.extractionCtx, a.cancelExtraction = context.WithCancel(a.ctx)
+	}
+	return a.extractionCtx
+}
+
+// CancelMetadataExtraction stops every running metadata extraction. The in-memory metadata is left as it was
+// before the extraction started, and the frontend receives metadata:extraction:cancelled.
+func (a *App) CancelMetadataExtraction() {
+	a.extractionMu.Lock()
+	defer a.extractionMu.Unlock()
+
+	if a.cancelExtraction != nil {
+		services.LogInfo("Cancelling metadata extraction")
+		a.cancelExtraction()
+		a.cancelExtraction = nil
+	}
 }
 
 // GetActiveConnection returns the connection details for the current session.
 func (a *App) GetActiveConnection() *services.ConnectionDetails {
-	return a.activeConnection
+	return a.getActiveConnection()
+}
+
+// getActiveConnection returns a copy of the active connection details, or nil if not connected.
+func (a *App) getActiveConnection() *services.ConnectionDetails {
+	a.connMu.RLock()
+	defer a.connMu.RUnlock()
+
+	if a.activeConnection == nil {
+		return nil
+	}
+	details := *a.activeConnection
+	return &details
+}
+
+// getActiveConnectionID returns the ID of the active connection, or an empty string if not connected.
+func (a *App) getActiveConnectionID() string {
+	a.connMu.RLock()
+	defer a.connMu.RUnlock()
+
+	return a.activeConnectionID
+}
+
+// setActiveConnection replaces the active connection details and ID.
+func (a *App) setActiveConnection(details *services.ConnectionDetails, connectionID string) {
+	a.connMu.Lock()
+	defer a.connMu.Unlock()
+
+	a.activeConnection = details
+	a.activeConnectionID = connectionID
+
+	if a.stopPoolStats != nil {
+		a.stopPoolStats()
+		a.stopPoolStats = nil
+	}
+	if details != nil && a.ctx != nil {
+		ctx, cancel := context.WithCancel(a.ctx)
+		a.stopPoolStats = cancel
+		go a.emitPoolStats(ctx, *details, connectionID)
+	}
+}
+
+// poolStatsInterval is how often "pool:stats" events are emitted for the active connection
+const poolStatsInterval = 5 * time.Second
+
+// emitPoolStats emits "pool:stats" events with the active connection's pool statistics until ctx is
+// cancelled. Intervals before the first query, when there is no pool yet, are skipped.
+func (a *App) emitPoolStats(ctx context.Context, details services.ConnectionDetails, connectionID string) {
+	ticker := time.NewTicker(poolStatsInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		stats, err := a.dbService.PoolStats(details)
+		if err != nil {
+			continue
+		}
+		runtime.EventsEmit(a.ctx, "pool:stats", map[string]any{
+			"connectionId": connectionID,
+			"stats":        stats,
+		})
+	}
+}
+
+// GetPoolStats returns the pool statistics of a saved connection, or of the active connection when
+// connectionID is empty. Fails if the connection has no pool, i.e. hasn't run a query yet.
+func (a *App) GetPoolStats(connectionID string) (*services.PoolStats, error) {
+	var details services.ConnectionDetails
+	if connectionID == "" || connectionID == a.getActiveConnectionID() {
+		conn := a.getActiveConnection()
+		if conn == nil {
+			return nil, fmt.Errorf("no active connection")
+		}
+		details = *conn
+	} else {
+		saved, found, err := a.configService.GetConnection(connectionID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve saved connection '%s': %w", connectionID, err)
+		}
+		if !found {
+			return nil, fmt.Errorf("saved connection '%s' not found", connectionID)
+		}
+		details = saved
+	}
+	return a.dbService.PoolStats(details)
 }
 
 // --- Configuration Management Methods ---
@@ -223,12 +493,30 @@ func (a *App) ListSavedConnections() (map[string]services.ConnectionDetails, err
 	return a.configService.GetAllConnections()
 }
 
+// ListConnectionGroups returns the saved connections grouped by folder, for rendering as a tree.
+// Ungrouped connections are in the group with an empty name, which is always first.
+func (a *App) ListConnectionGroups() []services.ConnectionGroup {
+	return a.configService.GetConnectionsByGroup()
+}
+
+// SetConnectionGroup moves a saved connection into a group, or out of any group if group is empty.
+func (a *App) SetConnectionGroup(connectionID string, group string) error {
+	return a.configService.SetConnectionGroup(connectionID, group)
+}
+
+// ValidateConnection lists every problem with connection details, by field, for the UI to highlight before
+// saving or testing. Returns nil if the details are valid.
+func (a *App) ValidateConnection(details services.ConnectionDetails) *services.ValidationError {
+	var validationErr *services.ValidationError
+	if errors.As(a.configService.ValidateConnectionForSave(details), &validationErr) {
+		return validationErr
+	}
+	return nil
+}
+
 // SaveConnection saves or updates connection details in the config file.
 // Returns the connection ID.
 func (a *App) SaveConnection(details services.ConnectionDetails) (string, error) {
-	if details.Name == "" {
-		return "", fmt.Errorf("connection name cannot be empty")
-	}
 	services.LogInfo("Saving connection details for: %s", details.Name)
 	connectionID, err := a.configService.AddOrUpdateConnection(details)
 	if err != nil {
@@ -236,9 +524,45 @@ func (a *App) SaveConnection(details services.ConnectionDetails) (string, error)
 		return "", err
 	}
 	services.LogInfo("Connection '%s' saved successfully with ID: %s", details.Name, connectionID)
+	a.notifyCommandsChanged()
+	return connectionID, nil
+}
+
+// GetDefaultConnection returns the ID of the connection opened automatically on startup, or an empty string.
+func (a *App) GetDefaultConnection() string {
+	return a.configService.GetDefaultConnection()
+}
+
+// SetDefaultConnection sets the connection to open automatically on startup. An empty ID clears it.
+func (a *App) SetDefaultConnection(connectionID string) error {
+	services.LogInfo("Setting default connection: %q", connectionID)
+	return a.configService.SetDefaultConnection(connectionID)
+}
+
+// CloneSavedConnection saves a copy of a connection under newName (or "<name> (copy)" if empty) and returns
+// the new connection ID.
+func (a *App) CloneSavedConnection(sourceID string, newName string) (string, error) {
+	connectionID, err := a.configService.CloneConnection(sourceID, newName)
+	if err != nil {
+		return "", err
+	}
+	services.LogInfo("Connection '%s' cloned as %s", sourceID, connectionID)
+	a.notifyCommandsChanged()
 	return connectionID, nil
 }
 
+// GetEffectiveDSN returns the DSN a saved connection would use, with the password masked, for debugging.
+func (a *App) GetEffectiveDSN(connectionID string) (string, error) {
+	details, found, err := a.configService.GetConnection(connectionID)
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve saved connection '%s': %w", connectionID, err)
+	}
+	if !found {
+		return "", fmt.Errorf("saved connection '%s' not found", connectionID)
+	}
+	return services.MaskedDSN(details), nil
+}
+
 // DeleteSavedConnection removes a connection from the config file by ID.
 func (a *App) DeleteSavedConnection(connectionID string) error {
 	if connectionID == "" {
@@ -265,147 +589,1248 @@ func (a *App) DeleteSavedConnection(connectionID string) error {
 	}
 
 	// If the deleted connection was the active one, disconnect the session
-	if a.activeConnectionID == connectionID {
+	if a.getActiveConnectionID() == connectionID {
 		services.LogInfo("Disconnecting active session as it was deleted")
 		a.Disconnect()
 	}
 
+	a.notifyCommandsChanged()
 	return nil
 }
 
 // --- SQL Execution Method ---
 
+// ExecuteSQLOptions adjusts how ExecuteSQLWithOptions runs a query
+type ExecuteSQLOptions struct {
+	BypassCache bool `json:"bypassCache"` // Run the query even if a cached result exists
+}
+
+// executeOptions are the checks and shortcuts executeSQL skips for a call
+type executeOptions struct {
+	bypassSafeMode     bool
+	bypassCache        bool
+	confirmDestructive bool // Run UPDATE/DELETE without WHERE
+}
+
 // ExecuteSQL uses the *active session connection* details to execute a query.
+// UPDATE and DELETE statements without a WHERE clause are rejected, see ExecuteSQLConfirmed.
 func (a *App) ExecuteSQL(query string) (*services.SQLResult, error) {
+	return a.executeSQL(query, executeOptions{})
+}
+
+// ExecuteSQLWithOptions is ExecuteSQL with per-call options, e.g. to refresh a cached result.
+func (a *App) ExecuteSQLWithOptions(query string, opts ExecuteSQLOptions) (*services.SQLResult, error) {
+	return a.executeSQL(query, executeOptions{bypassCache: opts.BypassCache})
+}
+
+// ExecuteSQLConfirmed executes a query like ExecuteSQL; with confirm set, UPDATE and DELETE statements
+// without a WHERE clause run instead of being rejected. The frontend calls it after the user agreed in
+// the dialog shown for a "query:destructive" event.
+func (a *App) ExecuteSQLConfirmed(query string, confirm bool) (*services.SQLResult, error) {
+	if confirm {
+		services.LogInfo("Destructive statement confirmed: %s", query)
+	}
+	return a.executeSQL(query, executeOptions{confirmDestructive: confirm})
+}
+
+// ExecuteSQLOverridingSafeMode executes a query that safe mode blocked, after the user confirmed it.
+func (a *App) ExecuteSQLOverridingSafeMode(query string) (*services.SQLResult, error) {
+	services.LogInfo("Safe mode overridden for query: %s", query)
+	return a.executeSQL(query, executeOptions{bypassSafeMode: true})
+}
+
+// ExecuteNamedQuery executes a query with :name placeholders bound from params.
+func (a *App) ExecuteNamedQuery(query string, params map[string]any) (*services.SQLResult, error) {
+	boundQuery, args, err := services.BindNamedParams(query, params)
+	if err != nil {
+		return nil, err
+	}
+	return a.executeSQL(boundQuery, executeOptions{}, args...)
+}
+
+func (a *App) executeSQL(query string, opts executeOptions, args ...any) (*services.SQLResult, error) {
 	services.LogInfo("Executing SQL with active connection: %s", query)
 	if a.ctx == nil {
 		return nil, fmt.Errorf("app context not initialized")
 	}
-	if a.activeConnection == nil {
+	conn := a.getActiveConnection()
+	if conn == nil {
 		return nil, fmt.Errorf("no active database connection established for this session")
 	}
-	result, err := a.dbService.ExecuteSQL(a.ctx, *a.activeConnection, query)
-	if err != nil {
-		services.LogInfo("SQL execution failed: %v", err)
-		return nil, err
+
+	if kind := services.UnguardedWriteKind(query); kind != "" && !opts.confirmDestructive {
+		services.LogInfo("%s without WHERE needs confirmation: %s", kind, query)
+		runtime.EventsEmit(a.ctx, "query:destructive", map[string]any{
+			"query": query, // Re-run through ExecuteSQLConfirmed once the user agrees
+			"kind":  kind,
+		})
+		return nil, fmt.Errorf("%s %w; confirm to run it anyway", kind, services.ErrUnguardedWrite)
 	}
-	services.LogInfo("SQL execution completed successfully")
-	return result, nil
-}
 
-// GetVersion retrieves the database version using SELECT VERSION() query.
-func (a *App) GetVersion() (string, error) {
-	if a.ctx == nil {
-		return "", fmt.Errorf("app context not initialized")
+	// Guard against accidentally pulling entire tables into the grid
+	originalQuery := query
+	limitInjected := false
+	if limit := a.configService.GetAutoLimit(); limit > 0 {
+		query, limitInjected = services.InjectLimit(query, limit)
+		if limitInjected {
+			services.LogInfo("Appended LIMIT %d to query", limit)
+		}
 	}
-	if a.activeConnection == nil {
-		return "", fmt.Errorf("no active database connection established for this session")
+
+	// finish prepares a result for the frontend without touching the cached copy
+	finish := func(result *services.SQLResult) *services.SQLResult {
+		result.LimitInjected = limitInjected
+		if a.configService.IsColumnHintsEnabled() {
+			services.InferColumnHints(result)
+		}
+		if a.configService.IsNullsAsSentinelEnabled() {
+			services.MarkNulls(result.Rows)
+		}
+		return result
 	}
 
-	result, err := a.dbService.ExecuteSQL(a.ctx, *a.activeConnection, "SELECT VERSION();")
+	// With autocommit off, writes start a pending transaction that later statements also run in
+	txID, err := a.pendingTxFor(*conn, query)
 	if err != nil {
-		services.LogInfo("Failed to get database version: %v", err)
-		return "", fmt.Errorf("failed to get database version: %w", err)
+		return nil, err
 	}
 
-	if len(result.Rows) == 0 {
-		return "", fmt.Errorf("no version information returned from database")
+	cacheTTL := a.configService.GetQueryCacheTTL()
+	if txID != "" {
+		cacheTTL = 0 // Results may include uncommitted changes
 	}
-
-	// Get the column name from the first row
-	row := result.Rows[0]
-	if len(row) == 0 {
-		return "", fmt.Errorf("empty version result returned from database")
+	if cacheTTL > 0 && !opts.bypassCache {
+		if cached, ok := a.queryCache.Get(*conn, query, args, cacheTTL); ok {
+			services.LogInfo("Serving cached result")
+			cached.Cached = true
+			return finish(cached), nil
+		}
 	}
 
-	// VERSION() returns a single column, get the first value
-	var version string
-	for _, v := range row {
-		if versionStr, ok := v.(string); ok {
-			version = versionStr
-			break
+	if conn.SafeMode && !opts.bypassSafeMode {
+		reason, err := a.dbService.CheckFullScanSafety(a.ctx, *conn, query, conn.SafeModeRowThreshold, args...)
+		if err != nil {
+			// Don't block on EXPLAIN failures; the query itself will surface the real error
+			services.LogInfo("Warning: Safe mode check failed, executing anyway: %v", err)
+		} else if reason != "" {
+			services.LogInfo("Query blocked by safe mode: %s", reason)
+			runtime.EventsEmit(a.ctx, "query:blocked", map[string]any{
+				"query":  originalQuery, // Re-run through ExecuteSQLOverridingSafeMode as typed
+				"reason": reason,
+			})
+			return nil, fmt.Errorf("query blocked by safe mode: %s", reason)
 		}
 	}
 
-	if version == "" {
-		return "", fmt.Errorf("version information is not a string or is empty")
+	queryID := strconv.FormatUint(a.nextQueryID.Add(1), 10)
+	ctx, cancel := services.WithQueryTimeout(a.ctx, *conn)
+	a.queryMu.Lock()
+	a.queries[queryID] = cancel
+	a.queryMu.Unlock()
+	defer func() {
+		a.queryMu.Lock()
+		delete(a.queries, queryID)
+		a.queryMu.Unlock()
+		cancel()
+	}()
+	runtime.EventsEmit(a.ctx, "query:started", map[string]any{"queryId": queryID, "query": query})
+
+	var result *services.SQLResult
+	if txID != "" {
+		result, err = a.dbService.ExecuteInTx(ctx, txID, query, args...)
+	} else {
+		result, err = a.dbService.ExecuteSQL(ctx, *conn, query, args...)
+	}
+	if err != nil {
+		err = services.QueryContextError(ctx, err)
+		services.LogInfo("SQL execution failed: %v", err)
+		return nil, err
+	}
+	services.LogInfo("SQL execution completed successfully")
+	a.queryCache.InvalidateForStatement(*conn, query)
+	if cacheTTL > 0 {
+		a.queryCache.Put(*conn, query, args, result)
 	}
+	return finish(result), nil
+}
 
-	services.LogInfo("Database version retrieved: %s", version)
-	return version, nil
+// ClearQueryCache drops all cached query results.
+func (a *App) ClearQueryCache() {
+	services.LogInfo("Clearing query result cache")
+	a.queryCache.Clear()
 }
 
-// ListDatabases retrieves a list of database/schema names accessible by the connection.
-func (a *App) ListDatabases() ([]string, error) {
+// ExecuteScript runs the semicolon-separated statements of a script one after another on a single
+// connection and returns each statement's result or error. With stopOnError, statements after the
+// first failure are skipped. Like ExecuteSQL, a script containing UPDATE or DELETE without WHERE is
+// rejected unless confirmDestructive is set, and with autocommit off a script that writes runs in the
+// pending transaction.
+func (a *App) ExecuteScript(script string, stopOnError bool, confirmDestructive bool) ([]services.StatementResult, error) {
 	if a.ctx == nil {
 		return nil, fmt.Errorf("app context not initialized")
 	}
-	if a.activeConnection == nil {
+	conn := a.getActiveConnection()
+	if conn == nil {
 		return nil, fmt.Errorf("no active connection")
 	}
 
-	// Delegate to DatabaseService
-	return a.dbService.ListDatabases(a.ctx, *a.activeConnection)
+	statements := services.SplitStatements(script)
+	if !confirmDestructive {
+		for _, stmt := range statements {
+			if kind := services.UnguardedWriteKind(stmt.SQL); kind != "" {
+				runtime.EventsEmit(a.ctx, "query:destructive", map[string]any{
+					"query": stmt.SQL,
+					"kind":  kind,
+					"line":  stmt.Line,
+				})
+				return nil, fmt.Errorf("%s on line %d %w; confirm to run the script anyway", kind, stmt.Line, services.ErrUnguardedWrite)
+			}
+		}
+	}
+
+	txID := ""
+	if len(statements) > 0 {
+		// The transaction is decided by the first statement that writes, if any
+		probe := statements[0].SQL
+		for _, stmt := range statements {
+			if !services.IsReadOnlyStatement(stmt.SQL) {
+				probe = stmt.SQL
+				break
+			}
+		}
+		var err error
+		if txID, err = a.pendingTxFor(*conn, probe); err != nil {
+			return nil, err
+		}
+	}
+
+	opts := services.ScriptOptions{StopOnError: stopOnError}
+	var results []services.StatementResult
+	var err error
+	if txID != "" {
+		results, err = a.dbService.ExecuteScriptInTx(a.ctx, txID, script, opts)
+	} else {
+		results, err = a.dbService.ExecuteScript(a.ctx, *conn, script, opts)
+	}
+	for _, stmt := range statements {
+		a.queryCache.InvalidateForStatement(*conn, stmt.SQL)
+	}
+	return results, err
 }
 
-// ListTables retrieves a list of table names from the specified database.
-// If dbName is empty, it uses the database specified in the active connection details.
-func (a *App) ListTables(dbName string) ([]string, error) {
+// CancelQuery stops a running query by the ID announced in its "query:started" event. The query then
+// fails with a "query cancelled" error. Returns false if no such query is running.
+func (a *App) CancelQuery(queryID string) bool {
+	a.queryMu.Lock()
+	cancel, ok := a.queries[queryID]
+	a.queryMu.Unlock()
+	if ok {
+		services.LogInfo("Cancelling query %s", queryID)
+		cancel()
+	}
+	return ok
+}
+
+// streamBatchSize is the number of rows sent per "query:rows:batch" event
+const streamBatchSize = 500
+
+// StreamQuery runs a query on the active connection and sends its rows to the frontend in
+// "query:rows:batch" events ({streamId, offset, rows}) instead of returning them, so results larger
+// than memory can be viewed. streamID is chosen by the caller to tell concurrent streams apart.
+// Returns once all rows were sent; the summary carries the column order.
+func (a *App) StreamQuery(streamID string, query string) (*services.StreamSummary, error) {
 	if a.ctx == nil {
 		return nil, fmt.Errorf("app context not initialized")
 	}
-	if a.activeConnection == nil {
+	conn := a.getActiveConnection()
+	if conn == nil {
 		return nil, fmt.Errorf("no active connection")
 	}
+	if !services.IsReadOnlyStatement(query) {
+		if err := autoCommitRequired(*conn, "streaming a statement that writes"); err != nil {
+			return nil, err
+		}
+	}
 
-	// Delegate to DatabaseService
-	return a.dbService.ListTables(a.ctx, *a.activeConnection, dbName)
+	var offset int64
+	batch := make([]map[string]any, 0, streamBatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		runtime.EventsEmit(a.ctx, "query:rows:batch", map[string]any{
+			"streamId": streamID,
+			"offset":   offset,
+			"rows":     batch,
+		})
+		offset += int64(len(batch))
+		batch = make([]map[string]any, 0, streamBatchSize)
+	}
+
+	summary, err := a.dbService.ExecuteSQLStream(a.ctx, *conn, query, func(row map[string]any) error {
+		batch = append(batch, row)
+		if len(batch) == streamBatchSize {
+			flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	flush()
+	return summary, nil
 }
 
-// GetTableData retrieves data (rows and columns) for a specific table with pagination and filtering.
-// filterParams is a placeholder for potential future filtering implementation.
-func (a *App) GetTableData(dbName string, tableName string, limit int, offset int, filterParams *map[string]any) (*services.TableDataResponse, error) {
+// --- Transactions ---
+
+// BeginTx starts a transaction on the active connection and returns its ID. The transaction is rolled back
+// on disconnect, on shutdown, or after being idle for services.TxIdleTimeout ("transaction:expired" event).
+func (a *App) BeginTx() (string, error) {
 	if a.ctx == nil {
-		return nil, fmt.Errorf("app context not initialized")
+		return "", fmt.Errorf("app context not initialized")
 	}
-	if a.activeConnection == nil {
-		return nil, fmt.Errorf("no active connection")
+	conn := a.getActiveConnection()
+	if conn == nil {
+		return "", fmt.Errorf("no active connection")
 	}
 
-	// Delegate to DatabaseService
-	return a.dbService.GetTableData(a.ctx, *a.activeConnection, dbName, tableName, limit, offset, filterParams)
+	return a.dbService.BeginTx(a.ctx, *conn)
 }
 
-// GetTableSchema retrieves the detailed schema/structure for a specific table.
-func (a *App) GetTableSchema(dbName string, tableName string) (*services.TableSchema, error) {
+// ExecuteInTx executes a query inside a transaction started with BeginTx. Like ExecuteSQL, UPDATE and
+// DELETE statements without a WHERE clause are rejected unless confirmDestructive is set.
+func (a *App) ExecuteInTx(txID string, query string, confirmDestructive bool) (*services.SQLResult, error) {
 	if a.ctx == nil {
 		return nil, fmt.Errorf("app context not initialized")
 	}
-	if a.activeConnection == nil {
-		return nil, fmt.Errorf("no active connection")
+	if kind := services.UnguardedWriteKind(query); kind != "" {
+		if !confirmDestructive {
+			services.LogInfo("%s without WHERE needs confirmation: %s", kind, query)
+			runtime.EventsEmit(a.ctx, "query:destructive", map[string]any{
+				"query": query, // Re-run through ExecuteInTx with confirmDestructive once the user agrees
+				"kind":  kind,
+				"txId":  txID,
+			})
+			return nil, fmt.Errorf("%s %w; confirm to run it anyway", kind, services.ErrUnguardedWrite)
+		}
+		services.LogInfo("Destructive statement confirmed in transaction %s: %s", txID, query)
 	}
-
-	// Delegate to DatabaseService
-	return a.dbService.GetTableSchema(a.ctx, *a.activeConnection, dbName, tableName)
+	return a.dbService.ExecuteInTx(a.ctx, txID, query)
 }
 
-// --- Theme Settings ---
-
-// GetThemeSettings retrieves the currently saved theme settings.
-func (a *App) GetThemeSettings() (*services.ThemeSettings, error) {
-	if a.configService == nil {
-		return nil, fmt.Errorf("config service not initialized")
+// CommitTx commits a transaction started with BeginTx.
+func (a *App) CommitTx(txID string) error {
+	if err := a.dbService.CommitTx(txID); err != nil {
+		return err
 	}
-	return a.configService.GetThemeSettings()
+	// The transaction's writes aren't tracked, so any cached result may be stale
+	a.queryCache.Clear()
+	return nil
 }
 
-// SaveThemeSettings saves the provided theme settings to the config file.
-func (a *App) SaveThemeSettings(settings services.ThemeSettings) error {
-	services.LogInfo("Saving theme settings: %+v\n", settings)
-	if a.configService == nil {
-		return fmt.Errorf("config service not initialized")
-	}
-	return a.configService.SaveThemeSettings(settings)
+// RollbackTx rolls back a transaction started with BeginTx.
+func (a *App) RollbackTx(txID string) error {
+	return a.dbService.RollbackTx(txID)
+}
+
+// pendingTxFor returns the transaction a statement must run in when the connection has autocommit off: the
+// pending transaction if there is one, else a new one for a statement that writes. It returns "" for
+// autocommit connections and for reads while nothing is pending.
+func (a *App) pendingTxFor(conn services.ConnectionDetails, query string) (string, error) {
+	if services.AutoCommitEnabled(conn) {
+		return "", nil
+	}
+
+	a.pendingMu.Lock()
+	defer a.pendingMu.Unlock()
+
+	if a.pendingTxID != "" || services.IsReadOnlyStatement(query) {
+		return a.pendingTxID, nil
+	}
+	txID, err := a.dbService.BeginTx(a.ctx, conn)
+	if err != nil {
+		return "", err
+	}
+	a.pendingTxID = txID
+	runtime.EventsEmit(a.ctx, "transaction:pending", true)
+	return txID, nil
+}
+
+// autoCommitRequired rejects an operation that commits on its own, so it can't join the pending transaction,
+// while the connection has autocommit off.
+func autoCommitRequired(conn services.ConnectionDetails, operation string) error {
+	if services.AutoCommitEnabled(conn) {
+		return nil
+	}
+	return fmt.Errorf("%s isn't possible while autocommit is off; turn autocommit on first", operation)
+}
+
+// takePendingTx forgets the pending transaction and returns its ID, "" if there is none. The frontend
+// receives "transaction:pending" false.
+func (a *App) takePendingTx() string {
+	a.pendingMu.Lock()
+	defer a.pendingMu.Unlock()
+
+	txID := a.pendingTxID
+	if txID != "" {
+		a.pendingTxID = ""
+		runtime.EventsEmit(a.ctx, "transaction:pending", false)
+	}
+	return txID
+}
+
+// HasPendingChanges reports whether writes made with autocommit off are waiting to be committed or rolled back.
+func (a *App) HasPendingChanges() bool {
+	a.pendingMu.Lock()
+	defer a.pendingMu.Unlock()
+
+	return a.pendingTxID != ""
+}
+
+// CommitPendingChanges commits the writes made since autocommit was turned off or the last commit.
+func (a *App) CommitPendingChanges() error {
+	txID := a.takePendingTx()
+	if txID == "" {
+		return fmt.Errorf("no pending changes to commit")
+	}
+	return a.CommitTx(txID)
+}
+
+// RollbackPendingChanges discards the writes made since autocommit was turned off or the last commit.
+func (a *App) RollbackPendingChanges() error {
+	txID := a.takePendingTx()
+	if txID == "" {
+		return fmt.Errorf("no pending changes to roll back")
+	}
+	// Cached results never include uncommitted changes, so there is nothing to invalidate
+	return a.RollbackTx(txID)
+}
+
+// SetAutoCommit turns autocommit on or off for the active session until the next connect, overriding the
+// saved connection's setting. Pending changes must be committed or rolled back before turning it back on.
+func (a *App) SetAutoCommit(enabled bool) error {
+	if enabled && a.HasPendingChanges() {
+		return fmt.Errorf("commit or roll back the pending changes first")
+	}
+
+	a.connMu.Lock()
+	defer a.connMu.Unlock()
+
+	if a.activeConnection == nil {
+		return fmt.Errorf("no active connection")
+	}
+	a.activeConnection.AutoCommit = &enabled
+	services.LogInfo("Autocommit %v for the active session", enabled)
+	return nil
+}
+
+// GetVersion retrieves the database version using SELECT VERSION() query.
+func (a *App) GetVersion() (string, error) {
+	if a.ctx == nil {
+		return "", fmt.Errorf("app context not initialized")
+	}
+	conn := a.getActiveConnection()
+	if conn == nil {
+		return "", fmt.Errorf("no active database connection established for this session")
+	}
+
+	result, err := a.dbService.ExecuteSQL(a.ctx, *conn, "SELECT VERSION();")
+	if err != nil {
+		services.LogInfo("Failed to get database version: %v", err)
+		return "", fmt.Errorf("failed to get database version: %w", err)
+	}
+
+	if len(result.Rows) == 0 {
+		return "", fmt.Errorf("no version information returned from database")
+	}
+
+	// Get the column name from the first row
+	row := result.Rows[0]
+	if len(row) == 0 {
+		return "", fmt.Errorf("empty version result returned from database")
+	}
+
+	// VERSION() returns a single column, get the first value
+	var version string
+	for _, v := range row {
+		if versionStr, ok := v.(string); ok {
+			version = versionStr
+			break
+		}
+	}
+
+	if version == "" {
+		return "", fmt.Errorf("version information is not a string or is empty")
+	}
+
+	services.LogInfo("Database version retrieved: %s", version)
+	return version, nil
+}
+
+// ListDatabases retrieves a list of database/schema names accessible by the connection.
+func (a *App) ListDatabases() ([]string, error) {
+	if a.ctx == nil {
+		return nil, fmt.Errorf("app context not initialized")
+	}
+	conn := a.getActiveConnection()
+	if conn == nil {
+		return nil, fmt.Errorf("no active connection")
+	}
+
+	// Delegate to DatabaseService
+	return a.dbService.ListDatabases(a.ctx, *conn)
+}
+
+// ListTables retrieves a list of table names from the specified database.
+// If dbName is empty, it uses the database specified in the active connection details.
+func (a *App) ListTables(dbName string) ([]string, error) {
+	if a.ctx == nil {
+		return nil, fmt.Errorf("app context not initialized")
+	}
+	conn := a.getActiveConnection()
+	if conn == nil {
+		return nil, fmt.Errorf("no active connection")
+	}
+
+	// Delegate to DatabaseService
+	return a.dbService.ListTables(a.ctx, *conn, dbName)
+}
+
+// GetTableData retrieves data (rows and columns) for a specific table with pagination and filtering.
+// filterParams is a placeholder for potential future filtering implementation.
+func (a *App) GetTableData(dbName string, tableName string, limit int, offset int, filterParams *map[string]any) (*services.TableDataResponse, error) {
+	if a.ctx == nil {
+		return nil, fmt.Errorf("app context not initialized")
+	}
+	conn := a.getActiveConnection()
+	if conn == nil {
+		return nil, fmt.Errorf("no active connection")
+	}
+
+	if limit <= 0 {
+		targetDB := dbName
+		if targetDB == "" {
+			targetDB = conn.DBName
+		}
+		limit = a.configService.GetPageSize(a.getActiveConnectionID(), targetDB, tableName)
+	}
+
+	// Delegate to DatabaseService
+	resp, err := a.dbService.GetTableData(a.ctx, *conn, dbName, tableName, limit, offset, filterParams)
+	if err != nil {
+		return nil, err
+	}
+	if a.configService.IsNullsAsSentinelEnabled() {
+		services.MarkNulls(resp.Rows)
+	}
+	resp.NullDisplay, _ = a.configService.GetNullDisplay()
+	return resp, nil
+}
+
+// GetTableDataKeyset fetches a page of rows ordered by a single-column key, starting after afterValue.
+// An empty pkColumn uses the table's primary key.
+func (a *App) GetTableDataKeyset(dbName string, tableName string, pkColumn string, afterValue any, limit int) (*services.KeysetPageResponse, error) {
+	if a.ctx == nil {
+		return nil, fmt.Errorf("app context not initialized")
+	}
+	conn := a.getActiveConnection()
+	if conn == nil {
+		return nil, fmt.Errorf("no active connection")
+	}
+
+	if limit <= 0 {
+		targetDB := dbName
+		if targetDB == "" {
+			targetDB = conn.DBName
+		}
+		limit = a.configService.GetPageSize(a.getActiveConnectionID(), targetDB, tableName)
+	}
+
+	return a.dbService.GetTableDataKeyset(a.ctx, *conn, dbName, tableName, pkColumn, afterValue, limit)
+}
+
+// GetTableSchema retrieves the detailed schema/structure for a specific table.
+func (a *App) GetTableSchema(dbName string, tableName string) (*services.TableSchema, error) {
+	if a.ctx == nil {
+		return nil, fmt.Errorf("app context not initialized")
+	}
+	conn := a.getActiveConnection()
+	if conn == nil {
+		return nil, fmt.Errorf("no active connection")
+	}
+
+	// Delegate to DatabaseService
+	return a.dbService.GetTableSchema(a.ctx, *conn, dbName, tableName)
+}
+
+// ResolveRowKey returns the columns that identify single rows of a table for editing: the primary key,
+// a NOT NULL unique index or TiDB's hidden row ID.
+func (a *App) ResolveRowKey(dbName string, tableName string) (*services.RowKey, error) {
+	if a.ctx == nil {
+		return nil, fmt.Errorf("app context not initialized")
+	}
+	conn := a.getActiveConnection()
+	if conn == nil {
+		return nil, fmt.Errorf("no active connection")
+	}
+
+	return a.dbService.ResolveRowKey(a.ctx, *conn, dbName, tableName)
+}
+
+// GetRowContext retrieves a row together with its neighbors ordered by the table's primary key.
+func (a *App) GetRowContext(dbName string, tableName string, pkValue any, before int, after int) (*services.RowContextResponse, error) {
+	if a.ctx == nil {
+		return nil, fmt.Errorf("app context not initialized")
+	}
+	conn := a.getActiveConnection()
+	if conn == nil {
+		return nil, fmt.Errorf("no active connection")
+	}
+
+	return a.dbService.GetRowsAroundPK(a.ctx, *conn, dbName, tableName, pkValue, before, after)
+}
+
+// DiffTableWithCSV compares a table with a CSV file and reports the inserts, updates and deletes
+// an import would cause, without applying anything.
+func (a *App) DiffTableWithCSV(dbName string, tableName string, csvPath string, keyColumns []string) (*services.DataDiff, error) {
+	if a.ctx == nil {
+		return nil, fmt.Errorf("app context not initialized")
+	}
+	conn := a.getActiveConnection()
+	if conn == nil {
+		return nil, fmt.Errorf("no active connection")
+	}
+
+	f, err := os.Open(csvPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open CSV file: %w", err)
+	}
+	defer f.Close()
+
+	return a.dbService.DiffTableWithCSV(a.ctx, *conn, dbName, tableName, f, keyColumns)
+}
+
+// DiffQueryResults compares two query results, ignoring row order unless orderSensitive is set.
+func (a *App) DiffQueryResults(left *services.SQLResult, right *services.SQLResult, orderSensitive bool) (*services.ResultDiff, error) {
+	return services.DiffQueryResults(left, right, orderSensitive)
+}
+
+// GetEnumValueUsage counts how often each declared value of an ENUM or SET column is used.
+func (a *App) GetEnumValueUsage(dbName string, tableName string, column string) (map[string]int64, error) {
+	if a.ctx == nil {
+		return nil, fmt.Errorf("app context not initialized")
+	}
+	conn := a.getActiveConnection()
+	if conn == nil {
+		return nil, fmt.Errorf("no active connection")
+	}
+
+	return a.dbService.GetEnumValueUsage(a.ctx, *conn, dbName, tableName, column)
+}
+
+// EstimateIndexImpact estimates the size and write cost of adding an index on columns of a table.
+// It is advisory only and doesn't change anything.
+func (a *App) EstimateIndexImpact(dbName string, tableName string, columns []string) (*services.IndexImpact, error) {
+	if a.ctx == nil {
+		return nil, fmt.Errorf("app context not initialized")
+	}
+	conn := a.getActiveConnection()
+	if conn == nil {
+		return nil, fmt.Errorf("no active connection")
+	}
+
+	return a.dbService.EstimateIndexImpact(a.ctx, *conn, dbName, tableName, columns)
+}
+
+// GetTableTimestamps returns the creation and last update time of every table in a database.
+func (a *App) GetTableTimestamps(dbName string) ([]services.TableTimestamps, error) {
+	if a.ctx == nil {
+		return nil, fmt.Errorf("app context not initialized")
+	}
+	conn := a.getActiveConnection()
+	if conn == nil {
+		return nil, fmt.Errorf("no active connection")
+	}
+
+	return a.dbService.GetTableTimestamps(a.ctx, *conn, dbName)
+}
+
+// GetQueryResultSchema returns the result columns and types of a SELECT without fetching any rows.
+func (a *App) GetQueryResultSchema(dbName string, query string) ([]services.ColumnTypeInfo, error) {
+	if a.ctx == nil {
+		return nil, fmt.Errorf("app context not initialized")
+	}
+	conn := a.getActiveConnection()
+	if conn == nil {
+		return nil, fmt.Errorf("no active connection")
+	}
+
+	return a.dbService.GetQueryResultSchema(a.ctx, *conn, dbName, query)
+}
+
+// GetLongRunningQueries returns the sessions that have been running a statement for at least minSeconds,
+// longest first, leaving out idle connections.
+func (a *App) GetLongRunningQueries(minSeconds int) ([]services.ProcessInfo, error) {
+	if a.ctx == nil {
+		return nil, fmt.Errorf("app context not initialized")
+	}
+	conn := a.getActiveConnection()
+	if conn == nil {
+		return nil, fmt.Errorf("no active connection")
+	}
+
+	return a.dbService.GetLongRunningQueries(a.ctx, *conn, minSeconds)
+}
+
+// GetTiFlashReplicaStatus returns the TiFlash replica status of the tables in a database.
+// The result is marked unsupported, rather than failing, on servers without TiFlash.
+func (a *App) GetTiFlashReplicaStatus(dbName string) (*services.TiFlashStatus, error) {
+	if a.ctx == nil {
+		return nil, fmt.Errorf("app context not initialized")
+	}
+	conn := a.getActiveConnection()
+	if conn == nil {
+		return nil, fmt.Errorf("no active connection")
+	}
+
+	return a.dbService.GetTiFlashReplicaStatus(a.ctx, *conn, dbName)
+}
+
+const (
+	tiflashPollInterval = 3 * time.Second
+	tiflashPollTimeout  = 30 * time.Minute
+)
+
+// SetTableTiFlashReplica sets the number of TiFlash replicas of a table (0 removes them), then emits
+// "tiflash:replica:progress" events with the table's replica status until it is fully synced.
+func (a *App) SetTableTiFlashReplica(dbName string, tableName string, count int) error {
+	if a.ctx == nil {
+		return fmt.Errorf("app context not initialized")
+	}
+	conn := a.getActiveConnection()
+	if conn == nil {
+		return fmt.Errorf("no active connection")
+	}
+
+	if err := autoCommitRequired(*conn, "changing TiFlash replicas"); err != nil {
+		return err
+	}
+
+	if err := a.dbService.SetTiFlashReplica(a.ctx, *conn, dbName, tableName, count); err != nil {
+		return err
+	}
+
+	go a.pollTiFlashReplica(*conn, dbName, tableName)
+	return nil
+}
+
+// pollTiFlashReplica emits the replica status of a table until it is available and fully synced,
+// the replicas are gone, or polling times out.
+func (a *App) pollTiFlashReplica(conn services.ConnectionDetails, dbName string, tableName string) {
+	ticker := time.NewTicker(tiflashPollInterval)
+	defer ticker.Stop()
+	deadline := time.Now().Add(tiflashPollTimeout)
+
+	for {
+		status, err := a.dbService.GetTiFlashReplicaStatus(a.ctx, conn, dbName)
+		if err != nil {
+			services.LogError("Failed to poll TiFlash replica status for %s.%s: %v", dbName, tableName, err)
+			return
+		}
+
+		var replica *services.TiFlashReplica
+		for i := range status.Replicas {
+			if status.Replicas[i].TableName == tableName {
+				replica = &status.Replicas[i]
+				break
+			}
+		}
+		runtime.EventsEmit(a.ctx, "tiflash:replica:progress", map[string]any{
+			"dbName":    dbName,
+			"tableName": tableName,
+			"replica":   replica, // nil once replicas are removed
+		})
+
+		if replica == nil || (replica.Available && replica.Progress >= 1) {
+			return
+		}
+		if time.Now().After(deadline) {
+			services.LogWarning("Stopped polling TiFlash replica status for %s.%s after %v", dbName, tableName, tiflashPollTimeout)
+			return
+		}
+
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// AnalyzeEstimationAccuracy runs EXPLAIN ANALYZE on a query and reports per-operator estimation errors.
+// The query is executed as part of the analysis.
+func (a *App) AnalyzeEstimationAccuracy(dbName string, query string) (*services.EstimationReport, error) {
+	if a.ctx == nil {
+		return nil, fmt.Errorf("app context not initialized")
+	}
+	conn := a.getActiveConnection()
+	if conn == nil {
+		return nil, fmt.Errorf("no active connection")
+	}
+	if !services.IsReadOnlyStatement(query) {
+		if err := autoCommitRequired(*conn, "analyzing a statement that writes"); err != nil {
+			return nil, err
+		}
+	}
+
+	return a.dbService.AnalyzeEstimationAccuracy(a.ctx, *conn, dbName, query)
+}
+
+// --- Data Export ---
+
+// ExportWithTemplate runs a query on the active connection and writes every row through a Go text/template
+// to a file chosen by the user. Returns the file path, or an empty string if the dialog was cancelled.
+func (a *App) ExportWithTemplate(query string, goTemplate string) (string, error) {
+	if a.ctx == nil {
+		return "", fmt.Errorf("app context not initialized")
+	}
+	conn := a.getActiveConnection()
+	if conn == nil {
+		return "", fmt.Errorf("no active connection")
+	}
+	if !services.IsReadOnlyStatement(query) {
+		if err := autoCommitRequired(*conn, "exporting a statement that writes"); err != nil {
+			return "", err
+		}
+	}
+
+	filePath, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Export Query Results",
+		DefaultFilename: "export.txt",
+	})
+	if err != nil || filePath == "" {
+		return "", err
+	}
+
+	f, err := os.Create(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to create export file: %w", err)
+	}
+	defer f.Close()
+
+	if err := a.dbService.ExportWithTemplate(a.ctx, *conn, query, goTemplate, f); err != nil {
+		return "", err
+	}
+	services.LogInfo("Query results exported to %s", filePath)
+	return filePath, nil
+}
+
+// ExportQueryToFile runs a query on the active connection and streams its rows to a file. format is
+// "csv", "json" or "ndjson". When path is empty the user picks the file in a save dialog. Returns the
+// file path, or an empty string if the dialog was cancelled.
+func (a *App) ExportQueryToFile(query string, path string, format string) (string, error) {
+	return a.exportToFile(query, path, format, "export")
+}
+
+// ExportTableToFile exports the rows of a table matching the data grid's filters, like ExportQueryToFile.
+func (a *App) ExportTableToFile(dbName string, tableName string, path string, format string, filterParams *map[string]any) (string, error) {
+	if tableName == "" {
+		return "", fmt.Errorf("table name is required")
+	}
+	if dbName == "" {
+		if conn := a.getActiveConnection(); conn != nil {
+			dbName = conn.DBName
+		}
+	}
+	if dbName == "" {
+		return "", fmt.Errorf("database name is required")
+	}
+	query, args, err := services.BuildTableQuery(dbName, tableName, filterParams)
+	if err != nil {
+		return "", err
+	}
+	return a.exportToFile(query, path, format, tableName, args...)
+}
+
+// exportToFile streams the rows of query to path in the given format. defaultName names the file
+// suggested by the save dialog when path is empty. Optional args are bound to `?` placeholders.
+func (a *App) exportToFile(query string, path string, format string, defaultName string, args ...any) (string, error) {
+	if a.ctx == nil {
+		return "", fmt.Errorf("app context not initialized")
+	}
+	conn := a.getActiveConnection()
+	if conn == nil {
+		return "", fmt.Errorf("no active connection")
+	}
+	if !services.IsReadOnlyStatement(query) {
+		if err := autoCommitRequired(*conn, "exporting a statement that writes"); err != nil {
+			return "", err
+		}
+	}
+
+	format = strings.ToLower(format)
+	var export func(w io.Writer) error
+	switch format {
+	case "csv":
+		opts := services.DefaultCSVOptions()
+		if display, custom := a.configService.GetNullDisplay(); custom {
+			opts.NullString = display
+		}
+		export = func(w io.Writer) error {
+			return a.dbService.ExportQueryCSV(a.ctx, *conn, query, w, opts, args...)
+		}
+	case "json", "ndjson":
+		export = func(w io.Writer) error {
+			return a.dbService.ExportQueryJSON(a.ctx, *conn, query, w, services.JSONOptions{NDJSON: format == "ndjson"}, args...)
+		}
+	default:
+		return "", fmt.Errorf("unsupported export format '%s'", format)
+	}
+
+	if path == "" {
+		var err error
+		path, err = runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+			Title:           "Export Query Results",
+			DefaultFilename: defaultName + "." + format,
+		})
+		if err != nil || path == "" {
+			return "", err
+		}
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to create export file: %w", err)
+	}
+	defer f.Close()
+
+	if err := export(f); err != nil {
+		return "", err
+	}
+	services.LogInfo("Query results exported to %s", path)
+	return path, nil
+}
+
+// ExportTableSQL asks for a destination and writes a table as INSERT statements, optionally preceded by
+// its CREATE TABLE statement. Returns the file path, or an empty string if the dialog was cancelled.
+func (a *App) ExportTableSQL(dbName string, tableName string, includeDDL bool) (string, error) {
+	return a.exportTableSQLFile(dbName, tableName, services.SQLDumpOptions{IncludeDDL: includeDDL})
+}
+
+// ExportTableUpserts is like ExportTableSQL but writes INSERT ... ON DUPLICATE KEY UPDATE statements,
+// so the file can be applied repeatedly to a table that already holds some of the rows. The table needs
+// a primary key or NOT NULL unique index.
+func (a *App) ExportTableUpserts(dbName string, tableName string, includeDDL bool) (string, error) {
+	return a.exportTableSQLFile(dbName, tableName, services.SQLDumpOptions{IncludeDDL: includeDDL, Upsert: true})
+}
+
+// exportTableSQLFile asks for a destination and dumps a table to it with the given options.
+func (a *App) exportTableSQLFile(dbName string, tableName string, opts services.SQLDumpOptions) (string, error) {
+	if a.ctx == nil {
+		return "", fmt.Errorf("app context not initialized")
+	}
+	conn := a.getActiveConnection()
+	if conn == nil {
+		return "", fmt.Errorf("no active connection")
+	}
+
+	filePath, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Export Table as SQL",
+		DefaultFilename: tableName + ".sql",
+		Filters:         []runtime.FileFilter{{DisplayName: "SQL Files (*.sql)", Pattern: "*.sql"}},
+	})
+	if err != nil || filePath == "" {
+		return "", err
+	}
+
+	f, err := os.Create(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to create export file: %w", err)
+	}
+	defer f.Close()
+
+	if err := a.dbService.ExportTableSQL(a.ctx, *conn, dbName, tableName, f, opts); err != nil {
+		return "", err
+	}
+	services.LogInfo("Table %s.%s exported to %s", dbName, tableName, filePath)
+	return filePath, nil
+}
+
+// ExportTableResumable exports a table to a file as "csv" or "sql" INSERT statements in row key order,
+// checkpointing progress next to the file. If the export is interrupted, calling it again with resume and
+// the same path continues after the last checkpoint. When path is empty the user picks the file in a save
+// dialog. Returns the file path, or an empty string if the dialog was cancelled.
+func (a *App) ExportTableResumable(dbName string, tableName string, path string, format string, includeDDL bool, resume bool) (string, error) {
+	if a.ctx == nil {
+		return "", fmt.Errorf("app context not initialized")
+	}
+	conn := a.getActiveConnection()
+	if conn == nil {
+		return "", fmt.Errorf("no active connection")
+	}
+
+	opts := services.TableExportOptions{
+		Format: strings.ToLower(format),
+		CSV:    services.DefaultCSVOptions(),
+		SQL:    services.SQLDumpOptions{IncludeDDL: includeDDL},
+	}
+	if display, custom := a.configService.GetNullDisplay(); custom {
+		opts.CSV.NullString = display
+	}
+
+	if path == "" {
+		var err error
+		path, err = runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+			Title:           "Export Table",
+			DefaultFilename: tableName + "." + opts.Format,
+		})
+		if err != nil || path == "" {
+			return "", err
+		}
+	}
+
+	if _, err := a.dbService.ExportTableResumable(a.ctx, *conn, dbName, tableName, path, opts, resume); err != nil {
+		return "", err
+	}
+	services.LogInfo("Table %s.%s exported to %s", dbName, tableName, path)
+	return path, nil
+}
+
+// GetExportCheckpoint returns the checkpoint of an interrupted export to path, so the frontend can offer to
+// resume it, or nil if there is none.
+func (a *App) GetExportCheckpoint(path string) (*services.ExportCheckpoint, error) {
+	return services.LoadExportCheckpoint(path)
+}
+
+// ImportCSV asks for a CSV file and loads it into a table of the active connection, emitting
+// "import:progress" events after each batch. The returned report lists every skipped record. Returns
+// nil if the dialog was cancelled.
+func (a *App) ImportCSV(dbName string, tableName string, opts services.ImportOptions) (*services.ImportResult, error) {
+	if a.ctx == nil {
+		return nil, fmt.Errorf("app context not initialized")
+	}
+	conn := a.getActiveConnection()
+	if conn == nil {
+		return nil, fmt.Errorf("no active connection")
+	}
+	// Batches commit as they go, so an import can't be part of the pending transaction
+	if err := autoCommitRequired(*conn, "importing CSV"); err != nil {
+		return nil, err
+	}
+
+	filePath, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title:   "Import CSV into " + tableName,
+		Filters: []runtime.FileFilter{{DisplayName: "CSV Files (*.csv)", Pattern: "*.csv"}},
+	})
+	if err != nil || filePath == "" {
+		return nil, err
+	}
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open CSV file: %w", err)
+	}
+	defer f.Close()
+
+	opts.OnProgress = func(processed int64, result services.ImportResult) {
+		runtime.EventsEmit(a.ctx, "import:progress", map[string]any{
+			"dbName":    dbName,
+			"tableName": tableName,
+			"processed": processed,
+			"inserted":  result.Inserted,
+			"skipped":   result.Skipped,
+		})
+	}
+	defer a.queryCache.Invalidate(*conn, tableName)
+	return a.dbService.ImportCSV(a.ctx, *conn, dbName, tableName, f, opts)
+}
+
+// --- Row Editing Methods ---
+
+// InsertRow inserts a row into a table. With dryRun, the generated SQL is returned without executing it.
+func (a *App) InsertRow(dbName string, tableName string, values map[string]any, dryRun bool) (*services.RowEditResult, error) {
+	if a.ctx == nil {
+		return nil, fmt.Errorf("app context not initialized")
+	}
+	conn := a.getActiveConnection()
+	if conn == nil {
+		return nil, fmt.Errorf("no active connection")
+	}
+
+	return a.editRow(*conn, tableName, dryRun, func(dryRun bool) (*services.RowEditResult, error) {
+		return a.dbService.InsertRow(a.ctx, *conn, dbName, tableName, values, dryRun)
+	})
+}
+
+// UpdateRow updates the row identified by key. With dryRun, the generated SQL is returned without executing it.
+func (a *App) UpdateRow(dbName string, tableName string, key map[string]any, values map[string]any, dryRun bool) (*services.RowEditResult, error) {
+	if a.ctx == nil {
+		return nil, fmt.Errorf("app context not initialized")
+	}
+	conn := a.getActiveConnection()
+	if conn == nil {
+		return nil, fmt.Errorf("no active connection")
+	}
+
+	return a.editRow(*conn, tableName, dryRun, func(dryRun bool) (*services.RowEditResult, error) {
+		return a.dbService.UpdateRow(a.ctx, *conn, dbName, tableName, key, values, dryRun)
+	})
+}
+
+// DeleteRow deletes the row identified by key. With dryRun, the generated SQL is returned without executing it.
+func (a *App) DeleteRow(dbName string, tableName string, key map[string]any, dryRun bool) (*services.RowEditResult, error) {
+	if a.ctx == nil {
+		return nil, fmt.Errorf("app context not initialized")
+	}
+	conn := a.getActiveConnection()
+	if conn == nil {
+		return nil, fmt.Errorf("no active connection")
+	}
+
+	return a.editRow(*conn, tableName, dryRun, func(dryRun bool) (*services.RowEditResult, error) {
+		return a.dbService.DeleteRow(a.ctx, *conn, dbName, tableName, key, dryRun)
+	})
+}
+
+// editRow runs a row edit. With autocommit off, the generated statement runs in the pending transaction.
+func (a *App) editRow(conn services.ConnectionDetails, tableName string, dryRun bool, edit func(dryRun bool) (*services.RowEditResult, error)) (*services.RowEditResult, error) {
+	defer a.queryCache.Invalidate(conn, tableName)
+	if dryRun || services.AutoCommitEnabled(conn) {
+		return edit(dryRun)
+	}
+
+	result, err := edit(true)
+	if err != nil {
+		return nil, err
+	}
+	txID, err := a.pendingTxFor(conn, result.Statement.SQL)
+	if err != nil {
+		return nil, err
+	}
+	result.Result, err = a.dbService.ExecuteInTx(a.ctx, txID, result.Statement.SQL, result.Statement.Args...)
+	if err != nil {
+		return nil, err
+	}
+	result.Executed = true
+	return result, nil
+}
+
+// --- Command Palette ---
+
+// CommandDescriptor describes an action the command palette can offer. The frontend invokes it by
+// calling the bound App method named by Method (or emitting Event) with Params.
+type CommandDescriptor struct {
+	ID       string         `json:"id"` // Stable across calls, e.g. "connection.connect:<connectionID>"
+	Title    string         `json:"title"`
+	Category string         `json:"category"` // connection, table or metadata
+	Method   string         `json:"method,omitempty"`
+	Event    string         `json:"event,omitempty"`
+	Params   map[string]any `json:"params,omitempty"`
+}
+
+// ListCommands aggregates the actions available in the current state: connecting to each saved
+// connection and, with an active connection, disconnecting, refreshing metadata and opening or
+// exporting each cached table. A "commands:changed" event is emitted whenever this list may have changed.
+func (a *App) ListCommands() []CommandDescriptor {
+	commands := make([]CommandDescriptor, 0)
+
+	connections, err := a.configService.GetAllConnections()
+	if err != nil {
+		services.LogError("Failed to list connections for commands: %v", err)
+	}
+	connectionIDs := make([]string, 0, len(connections))
+	for id := range connections {
+		connectionIDs = append(connectionIDs, id)
+	}
+	sort.Slice(connectionIDs, func(i, j int) bool {
+		return connections[connectionIDs[i]].Name < connections[connectionIDs[j]].Name
+	})
+	for _, id := range connectionIDs {
+		commands = append(commands, CommandDescriptor{
+			ID:       "connection.connect:" + id,
+			Title:    "Connect to " + connections[id].Name,
+			Category: "connection",
+			Method:   "ConnectUsingSaved",
+			Params:   map[string]any{"connectionID": id},
+		})
+	}
+
+	connectionID := a.getActiveConnectionID()
+	if connectionID == "" {
+		return commands
+	}
+
+	commands = append(commands,
+		CommandDescriptor{
+			ID:       "connection.disconnect",
+			Title:    "Disconnect",
+			Category: "connection",
+			Method:   "Disconnect",
+		},
+		CommandDescriptor{
+			ID:       "metadata.refresh",
+			Title:    "Refresh metadata",
+			Category: "metadata",
+			Event:    "metadata:extraction:start",
+			Params:   map[string]any{"connectionID": connectionID, "force": true, "dbName": ""},
+		},
+	)
+
+	tables, err := a.metadataService.CachedTableNames(connectionID)
+	if err != nil {
+		services.LogError("Failed to list cached tables for commands: %v", err)
+		return commands
+	}
+	dbNames := make([]string, 0, len(tables))
+	for dbName := range tables {
+		dbNames = append(dbNames, dbName)
+	}
+	sort.Strings(dbNames)
+	for _, dbName := range dbNames {
+		for _, tableName := range tables[dbName] {
+			commands = append(commands, CommandDescriptor{
+				ID:       "table.open:" + dbName + "." + tableName,
+				Title:    "Open table " + dbName + "." + tableName,
+				Category: "table",
+				Method:   "GetTableData",
+				Params:   map[string]any{"dbName": dbName, "tableName": tableName},
+			}, CommandDescriptor{
+				ID:       "table.exportSQL:" + dbName + "." + tableName,
+				Title:    "Export table " + dbName + "." + tableName + " as SQL",
+				Category: "table",
+				Method:   "ExportTableSQL",
+				Params:   map[string]any{"dbName": dbName, "tableName": tableName, "includeDDL": true},
+			})
+		}
+	}
+
+	return commands
+}
+
+// notifyCommandsChanged tells the frontend to reload the command palette.
+func (a *App) notifyCommandsChanged() {
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "commands:changed")
+	}
+}
+
+// --- Theme Settings ---
+
+// GetThemeSettings retrieves the currently saved theme settings.
+func (a *App) GetThemeSettings() (*services.ThemeSettings, error) {
+	if a.configService == nil {
+		return nil, fmt.Errorf("config service not initialized")
+	}
+	return a.configService.GetThemeSettings()
+}
+
+// SaveThemeSettings saves the provided theme settings to the config file.
+func (a *App) SaveThemeSettings(settings services.ThemeSettings) error {
+	services.LogInfo("Saving theme settings: %+v\n", settings)
+	if a.configService == nil {
+		return fmt.Errorf("config service not initialized")
+	}
+	return a.configService.SaveThemeSettings(settings)
 }
 
 // --- AI Provider Settings ---
@@ -427,6 +1852,353 @@ func (a *App) SaveAIProviderSettings(settings services.AIProviderSettings) error
 	return a.configService.SaveAIProviderSettings(settings)
 }
 
+// --- Background Activity Settings ---
+
+// GetBackgroundActivityEnabled reports whether background DB activity is currently allowed.
+func (a *App) GetBackgroundActivityEnabled() bool {
+	return a.configService.IsBackgroundActivityEnabled()
+}
+
+// SetBackgroundActivityEnabled pauses or resumes background DB activity such as metadata auto-extraction.
+// Resuming does not trigger any queries by itself; work picks up on the next regular request.
+func (a *App) SetBackgroundActivityEnabled(enabled bool) error {
+	services.LogInfo("Setting background activity enabled: %v", enabled)
+	if a.configService == nil {
+		return fmt.Errorf("config service not initialized")
+	}
+	return a.configService.SetBackgroundActivityEnabled(enabled)
+}
+
+// GetPasswordStorage returns where connection passwords are saved: "file" or "keychain".
+func (a *App) GetPasswordStorage() string {
+	return a.configService.GetPasswordStorage()
+}
+
+// SetPasswordStorage moves saved connection passwords into the OS keychain ("keychain") or back into the
+// config file ("file"). If the keychain is unavailable, passwords stay in the file and a warning is logged.
+func (a *App) SetPasswordStorage(storage string) error {
+	services.LogInfo("Setting password storage: %s", storage)
+	if a.configService == nil {
+		return fmt.Errorf("config service not initialized")
+	}
+	return a.configService.SetPasswordStorage(storage)
+}
+
+// MetadataConcurrency bounds how much metadata extraction runs at once.
+type MetadataConcurrency struct {
+	Databases int `json:"databases"`
+	Tables    int `json:"tables"`
+}
+
+// GetMetadataConcurrency returns the limits on databases and tables extracted concurrently.
+func (a *App) GetMetadataConcurrency() MetadataConcurrency {
+	databases, tables := a.configService.GetMetadataConcurrency()
+	return MetadataConcurrency{Databases: databases, Tables: tables}
+}
+
+// SetMetadataConcurrency sets the extraction limits, values under 1 restore the defaults.
+// Takes effect on the next extraction.
+func (a *App) SetMetadataConcurrency(databases, tables int) error {
+	services.LogInfo("Setting metadata concurrency: %d databases, %d tables", databases, tables)
+	if a.configService == nil {
+		return fmt.Errorf("config service not initialized")
+	}
+	return a.configService.SetMetadataConcurrency(databases, tables)
+}
+
+// --- Workspace Import/Export ---
+
+// ExportWorkspace asks for a destination and writes the config and all metadata into a single zip archive.
+// Returns the chosen path, or an empty string if the dialog was cancelled.
+func (a *App) ExportWorkspace(includeSecrets bool) (string, error) {
+	if a.ctx == nil {
+		return "", fmt.Errorf("app context not initialized")
+	}
+
+	filePath, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Export Workspace",
+		DefaultFilename: "tidb-desktop-workspace.zip",
+		Filters:         []runtime.FileFilter{{DisplayName: "Zip Archives (*.zip)", Pattern: "*.zip"}},
+	})
+	if err != nil || filePath == "" {
+		return "", err
+	}
+
+	f, err := os.Create(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to create workspace archive: %w", err)
+	}
+	defer f.Close()
+
+	if err := a.metadataService.ExportWorkspace(f, includeSecrets); err != nil {
+		return "", err
+	}
+	services.LogInfo("Workspace exported to %s", filePath)
+	return filePath, nil
+}
+
+// ImportWorkspace asks for an archive created by ExportWorkspace and restores it.
+// Returns false if the dialog was cancelled.
+func (a *App) ImportWorkspace(overwrite bool) (bool, error) {
+	if a.ctx == nil {
+		return false, fmt.Errorf("app context not initialized")
+	}
+
+	filePath, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title:   "Import Workspace",
+		Filters: []runtime.FileFilter{{DisplayName: "Zip Archives (*.zip)", Pattern: "*.zip"}},
+	})
+	if err != nil || filePath == "" {
+		return false, err
+	}
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		return false, fmt.Errorf("failed to open workspace archive: %w", err)
+	}
+	defer f.Close()
+
+	if err := a.metadataService.ImportWorkspace(f, overwrite); err != nil {
+		return false, err
+	}
+	services.LogInfo("Workspace imported from %s", filePath)
+
+	// An overwrite replaces every connection, including the active one
+	if overwrite && a.getActiveConnection() != nil {
+		a.Disconnect()
+	}
+	a.notifyCommandsChanged()
+	return true, nil
+}
+
+// ExportConnections asks for a destination and saves the given connections as a shareable JSON file,
+// without passwords unless includeSecrets is set. Returns the chosen path, or an empty string if the
+// dialog was cancelled.
+func (a *App) ExportConnections(connectionIDs []string, includeSecrets bool) (string, error) {
+	if a.ctx == nil {
+		return "", fmt.Errorf("app context not initialized")
+	}
+
+	data, err := a.configService.ExportConnections(connectionIDs, includeSecrets)
+	if err != nil {
+		return "", err
+	}
+
+	filePath, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Export Connections",
+		DefaultFilename: "tidb-desktop-connections.json",
+		Filters:         []runtime.FileFilter{{DisplayName: "JSON Files (*.json)", Pattern: "*.json"}},
+	})
+	if err != nil || filePath == "" {
+		return "", err
+	}
+
+	if err := os.WriteFile(filePath, data, 0600); err != nil {
+		return "", fmt.Errorf("failed to write connections file: %w", err)
+	}
+	services.LogInfo("Exported %d connections to %s", len(connectionIDs), filePath)
+	return filePath, nil
+}
+
+// ImportConnections asks for a file created by ExportConnections and adds its connections, resolving
+// name clashes per onConflict ("rename", "skip" or "overwrite"). Returns nil if the dialog was cancelled.
+func (a *App) ImportConnections(onConflict string) (*services.ImportSummary, error) {
+	if a.ctx == nil {
+		return nil, fmt.Errorf("app context not initialized")
+	}
+
+	filePath, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title:   "Import Connections",
+		Filters: []runtime.FileFilter{{DisplayName: "JSON Files (*.json)", Pattern: "*.json"}},
+	})
+	if err != nil || filePath == "" {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read connections file: %w", err)
+	}
+
+	summary, err := a.configService.ImportConnections(data, onConflict)
+	if err != nil {
+		return nil, err
+	}
+	services.LogInfo("Imported connections from %s: %d added, %d renamed, %d skipped, %d overwritten", filePath,
+		len(summary.Added), len(summary.Renamed), len(summary.Skipped), len(summary.Overwritten))
+
+	activeID := a.getActiveConnectionID()
+	for _, connectionID := range summary.ReplacedIDs {
+		if err := a.metadataService.DeleteConnectionMetadata(connectionID); err != nil {
+			services.LogInfo("Warning: Failed to delete metadata for replaced connection %s: %v", connectionID, err)
+		}
+		if connectionID == activeID {
+			a.Disconnect()
+		}
+	}
+	a.notifyCommandsChanged()
+	return &summary, nil
+}
+
+// --- Diagnostics ---
+
+// GenerateDiagnosticBundle builds a zip archive for support requests: app, version and OS information,
+// the config and saved connections (with last test results) without secrets, metadata cache statistics
+// and recent logs. Passwords, API keys and DSN credentials are redacted.
+func (a *App) GenerateDiagnosticBundle() ([]byte, error) {
+	a.testMu.Lock()
+	testResults := make(map[string]services.ConnectionTestRecord, len(a.testResults))
+	for id, record := range a.testResults {
+		testResults[id] = record
+	}
+	a.testMu.Unlock()
+
+	info := services.DiagnosticInfo{
+		AppName:     appName,
+		Version:     version,
+		Commit:      commitHash,
+		GoVersion:   goruntime.Version(),
+		OS:          goruntime.GOOS,
+		Arch:        goruntime.GOARCH,
+		GeneratedAt: time.Now(),
+		TestResults: testResults,
+	}
+
+	var buf bytes.Buffer
+	if err := a.metadataService.WriteDiagnosticBundle(&buf, info); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SaveDiagnosticBundle asks for a destination and writes the diagnostic bundle there. Returns the file
+// path, or an empty string if the dialog was cancelled.
+func (a *App) SaveDiagnosticBundle() (string, error) {
+	if a.ctx == nil {
+		return "", fmt.Errorf("app context not initialized")
+	}
+
+	filePath, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Save Diagnostic Bundle",
+		DefaultFilename: fmt.Sprintf("tidb-desktop-diagnostics-%s.zip", time.Now().Format("20060102-150405")),
+		Filters:         []runtime.FileFilter{{DisplayName: "Zip Archives (*.zip)", Pattern: "*.zip"}},
+	})
+	if err != nil || filePath == "" {
+		return "", err
+	}
+
+	data, err := a.GenerateDiagnosticBundle()
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filePath, data, 0600); err != nil {
+		return "", fmt.Errorf("failed to write diagnostic bundle: %w", err)
+	}
+	services.LogInfo("Diagnostic bundle saved to %s", filePath)
+	return filePath, nil
+}
+
+// --- Data View Settings ---
+
+// GetPageSize returns the page size used for a table when GetTableData is called with limit 0.
+func (a *App) GetPageSize(dbName string, tableName string) int {
+	return a.configService.GetPageSize(a.getActiveConnectionID(), dbName, tableName)
+}
+
+// SetDefaultPageSize saves the global default page size for table data.
+func (a *App) SetDefaultPageSize(pageSize int) error {
+	services.LogInfo("Setting default page size: %d", pageSize)
+	if a.configService == nil {
+		return fmt.Errorf("config service not initialized")
+	}
+	return a.configService.SetDefaultPageSize(pageSize)
+}
+
+// SetTablePageSize saves the page size for a table of the active connection. Pass 0 to use the global default.
+func (a *App) SetTablePageSize(dbName string, tableName string, pageSize int) error {
+	connectionID := a.getActiveConnectionID()
+	if connectionID == "" {
+		return fmt.Errorf("no active connection")
+	}
+	return a.configService.SetTablePageSize(connectionID, dbName, tableName, pageSize)
+}
+
+// GetNullsAsSentinelEnabled reports whether NULLs in grid and query results are sent as {"$null": true}.
+func (a *App) GetNullsAsSentinelEnabled() bool {
+	return a.configService.IsNullsAsSentinelEnabled()
+}
+
+// SetNullsAsSentinelEnabled turns marking NULLs with a sentinel object on or off.
+func (a *App) SetNullsAsSentinelEnabled(enabled bool) error {
+	services.LogInfo("Setting nulls as sentinel enabled: %v", enabled)
+	if a.configService == nil {
+		return fmt.Errorf("config service not initialized")
+	}
+	return a.configService.SetNullsAsSentinelEnabled(enabled)
+}
+
+// GetQueryCacheTTLSeconds returns how long SELECT results are reused, 0 when caching is off.
+func (a *App) GetQueryCacheTTLSeconds() int {
+	return int(a.configService.GetQueryCacheTTL() / time.Second)
+}
+
+// SetQueryCacheTTLSeconds sets how long SELECT results are reused; 0 turns caching off and drops
+// cached results.
+func (a *App) SetQueryCacheTTLSeconds(seconds int) error {
+	services.LogInfo("Setting query cache TTL: %ds", seconds)
+	if a.configService == nil {
+		return fmt.Errorf("config service not initialized")
+	}
+	if seconds <= 0 {
+		a.queryCache.Clear()
+	}
+	return a.configService.SetQueryCacheTTL(time.Duration(seconds) * time.Second)
+}
+
+// GetAutoLimit returns the LIMIT appended to SELECTs without one, or 0 when automatic limits are off.
+func (a *App) GetAutoLimit() int {
+	return a.configService.GetAutoLimit()
+}
+
+// SetAutoLimit turns the automatic LIMIT on or off and, if rows is positive, sets its row count.
+func (a *App) SetAutoLimit(enabled bool, rows int) error {
+	services.LogInfo("Setting auto limit enabled: %v, rows: %d", enabled, rows)
+	if a.configService == nil {
+		return fmt.Errorf("config service not initialized")
+	}
+	return a.configService.SetAutoLimit(enabled, rows)
+}
+
+// GetNullDisplay returns the text NULL cells are displayed as.
+func (a *App) GetNullDisplay() string {
+	display, _ := a.configService.GetNullDisplay()
+	return display
+}
+
+// SetNullDisplay sets the text NULL cells are displayed as, e.g. "NULL", "(null)" or an empty string.
+// Once set, it is also written for NULLs in CSV exports instead of \N.
+func (a *App) SetNullDisplay(display string) error {
+	services.LogInfo("Setting NULL display: %q", display)
+	if a.configService == nil {
+		return fmt.Errorf("config service not initialized")
+	}
+	return a.configService.SetNullDisplay(display)
+}
+
+// GetColumnHintsEnabled reports whether query results include column rendering hints.
+func (a *App) GetColumnHintsEnabled() bool {
+	return a.configService.IsColumnHintsEnabled()
+}
+
+// SetColumnHintsEnabled turns column rendering hints on or off. Disabling skips sampling result values.
+func (a *App) SetColumnHintsEnabled(enabled bool) error {
+	services.LogInfo("Setting column hints enabled: %v", enabled)
+	if a.configService == nil {
+		return fmt.Errorf("config service not initialized")
+	}
+	return a.configService.SetColumnHintsEnabled(enabled)
+}
+
 // --- Window Settings (not directly exposed to frontend, but used internally) ---
 
 // GetWindowSettings retrieves the currently saved window settings.
@@ -454,11 +2226,16 @@ func (a *App) GetDatabaseMetadata() (*services.ConnectionMetadata, error) {
 	if a.ctx == nil {
 		return nil, fmt.Errorf("app context not initialized")
 	}
-	if a.activeConnection == nil {
+	connectionID := a.getActiveConnectionID()
+	if connectionID == "" {
 		return nil, fmt.Errorf("no active connection")
 	}
 
-	return a.metadataService.GetMetadata(a.ctx, a.activeConnectionID)
+	metadata, err := a.metadataService.GetMetadata(a.ctx, connectionID)
+	if err != nil {
+		return nil, err
+	}
+	return a.metadataService.Snapshot(metadata), nil
 }
 
 // ExtractDatabaseMetadata forces a fresh extraction of database metadata
@@ -466,7 +2243,8 @@ func (a *App) ExtractDatabaseMetadata(dbName ...string) (*services.ConnectionMet
 	if a.ctx == nil {
 		return nil, fmt.Errorf("app context not initialized")
 	}
-	if a.activeConnection == nil {
+	connectionID := a.getActiveConnectionID()
+	if connectionID == "" {
 		return nil, fmt.Errorf("no active connection")
 	}
 
@@ -475,13 +2253,14 @@ func (a *App) ExtractDatabaseMetadata(dbName ...string) (*services.ConnectionMet
 		optionalDbName = dbName
 	}
 
-	metadata, err := a.metadataService.ExtractMetadata(a.ctx, a.activeConnectionID, optionalDbName...)
+	// Runs under the extraction context so CancelMetadataExtraction, Disconnect and shutdown stop it too
+	metadata, err := a.metadataService.ExtractMetadata(a.extractionContext(), connectionID, optionalDbName...)
 	if err != nil {
 		return nil, err
 	}
 
 	// Save the extracted metadata to disk
-	if saveErr := a.metadataService.SaveMetadata(a.activeConnectionID); saveErr != nil {
+	if saveErr := a.metadataService.SaveMetadata(connectionID); saveErr != nil {
 		services.LogError("Failed to save metadata after extraction: %v", saveErr)
 		// Don't fail the operation, just log the error
 	}
@@ -494,7 +2273,8 @@ func (a *App) UpdateAIDescription(dbName string, targetType string, tableName st
 	if a.ctx == nil {
 		return fmt.Errorf("app context not initialized")
 	}
-	if a.activeConnection == nil {
+	connectionID := a.getActiveConnectionID()
+	if connectionID == "" {
 		return fmt.Errorf("no active connection")
 	}
 
@@ -504,13 +2284,13 @@ func (a *App) UpdateAIDescription(dbName string, targetType string, tableName st
 		ColumnName: columnName,
 	}
 
-	err := a.metadataService.UpdateAIDescription(a.ctx, a.activeConnectionID, dbName, target, description)
+	err := a.metadataService.UpdateAIDescription(a.ctx, connectionID, dbName, target, description)
 	if err != nil {
 		return fmt.Errorf("failed to update AI description: %w", err)
 	}
 
 	// Save the updated metadata to disk
-	if saveErr := a.metadataService.SaveMetadata(a.activeConnectionID); saveErr != nil {
+	if saveErr := a.metadataService.SaveMetadata(connectionID); saveErr != nil {
 		services.LogError("Failed to save metadata after AI description update: %v", saveErr)
 		// Don't fail the operation, just log the error
 	}
@@ -518,9 +2298,139 @@ func (a *App) UpdateAIDescription(dbName string, targetType string, tableName st
 	return nil
 }
 
+// FindCollationIssues reports tables and columns whose collation differs from their expected default
+func (a *App) FindCollationIssues(dbName string) ([]services.CollationIssue, error) {
+	connectionID := a.getActiveConnectionID()
+	if connectionID == "" {
+		return nil, fmt.Errorf("no active connection")
+	}
+
+	return a.metadataService.FindCollationIssues(connectionID, dbName)
+}
+
+// FindCircularDependencies reports foreign key cycles in a database of the active connection
+func (a *App) FindCircularDependencies(dbName string) ([][]string, error) {
+	connectionID := a.getActiveConnectionID()
+	if connectionID == "" {
+		return nil, fmt.Errorf("no active connection")
+	}
+
+	return a.metadataService.FindCircularDependencies(connectionID, dbName)
+}
+
+// FindRedundantIndexes reports indexes of a database that duplicate or are a prefix of another index.
+func (a *App) FindRedundantIndexes(dbName string) ([]services.RedundantIndex, error) {
+	connectionID := a.getActiveConnectionID()
+	if connectionID == "" {
+		return nil, fmt.Errorf("no active connection")
+	}
+
+	return a.metadataService.FindRedundantIndexes(connectionID, dbName)
+}
+
+// SearchMetadata finds databases, tables and columns of the active connection whose names or descriptions
+// match query, best matches first, using only cached metadata.
+func (a *App) SearchMetadata(query string) ([]services.SearchHit, error) {
+	connectionID := a.getActiveConnectionID()
+	if connectionID == "" {
+		return nil, fmt.Errorf("no active connection")
+	}
+
+	return a.metadataService.SearchMetadata(connectionID, query)
+}
+
+// ExportERDiagram renders the tables and foreign keys of a database, from cached metadata, as an ER diagram
+// definition in the given format ("mermaid" or "dbml") for the UI to render or copy.
+func (a *App) ExportERDiagram(dbName string, format string) (string, error) {
+	connectionID := a.getActiveConnectionID()
+	if connectionID == "" {
+		return "", fmt.Errorf("no active connection")
+	}
+
+	return a.metadataService.ExportERDiagram(connectionID, dbName, format)
+}
+
+// DiffMetadataAgainstSaved reports how the active connection's in-memory metadata differs from its last saved file.
+func (a *App) DiffMetadataAgainstSaved() (*services.SchemaDiff, error) {
+	connectionID := a.getActiveConnectionID()
+	if connectionID == "" {
+		return nil, fmt.Errorf("no active connection")
+	}
+
+	return a.metadataService.DiffAgainstPersisted(connectionID)
+}
+
+// ExportSchemaAsHTML saves the foreign key graph of a database, built from cached metadata, as a standalone
+// interactive HTML file chosen by the user. Views are drawn only if includeViews is set.
+// Returns the file path, or an empty string if the dialog was cancelled.
+func (a *App) ExportSchemaAsHTML(dbName string, includeViews bool) (string, error) {
+	if a.ctx == nil {
+		return "", fmt.Errorf("app context not initialized")
+	}
+	connectionID := a.getActiveConnectionID()
+	if connectionID == "" {
+		return "", fmt.Errorf("no active connection")
+	}
+
+	html, err := a.metadataService.ExportSchemaHTML(connectionID, dbName, includeViews)
+	if err != nil {
+		return "", err
+	}
+
+	filePath, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Export Schema Diagram",
+		DefaultFilename: dbName + "-schema.html",
+		Filters:         []runtime.FileFilter{{DisplayName: "HTML Files (*.html)", Pattern: "*.html"}},
+	})
+	if err != nil || filePath == "" {
+		return "", err
+	}
+
+	if err := os.WriteFile(filePath, []byte(html), 0644); err != nil {
+		return "", fmt.Errorf("failed to write schema diagram: %w", err)
+	}
+	services.LogInfo("Schema diagram for %s exported to %s", dbName, filePath)
+	return filePath, nil
+}
+
+// ExportSchemaAsMarkdown saves a Markdown data dictionary of a database, built from cached metadata, to a file
+// chosen by the user. Returns the file path, or an empty string if the dialog was cancelled.
+func (a *App) ExportSchemaAsMarkdown(dbName string) (string, error) {
+	if a.ctx == nil {
+		return "", fmt.Errorf("app context not initialized")
+	}
+	connectionID := a.getActiveConnectionID()
+	if connectionID == "" {
+		return "", fmt.Errorf("no active connection")
+	}
+
+	markdown, err := a.metadataService.ExportMarkdown(connectionID, dbName)
+	if err != nil {
+		return "", err
+	}
+
+	filePath, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Export Data Dictionary",
+		DefaultFilename: dbName + ".md",
+		Filters:         []runtime.FileFilter{{DisplayName: "Markdown Files (*.md)", Pattern: "*.md"}},
+	})
+	if err != nil || filePath == "" {
+		return "", err
+	}
+
+	if err := os.WriteFile(filePath, []byte(markdown), 0644); err != nil {
+		return "", fmt.Errorf("failed to write data dictionary: %w", err)
+	}
+	services.LogInfo("Data dictionary for %s exported to %s", dbName, filePath)
+	return filePath, nil
+}
+
 func (a *App) emitMetadataWithVersion(metadata *services.ConnectionMetadata) {
+	// The cached metadata is shared with extraction, so the event carries a copy
+	metadata = a.metadataService.Snapshot(metadata)
+
 	// Try to get version, but don't fail the emission if it doesn't work
-	if a.activeConnection != nil {
+	if a.getActiveConnection() != nil && a.configService.IsBackgroundActivityEnabled() {
 		version, err := a.GetVersion()
 		if err != nil {
 			services.LogError("Failed to get database version for metadata emission: %v", err)
@@ -531,4 +2441,5 @@ func (a *App) emitMetadataWithVersion(metadata *services.ConnectionMetadata) {
 	}
 
 	runtime.EventsEmit(a.ctx, "metadata:extraction:completed", metadata)
+	a.notifyCommandsChanged()
 }
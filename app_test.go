@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/zoubingwu/tidb-desktop/services"
+)
+
+// Run with -race: connecting, disconnecting and reading the active connection from many goroutines must not race.
+func TestActiveConnectionConcurrentAccess(t *testing.T) {
+	app := &App{}
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := range 100 {
+				id := fmt.Sprintf("conn-%d-%d", i, j)
+				if j%10 == 9 {
+					app.setActiveConnection(nil, "")
+					continue
+				}
+				app.setActiveConnection(&services.ConnectionDetails{ID: id, Name: id, Host: "127.0.0.1"}, id)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				if details := app.getActiveConnection(); details != nil {
+					// The returned copy is the caller's to change
+					details.Name = "changed"
+				}
+				_ = app.getActiveConnectionID()
+			}
+		}()
+	}
+	wg.Wait()
+
+	app.setActiveConnection(&services.ConnectionDetails{ID: "last", Name: "last"}, "last")
+	details := app.getActiveConnection()
+	if details == nil || details.Name != "last" || app.getActiveConnectionID() != "last" {
+		t.Fatalf("active connection = %+v, id %q, want last", details, app.getActiveConnectionID())
+	}
+	details.Name = "changed"
+	if app.getActiveConnection().Name != "last" {
+		t.Fatal("getActiveConnection returned the stored details instead of a copy")
+	}
+}
+
+func TestCancelMetadataExtraction(t *testing.T) {
+	app := &App{ctx: context.Background()}
+
+	first := app.extractionContext()
+	if app.extractionContext() != first {
+		t.Fatal("concurrent extractions got different contexts, so one cancel wouldn't stop them all")
+	}
+	app.CancelMetadataExtraction()
+	if first.Err() != context.Canceled {
+		t.Fatalf("extraction context err = %v after cancelling, want context.Canceled", first.Err())
+	}
+
+	// The next extraction starts under a fresh context
+	next := app.extractionContext()
+	if next.Err() != nil {
+		t.Fatalf("extraction after a cancel starts with err = %v", next.Err())
+	}
+	app.CancelMetadataExtraction()
+	app.CancelMetadataExtraction() // A second cancel with nothing running is harmless
+	if next.Err() != context.Canceled {
+		t.Fatal("cancel didn't reach the second extraction")
+	}
+}
+
+func TestExtractionContextEndsWithApp(t *testing.T) {
+	appCtx, shutdown := context.WithCancel(context.Background())
+	app := &App{ctx: appCtx}
+
+	ctx := app.extractionContext()
+	shutdown()
+	if ctx.Err() == nil {
+		t.Fatal("extraction context outlived the app context")
+	}
+}
+
+func TestAutoCommitOffRejectsSelfCommittingWrites(t *testing.T) {
+	autoCommit := false
+	app := &App{ctx: context.Background()}
+	app.setActiveConnection(&services.ConnectionDetails{ID: "conn", Name: "conn", Host: "127.0.0.1", AutoCommit: &autoCommit}, "conn")
+
+	calls := map[string]func() error{
+		"ImportCSV": func() error {
+			_, err := app.ImportCSV("shop", "orders", services.ImportOptions{})
+			return err
+		},
+		"SetTableTiFlashReplica": func() error {
+			return app.SetTableTiFlashReplica("shop", "orders", 1)
+		},
+		"StreamQuery": func() error {
+			_, err := app.StreamQuery("stream", "DELETE FROM orders WHERE id = 1")
+			return err
+		},
+		"ExportQueryToFile": func() error {
+			_, err := app.ExportQueryToFile("UPDATE orders SET total = 0 WHERE id = 1", "out.csv", "csv")
+			return err
+		},
+		"AnalyzeEstimationAccuracy": func() error {
+			_, err := app.AnalyzeEstimationAccuracy("shop", "DELETE FROM orders WHERE id = 1")
+			return err
+		},
+	}
+	for name, call := range calls {
+		if err := call(); err == nil || !strings.Contains(err.Error(), "autocommit is off") {
+			t.Errorf("%s: err = %v, want it rejected while autocommit is off", name, err)
+		}
+	}
+}
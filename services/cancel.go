@@ -0,0 +1,43 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Errors returned in place of driver errors when a query's context ends
+var (
+	ErrQueryCancelled = errors.New("query cancelled")
+	ErrQueryTimeout   = errors.New("query timed out")
+)
+
+// WithQueryTimeout derives the context for a query on the connection, bounded by its
+// QueryTimeoutSeconds when set. Cancelling it stops the query with ErrQueryCancelled.
+func WithQueryTimeout(ctx context.Context, details ConnectionDetails) (context.Context, context.CancelFunc) {
+	if details.QueryTimeoutSeconds <= 0 {
+		ctx, cancel := context.WithCancelCause(ctx)
+		return ctx, func() { cancel(ErrQueryCancelled) }
+	}
+	timeout := time.Duration(details.QueryTimeoutSeconds) * time.Second
+	ctx, stop := context.WithTimeoutCause(ctx, timeout, fmt.Errorf("%w after %v", ErrQueryTimeout, timeout))
+	ctx, cancel := context.WithCancelCause(ctx)
+	return ctx, func() {
+		cancel(ErrQueryCancelled)
+		stop()
+	}
+}
+
+// QueryContextError explains a failed query by its context: a query stopped by WithQueryTimeout's
+// cancel func or timeout reports ErrQueryCancelled or ErrQueryTimeout instead of whatever the driver
+// returned (typically "invalid connection"). Other errors are returned unchanged.
+func QueryContextError(ctx context.Context, err error) error {
+	if err == nil || ctx.Err() == nil {
+		return err
+	}
+	if cause := context.Cause(ctx); errors.Is(cause, ErrQueryCancelled) || errors.Is(cause, ErrQueryTimeout) {
+		return cause
+	}
+	return err
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Column hints classify result columns so the UI can render them as links, badges or dates
+const (
+	ColumnHintURL       = "url"
+	ColumnHintEmail     = "email"
+	ColumnHintBoolean   = "boolean"
+	ColumnHintTimestamp = "timestamp"
+)
+
+// columnHintSampleSize is the number of leading rows inspected per column
+const columnHintSampleSize = 100
+
+// timestampLayouts are the textual date formats recognized as timestamps
+var timestampLayouts = []string{
+	time.DateTime,
+	"2006-01-02 15:04:05.999999",
+	time.DateOnly,
+	time.RFC3339,
+	time.RFC3339Nano,
+}
+
+// InferColumnHints sets result.ColumnHints from a sample of its rows. A column gets a hint only when all
+// of its sampled non-NULL values agree; columns without any non-NULL sample get none.
+func InferColumnHints(result *SQLResult) {
+	if result == nil || len(result.Columns) == 0 || len(result.Rows) == 0 {
+		return
+	}
+	sample := result.Rows[:min(len(result.Rows), columnHintSampleSize)]
+
+	hints := make(map[string]string)
+	for _, col := range result.Columns {
+		hint := ""
+		for _, row := range sample {
+			v := row[col]
+			if v == nil {
+				continue
+			}
+			h := valueHint(v)
+			if h == "" || (hint != "" && h != hint) {
+				hint = ""
+				break
+			}
+			hint = h
+		}
+		if hint != "" {
+			hints[col] = hint
+		}
+	}
+	if len(hints) > 0 {
+		result.ColumnHints = hints
+	}
+}
+
+// valueHint classifies a single non-NULL value, returning "" if it has no hint.
+func valueHint(v any) string {
+	switch val := v.(type) {
+	case time.Time:
+		return ColumnHintTimestamp
+	case bool:
+		return ColumnHintBoolean
+	case int64:
+		if val == 0 || val == 1 {
+			return ColumnHintBoolean
+		}
+		return ""
+	case string:
+		return stringHint(val)
+	}
+	return ""
+}
+
+// stringHint classifies a textual value as a URL, email address or timestamp.
+func stringHint(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" || len(s) > 2048 {
+		return ""
+	}
+	if strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://") {
+		if u, err := url.Parse(s); err == nil && u.Host != "" && !strings.ContainsAny(s, " \t\n") {
+			return ColumnHintURL
+		}
+		return ""
+	}
+	if at := strings.IndexByte(s, '@'); at > 0 && strings.Contains(s[at:], ".") {
+		if addr, err := mail.ParseAddress(s); err == nil && addr.Address == s {
+			return ColumnHintEmail
+		}
+		return ""
+	}
+	if len(s) >= len(time.DateOnly) && s[0] >= '0' && s[0] <= '9' {
+		for _, layout := range timestampLayouts {
+			if _, err := time.Parse(layout, s); err == nil {
+				return ColumnHintTimestamp
+			}
+		}
+	}
+	return ""
+}
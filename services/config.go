@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 )
@@ -25,6 +27,11 @@ const (
 	DefaultWindowHeight    = 768
 	DefaultWindowX         = -1 // Represents center
 	DefaultWindowY         = -1 // Represents center
+	DefaultPageSize        = 100
+	MaxPageSize            = 1000 // Upper bound for any page size, configured or requested
+	// Default bounds on concurrent metadata extraction work
+	DefaultMetadataDatabaseConcurrency = 2
+	DefaultMetadataTableConcurrency    = 8
 )
 
 // ThemeSettings holds theme preferences
@@ -62,6 +69,28 @@ type WindowSettings struct {
 	IsMaximized bool `json:"isMaximized,omitempty"`
 }
 
+// TablePreferences holds per-table data view preferences
+type TablePreferences struct {
+	PageSize int `json:"pageSize,omitempty"`
+}
+
+// DataViewSettings holds table data browsing preferences
+type DataViewSettings struct {
+	DefaultPageSize  int                         `json:"defaultPageSize,omitempty"`
+	TablePreferences map[string]TablePreferences `json:"tablePreferences,omitempty"` // key is "connectionID/db.table"
+	// ColumnHintsDisabled skips classifying query result columns for rendering hints
+	ColumnHintsDisabled bool `json:"columnHintsDisabled,omitempty"`
+	// NullsAsSentinel replaces NULLs in grid and query results with a NullSentinel object
+	NullsAsSentinel bool `json:"nullsAsSentinel,omitempty"`
+	// AutoLimitDisabled stops appending a LIMIT to editor SELECTs that have none
+	AutoLimitDisabled bool `json:"autoLimitDisabled,omitempty"`
+	AutoLimitRows     int  `json:"autoLimitRows,omitempty"` // Defaults to DefaultAutoLimit
+	// QueryCacheTTLSeconds is how long editor SELECT results are reused, 0 disables the cache
+	QueryCacheTTLSeconds int `json:"queryCacheTTLSeconds,omitempty"`
+	// NullDisplay is how NULL is shown in the grid and written in CSV exports, nil for the defaults
+	NullDisplay *string `json:"nullDisplay,omitempty"`
+}
+
 // AIProviderSettings holds API keys and settings for different AI providers
 type AIProviderSettings struct {
 	CurrentProvider string              `json:"provider,omitempty"` // 'openai', 'anthropic', 'openrouter'
@@ -83,6 +112,16 @@ type ConfigData struct {
 	ThemeSettings      *ThemeSettings               `json:"appearance,omitempty"`
 	AIProviderSettings *AIProviderSettings          `json:"ai,omitempty"`
 	WindowSettings     *WindowSettings              `json:"window,omitempty"`
+	DataViewSettings   *DataViewSettings            `json:"dataView,omitempty"`
+	// BackgroundActivityDisabled pauses automatic DB activity such as metadata auto-extraction
+	BackgroundActivityDisabled bool `json:"backgroundActivityDisabled,omitempty"`
+	// Limits on databases and tables extracted concurrently, 0 uses the defaults
+	MetadataDatabaseConcurrency int `json:"metadataDatabaseConcurrency,omitempty"`
+	MetadataTableConcurrency    int `json:"metadataTableConcurrency,omitempty"`
+	// PasswordStorage is PasswordStorageKeychain to keep connection passwords in the OS keychain, else the file
+	PasswordStorage string `json:"passwordStorage,omitempty"`
+	// DefaultConnectionID is connected to automatically on startup when set
+	DefaultConnectionID string `json:"defaultConnectionId,omitempty"`
 }
 
 // ConfigService handles loading and saving application configuration.
@@ -90,6 +129,8 @@ type ConfigService struct {
 	configPath string
 	config     *ConfigData
 	mu         sync.RWMutex
+	// Connection IDs whose passwords are in the OS keychain and left out of the config file
+	inKeychain map[string]bool
 }
 
 // NewConfigService creates a new service and loads the initial config.
@@ -104,6 +145,7 @@ func NewConfigService() (*ConfigService, error) {
 
 	service := &ConfigService{
 		configPath: configFilePath,
+		inKeychain: make(map[string]bool),
 		config: &ConfigData{
 			Connections:   make(map[string]ConnectionDetails),
 			ThemeSettings: &ThemeSettings{Mode: DefaultThemeMode, BaseTheme: DefaultBaseTheme},
@@ -120,6 +162,10 @@ func NewConfigService() (*ConfigService, error) {
 				Y:           DefaultWindowY,
 				IsMaximized: false,
 			},
+			DataViewSettings: &DataViewSettings{
+				DefaultPageSize:  DefaultPageSize,
+				TablePreferences: make(map[string]TablePreferences),
+			},
 		},
 	}
 
@@ -166,6 +212,23 @@ func (s *ConfigService) loadConfig() error {
 	if loadedConfig.WindowSettings != nil {
 		s.config.WindowSettings = loadedConfig.WindowSettings
 	}
+	if loadedConfig.DataViewSettings != nil {
+		s.config.DataViewSettings = loadedConfig.DataViewSettings
+		if s.config.DataViewSettings.TablePreferences == nil {
+			s.config.DataViewSettings.TablePreferences = make(map[string]TablePreferences)
+		}
+	}
+	s.config.BackgroundActivityDisabled = loadedConfig.BackgroundActivityDisabled
+	s.config.MetadataDatabaseConcurrency = loadedConfig.MetadataDatabaseConcurrency
+	s.config.MetadataTableConcurrency = loadedConfig.MetadataTableConcurrency
+	s.config.PasswordStorage = loadedConfig.PasswordStorage
+	s.config.DefaultConnectionID = loadedConfig.DefaultConnectionID
+	if s.usesKeychain() {
+		for id, details := range s.config.Connections {
+			s.loadConnectionSecrets(id, &details)
+			s.config.Connections[id] = details
+		}
+	}
 
 	return nil
 }
@@ -177,18 +240,65 @@ func (s *ConfigService) saveConfig() error {
 		return fmt.Errorf("failed to create config directory: %w", err)
 	}
 
-	data, err := json.MarshalIndent(s.config, "", "  ")
+	saved := *s.config
+	if len(s.inKeychain) > 0 {
+		// Passwords held in the keychain stay out of the file
+		saved.Connections = make(map[string]ConnectionDetails, len(s.config.Connections))
+		for id, details := range s.config.Connections {
+			if s.inKeychain[id] {
+				details.Password = ""
+				if details.SSHTunnel != nil {
+					tunnel := *details.SSHTunnel
+					tunnel.Password = ""
+					details.SSHTunnel = &tunnel
+				}
+			}
+			saved.Connections[id] = details
+		}
+	}
+
+	data, err := json.MarshalIndent(saved, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
 
-	if err := os.WriteFile(s.configPath, data, 0600); err != nil {
+	if err := writeFileAtomic(s.configPath, data, 0600); err != nil {
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
 
 	return nil
 }
 
+// renameFile is os.Rename, replaced in tests to make the last step of writeFileAtomic fail
+var renameFile = os.Rename
+
+// writeFileAtomic writes data to a temporary file next to path and renames it over path, so a crash
+// mid-write leaves the previous file intact rather than a truncated one.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // No-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return err
+	}
+	return renameFile(tmpPath, path)
+}
+
 // --- Connection Management Methods ---
 
 // GetAllConnections returns a copy of all stored connections.
@@ -206,37 +316,74 @@ func (s *ConfigService) GetAllConnections() (map[string]ConnectionDetails, error
 
 // AddOrUpdateConnection adds a new connection or updates an existing one.
 // Returns the connection ID.
+// Fails with a *ValidationError if the details are incomplete or the name is taken.
 func (s *ConfigService) AddOrUpdateConnection(details ConnectionDetails) (string, error) {
-	if details.Name == "" {
-		return "", fmt.Errorf("connection name cannot be empty")
-	}
-
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if err := s.validateConnectionForSave(details); err != nil {
+		return "", err
+	}
+
 	// Generate ID if not provided (new connection)
 	if details.ID == "" {
-		// Check for name conflicts
-		for _, existing := range s.config.Connections {
-			if existing.Name == details.Name {
-				return "", fmt.Errorf("connection name '%s' already exists", details.Name)
-			}
-		}
 		details.ID = generateConnectionID()
-	} else {
-		// Updating existing connection - check for name conflicts
-		for id, existing := range s.config.Connections {
-			if id != details.ID && existing.Name == details.Name {
-				return "", fmt.Errorf("connection name '%s' already exists", details.Name)
-			}
-		}
 	}
 
 	s.config.Connections[details.ID] = details
+	if s.usesKeychain() {
+		s.storeConnectionSecrets(details.ID, details)
+	}
 	err := s.saveConfig()
 	return details.ID, err
 }
 
+// CloneConnection saves a copy of a connection under a new ID and name, without its LastUsed time.
+// An empty newName picks "<name> (copy)", with a numeric suffix if needed. Returns the new connection ID.
+func (s *ConfigService) CloneConnection(sourceID, newName string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	source, found := s.config.Connections[sourceID]
+	if !found {
+		return "", fmt.Errorf("connection '%s' not found", sourceID)
+	}
+
+	clone := source
+	clone.ID = ""
+	clone.LastUsed = ""
+	clone.Name = newName
+	if clone.Name == "" {
+		clone.Name = s.uniqueConnectionName(source.Name + " (copy)")
+	}
+	// Don't share pointed-to settings with the source
+	if source.SSHTunnel != nil {
+		tunnel := *source.SSHTunnel
+		clone.SSHTunnel = &tunnel
+	}
+	if source.MetadataStaleMinutes != nil {
+		minutes := *source.MetadataStaleMinutes
+		clone.MetadataStaleMinutes = &minutes
+	}
+	if source.AutoCommit != nil {
+		autoCommit := *source.AutoCommit
+		clone.AutoCommit = &autoCommit
+	}
+	if err := s.validateConnectionForSave(clone); err != nil {
+		return "", err
+	}
+
+	clone.ID = generateConnectionID()
+	for _, exists := s.config.Connections[clone.ID]; exists; _, exists = s.config.Connections[clone.ID] {
+		clone.ID = generateConnectionID()
+	}
+	s.config.Connections[clone.ID] = clone
+	if s.usesKeychain() {
+		s.storeConnectionSecrets(clone.ID, clone)
+	}
+	return clone.ID, s.saveConfig()
+}
+
 // DeleteConnection removes a connection by ID.
 func (s *ConfigService) DeleteConnection(connectionID string) error {
 	s.mu.Lock()
@@ -247,6 +394,7 @@ func (s *ConfigService) DeleteConnection(connectionID string) error {
 	}
 
 	delete(s.config.Connections, connectionID)
+	s.deleteConnectionSecrets(connectionID)
 	return s.saveConfig()
 }
 
@@ -262,6 +410,77 @@ func (s *ConfigService) GetConnection(connectionID string) (ConnectionDetails, b
 	return details, found, nil
 }
 
+// ConnectionGroup is a folder of saved connections
+type ConnectionGroup struct {
+	Name        string              `json:"name"` // Empty for connections without a group
+	Connections []ConnectionDetails `json:"connections"`
+}
+
+// GetConnectionsByGroup returns the saved connections grouped by folder, ungrouped ones first and the rest
+// by group name, with connections sorted by name within each group.
+func (s *ConfigService) GetConnectionsByGroup() []ConnectionGroup {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	byGroup := make(map[string][]ConnectionDetails)
+	for id, details := range s.config.Connections {
+		details.ID = id
+		byGroup[details.Group] = append(byGroup[details.Group], details)
+	}
+
+	groups := make([]ConnectionGroup, 0, len(byGroup)+1)
+	if _, ok := byGroup[""]; !ok {
+		// The ungrouped bucket is always present
+		byGroup[""] = []ConnectionDetails{}
+	}
+	for name, connections := range byGroup {
+		sort.Slice(connections, func(i, j int) bool { return connections[i].Name < connections[j].Name })
+		groups = append(groups, ConnectionGroup{Name: name, Connections: connections})
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Name < groups[j].Name })
+	return groups
+}
+
+// SetConnectionGroup moves a connection into a group, or out of any group if group is empty.
+func (s *ConfigService) SetConnectionGroup(connectionID, group string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	details, found := s.config.Connections[connectionID]
+	if !found {
+		return fmt.Errorf("connection '%s' not found", connectionID)
+	}
+	details.Group = strings.TrimSpace(group)
+	s.config.Connections[connectionID] = details
+	return s.saveConfig()
+}
+
+// GetDefaultConnection returns the ID of the connection to open on startup, or an empty string if there is
+// none or it has since been deleted.
+func (s *ConfigService) GetDefaultConnection() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, exists := s.config.Connections[s.config.DefaultConnectionID]; !exists {
+		return ""
+	}
+	return s.config.DefaultConnectionID
+}
+
+// SetDefaultConnection sets the connection to open on startup, or clears it if connectionID is empty.
+func (s *ConfigService) SetDefaultConnection(connectionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if connectionID != "" {
+		if _, exists := s.config.Connections[connectionID]; !exists {
+			return fmt.Errorf("connection '%s' not found", connectionID)
+		}
+	}
+	s.config.DefaultConnectionID = connectionID
+	return s.saveConfig()
+}
+
 // RecordConnectionUsage updates the LastUsed timestamp for a connection by ID.
 func (s *ConfigService) RecordConnectionUsage(connectionID string) error {
 	s.mu.Lock()
@@ -277,6 +496,197 @@ func (s *ConfigService) RecordConnectionUsage(connectionID string) error {
 	return s.saveConfig()
 }
 
+// uniqueConnectionName returns name, or name with a numeric suffix if it is already taken, ignoring case
+// as validateConnectionForSave does. Caller must hold the lock.
+func (s *ConfigService) uniqueConnectionName(name string) string {
+	taken := func(candidate string) bool {
+		for _, existing := range s.config.Connections {
+			if sameConnectionName(existing.Name, candidate) {
+				return true
+			}
+		}
+		return false
+	}
+	candidate := name
+	for i := 2; taken(candidate); i++ {
+		candidate = fmt.Sprintf("%s (%d)", name, i)
+	}
+	return candidate
+}
+
+// --- Workspace Export/Import Helpers ---
+
+// exportConfigData returns the serialized config, with passwords and API keys removed unless includeSecrets is set.
+func (s *ConfigService) exportConfigData(includeSecrets bool) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	exported := *s.config
+	exported.Connections = make(map[string]ConnectionDetails, len(s.config.Connections))
+	for id, details := range s.config.Connections {
+		details.ID = id
+		if !includeSecrets {
+			details.Password = ""
+			if details.SSHTunnel != nil {
+				tunnel := *details.SSHTunnel
+				tunnel.Password = ""
+				details.SSHTunnel = &tunnel
+			}
+		}
+		exported.Connections[id] = details
+	}
+	if !includeSecrets && s.config.AIProviderSettings != nil {
+		exported.AIProviderSettings = stripAPIKeys(*s.config.AIProviderSettings)
+	}
+
+	data, err := json.MarshalIndent(exported, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	return data, nil
+}
+
+// stripAPIKeys returns a copy of the AI provider settings without API keys.
+func stripAPIKeys(settings AIProviderSettings) *AIProviderSettings {
+	if settings.OpenAI != nil {
+		openAI := *settings.OpenAI
+		openAI.APIKey = ""
+		settings.OpenAI = &openAI
+	}
+	if settings.Anthropic != nil {
+		anthropic := *settings.Anthropic
+		anthropic.APIKey = ""
+		settings.Anthropic = &anthropic
+	}
+	if settings.OpenRouter != nil {
+		openRouter := *settings.OpenRouter
+		openRouter.APIKey = ""
+		settings.OpenRouter = &openRouter
+	}
+	return &settings
+}
+
+// secrets returns the saved connection passwords and AI provider API keys, for redaction.
+func (s *ConfigService) secrets() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	secrets := make([]string, 0, len(s.config.Connections)+3)
+	for _, details := range s.config.Connections {
+		if details.Password != "" {
+			secrets = append(secrets, details.Password)
+		}
+		if details.SSHTunnel != nil && details.SSHTunnel.Password != "" {
+			secrets = append(secrets, details.SSHTunnel.Password)
+		}
+	}
+	if ai := s.config.AIProviderSettings; ai != nil {
+		if ai.OpenAI != nil && ai.OpenAI.APIKey != "" {
+			secrets = append(secrets, ai.OpenAI.APIKey)
+		}
+		if ai.Anthropic != nil && ai.Anthropic.APIKey != "" {
+			secrets = append(secrets, ai.Anthropic.APIKey)
+		}
+		if ai.OpenRouter != nil && ai.OpenRouter.APIKey != "" {
+			secrets = append(secrets, ai.OpenRouter.APIKey)
+		}
+	}
+	return secrets
+}
+
+// importConfigData merges imported connections under freshly generated IDs and names that don't clash
+// with existing ones; their table preferences come along. With overwrite, existing connections and
+// settings are replaced entirely, except the password storage, which belongs to this machine. Every
+// imported connection is validated before anything changes. Returns a map of imported ID to new ID and
+// the IDs of connections that were removed by an overwrite.
+func (s *ConfigService) importConfigData(imported ConfigData, overwrite bool) (map[string]string, []string, error) {
+	oldIDs := make([]string, 0, len(imported.Connections))
+	for oldID := range imported.Connections {
+		oldIDs = append(oldIDs, oldID)
+	}
+	sort.Strings(oldIDs) // Deterministic order for the name suffixes
+	for _, oldID := range oldIDs {
+		details := imported.Connections[oldID]
+		if strings.TrimSpace(details.Name) == "" {
+			return nil, nil, fmt.Errorf("connection %s has no name", oldID)
+		}
+		if err := ValidateConnectionDetails(details); err != nil {
+			return nil, nil, fmt.Errorf("connection '%s': %w", details.Name, err)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var removedIDs []string
+	if overwrite {
+		for id := range s.config.Connections {
+			removedIDs = append(removedIDs, id)
+			s.deleteConnectionSecrets(id)
+		}
+		s.config.Connections = make(map[string]ConnectionDetails)
+		if imported.ThemeSettings != nil {
+			s.config.ThemeSettings = imported.ThemeSettings
+		}
+		if imported.AIProviderSettings != nil {
+			s.config.AIProviderSettings = imported.AIProviderSettings
+		}
+		if imported.WindowSettings != nil {
+			s.config.WindowSettings = imported.WindowSettings
+		}
+		if imported.DataViewSettings != nil {
+			settings := *imported.DataViewSettings
+			settings.TablePreferences = make(map[string]TablePreferences) // Added below under the new IDs
+			s.config.DataViewSettings = &settings
+		}
+		s.config.BackgroundActivityDisabled = imported.BackgroundActivityDisabled
+		s.config.MetadataDatabaseConcurrency = imported.MetadataDatabaseConcurrency
+		s.config.MetadataTableConcurrency = imported.MetadataTableConcurrency
+		s.config.DefaultConnectionID = ""
+	}
+
+	idMapping := make(map[string]string, len(imported.Connections))
+	for _, oldID := range oldIDs {
+		details := imported.Connections[oldID]
+		newID := generateConnectionID()
+		for _, exists := s.config.Connections[newID]; exists; _, exists = s.config.Connections[newID] {
+			newID = generateConnectionID()
+		}
+		details.ID = newID
+		details.Name = s.uniqueConnectionName(details.Name)
+		s.config.Connections[newID] = details
+		if s.usesKeychain() {
+			s.storeConnectionSecrets(newID, details)
+		}
+		idMapping[oldID] = newID
+	}
+
+	if overwrite {
+		s.config.DefaultConnectionID = idMapping[imported.DefaultConnectionID]
+	}
+	if imported.DataViewSettings != nil {
+		for key, prefs := range imported.DataViewSettings.TablePreferences {
+			oldID, table, _ := strings.Cut(key, "/")
+			newID, ok := idMapping[oldID]
+			if !ok {
+				continue // Preferences of a connection that isn't in the import
+			}
+			if s.config.DataViewSettings == nil {
+				s.config.DataViewSettings = &DataViewSettings{DefaultPageSize: DefaultPageSize}
+			}
+			if s.config.DataViewSettings.TablePreferences == nil {
+				s.config.DataViewSettings.TablePreferences = make(map[string]TablePreferences)
+			}
+			s.config.DataViewSettings.TablePreferences[newID+"/"+table] = prefs
+		}
+	}
+
+	if err := s.saveConfig(); err != nil {
+		return nil, nil, err
+	}
+	return idMapping, removedIDs, nil
+}
+
 // --- Theme Settings Management Methods ---
 
 // GetThemeSettings retrieves the current theme settings.
@@ -333,3 +743,238 @@ func (s *ConfigService) SaveWindowSettings(settings WindowSettings) error {
 	s.config.WindowSettings = &settings
 	return s.saveConfig()
 }
+
+// --- Background Activity Methods ---
+
+// IsBackgroundActivityEnabled reports whether automatic background DB activity is allowed.
+func (s *ConfigService) IsBackgroundActivityEnabled() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return !s.config.BackgroundActivityDisabled
+}
+
+// SetBackgroundActivityEnabled updates and saves the background activity setting.
+func (s *ConfigService) SetBackgroundActivityEnabled(enabled bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.config.BackgroundActivityDisabled = !enabled
+	return s.saveConfig()
+}
+
+// GetMetadataConcurrency returns how many databases and tables metadata extraction may fetch at once.
+func (s *ConfigService) GetMetadataConcurrency() (databases, tables int) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	databases, tables = s.config.MetadataDatabaseConcurrency, s.config.MetadataTableConcurrency
+	if databases <= 0 {
+		databases = DefaultMetadataDatabaseConcurrency
+	}
+	if tables <= 0 {
+		tables = DefaultMetadataTableConcurrency
+	}
+	return databases, tables
+}
+
+// SetMetadataConcurrency updates and saves the metadata extraction limits. Values under 1 restore the defaults.
+func (s *ConfigService) SetMetadataConcurrency(databases, tables int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.config.MetadataDatabaseConcurrency = max(databases, 0)
+	s.config.MetadataTableConcurrency = max(tables, 0)
+	return s.saveConfig()
+}
+
+// --- Data View Settings Methods ---
+
+func tablePreferencesKey(connectionID, dbName, tableName string) string {
+	return fmt.Sprintf("%s/%s.%s", connectionID, dbName, tableName)
+}
+
+// GetPageSize returns the page size for a table: its own preference, else the global default.
+func (s *ConfigService) GetPageSize(connectionID, dbName, tableName string) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	settings := s.config.DataViewSettings
+	if settings == nil {
+		return DefaultPageSize
+	}
+	if prefs, ok := settings.TablePreferences[tablePreferencesKey(connectionID, dbName, tableName)]; ok && prefs.PageSize > 0 {
+		return min(prefs.PageSize, MaxPageSize)
+	}
+	if settings.DefaultPageSize > 0 {
+		return min(settings.DefaultPageSize, MaxPageSize)
+	}
+	return DefaultPageSize
+}
+
+// SetDefaultPageSize updates and saves the global default page size.
+func (s *ConfigService) SetDefaultPageSize(pageSize int) error {
+	if pageSize <= 0 || pageSize > MaxPageSize {
+		return fmt.Errorf("page size must be between 1 and %d", MaxPageSize)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.config.DataViewSettings == nil {
+		s.config.DataViewSettings = &DataViewSettings{TablePreferences: make(map[string]TablePreferences)}
+	}
+	s.config.DataViewSettings.DefaultPageSize = pageSize
+	return s.saveConfig()
+}
+
+// SetTablePageSize updates and saves the page size for a single table. A size of 0 clears it.
+func (s *ConfigService) SetTablePageSize(connectionID, dbName, tableName string, pageSize int) error {
+	if pageSize < 0 || pageSize > MaxPageSize {
+		return fmt.Errorf("page size must be between 0 and %d", MaxPageSize)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.config.DataViewSettings == nil {
+		s.config.DataViewSettings = &DataViewSettings{DefaultPageSize: DefaultPageSize}
+	}
+	if s.config.DataViewSettings.TablePreferences == nil {
+		s.config.DataViewSettings.TablePreferences = make(map[string]TablePreferences)
+	}
+
+	key := tablePreferencesKey(connectionID, dbName, tableName)
+	prefs := s.config.DataViewSettings.TablePreferences[key]
+	prefs.PageSize = pageSize
+	if prefs == (TablePreferences{}) {
+		delete(s.config.DataViewSettings.TablePreferences, key)
+	} else {
+		s.config.DataViewSettings.TablePreferences[key] = prefs
+	}
+	return s.saveConfig()
+}
+
+// IsNullsAsSentinelEnabled reports whether NULLs in results are replaced with a NullSentinel.
+func (s *ConfigService) IsNullsAsSentinelEnabled() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.config.DataViewSettings != nil && s.config.DataViewSettings.NullsAsSentinel
+}
+
+// SetNullsAsSentinelEnabled updates and saves the nulls-as-sentinel setting.
+func (s *ConfigService) SetNullsAsSentinelEnabled(enabled bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.config.DataViewSettings == nil {
+		s.config.DataViewSettings = &DataViewSettings{DefaultPageSize: DefaultPageSize, TablePreferences: make(map[string]TablePreferences)}
+	}
+	s.config.DataViewSettings.NullsAsSentinel = enabled
+	return s.saveConfig()
+}
+
+// DefaultAutoLimit is the LIMIT appended to editor SELECTs without one, unless configured otherwise
+const DefaultAutoLimit = 1000
+
+// GetAutoLimit returns the LIMIT appended to editor SELECTs without one, or 0 when disabled.
+func (s *ConfigService) GetAutoLimit() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	settings := s.config.DataViewSettings
+	switch {
+	case settings == nil:
+		return DefaultAutoLimit
+	case settings.AutoLimitDisabled:
+		return 0
+	case settings.AutoLimitRows > 0:
+		return settings.AutoLimitRows
+	}
+	return DefaultAutoLimit
+}
+
+// SetAutoLimit updates and saves the automatic LIMIT setting. rows <= 0 keeps the current row count.
+func (s *ConfigService) SetAutoLimit(enabled bool, rows int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.config.DataViewSettings == nil {
+		s.config.DataViewSettings = &DataViewSettings{DefaultPageSize: DefaultPageSize, TablePreferences: make(map[string]TablePreferences)}
+	}
+	s.config.DataViewSettings.AutoLimitDisabled = !enabled
+	if rows > 0 {
+		s.config.DataViewSettings.AutoLimitRows = rows
+	}
+	return s.saveConfig()
+}
+
+// GetQueryCacheTTL returns how long editor SELECT results are cached, 0 when caching is off.
+func (s *ConfigService) GetQueryCacheTTL() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.config.DataViewSettings == nil {
+		return 0
+	}
+	return time.Duration(s.config.DataViewSettings.QueryCacheTTLSeconds) * time.Second
+}
+
+// SetQueryCacheTTL updates and saves the query cache TTL. A TTL under a second turns caching off.
+func (s *ConfigService) SetQueryCacheTTL(ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.config.DataViewSettings == nil {
+		s.config.DataViewSettings = &DataViewSettings{DefaultPageSize: DefaultPageSize, TablePreferences: make(map[string]TablePreferences)}
+	}
+	s.config.DataViewSettings.QueryCacheTTLSeconds = max(int(ttl/time.Second), 0)
+	return s.saveConfig()
+}
+
+// DefaultNullDisplay is how NULL is shown in the grid unless configured otherwise
+const DefaultNullDisplay = "NULL"
+
+// GetNullDisplay returns the string NULL is displayed as and whether it was configured by the user.
+func (s *ConfigService) GetNullDisplay() (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.config.DataViewSettings == nil || s.config.DataViewSettings.NullDisplay == nil {
+		return DefaultNullDisplay, false
+	}
+	return *s.config.DataViewSettings.NullDisplay, true
+}
+
+// SetNullDisplay updates and saves the string NULL is displayed as. An empty string is a valid choice.
+func (s *ConfigService) SetNullDisplay(display string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.config.DataViewSettings == nil {
+		s.config.DataViewSettings = &DataViewSettings{DefaultPageSize: DefaultPageSize, TablePreferences: make(map[string]TablePreferences)}
+	}
+	s.config.DataViewSettings.NullDisplay = &display
+	return s.saveConfig()
+}
+
+// IsColumnHintsEnabled reports whether query results should carry column rendering hints.
+func (s *ConfigService) IsColumnHintsEnabled() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.config.DataViewSettings == nil || !s.config.DataViewSettings.ColumnHintsDisabled
+}
+
+// SetColumnHintsEnabled updates and saves the column hints setting.
+func (s *ConfigService) SetColumnHintsEnabled(enabled bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.config.DataViewSettings == nil {
+		s.config.DataViewSettings = &DataViewSettings{DefaultPageSize: DefaultPageSize, TablePreferences: make(map[string]TablePreferences)}
+	}
+	s.config.DataViewSettings.ColumnHintsDisabled = !enabled
+	return s.saveConfig()
+}
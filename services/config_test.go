@@ -0,0 +1,115 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newTestConfigService returns a ConfigService holding the given connections, keyed by their IDs, that saves
+// to a temporary directory.
+func newTestConfigService(t *testing.T, connections ...ConnectionDetails) *ConfigService {
+	t.Helper()
+	s := &ConfigService{
+		configPath: filepath.Join(t.TempDir(), ConfigFileName),
+		inKeychain: make(map[string]bool),
+		config: &ConfigData{
+			Connections: make(map[string]ConnectionDetails),
+			DataViewSettings: &DataViewSettings{
+				DefaultPageSize:  DefaultPageSize,
+				TablePreferences: make(map[string]TablePreferences),
+			},
+		},
+	}
+	for _, details := range connections {
+		s.config.Connections[details.ID] = details
+	}
+	return s
+}
+
+func TestNullDisplay(t *testing.T) {
+	s := newTestConfigService(t)
+	if display, configured := s.GetNullDisplay(); display != DefaultNullDisplay || configured {
+		t.Errorf("display = %q, %v, want the default", display, configured)
+	}
+
+	// An empty display is a choice of its own, not the default
+	if err := s.SetNullDisplay(""); err != nil {
+		t.Fatal(err)
+	}
+	reloaded := newTestConfigService(t)
+	reloaded.configPath = s.configPath
+	if err := reloaded.loadConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if display, configured := reloaded.GetNullDisplay(); display != "" || !configured {
+		t.Errorf("reloaded display = %q, %v, want a configured empty string", display, configured)
+	}
+}
+
+// dirEntries returns the names of the files in dir.
+func dirEntries(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, len(entries))
+	for i, entry := range entries {
+		names[i] = entry.Name()
+	}
+	return names
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeFileAtomic(path, []byte("new"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "new" {
+		t.Errorf("file holds %q (%v), want %q", data, err, "new")
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("file mode = %v (%v), want 0600", info.Mode().Perm(), err)
+	}
+	if names := dirEntries(t, dir); len(names) != 1 {
+		t.Errorf("directory holds %v, want only config.json", names)
+	}
+}
+
+func TestWriteFileAtomicFailureKeepsOriginal(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	renameErr := errors.New("disk unplugged")
+	renameFile = func(string, string) error { return renameErr }
+	t.Cleanup(func() { renameFile = os.Rename })
+
+	if err := writeFileAtomic(path, []byte("new"), 0600); !errors.Is(err, renameErr) {
+		t.Fatalf("err = %v, want %v", err, renameErr)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "old" {
+		t.Errorf("file holds %q (%v), want the original %q", data, err, "old")
+	}
+	if names := dirEntries(t, dir); len(names) != 1 || names[0] != "config.json" {
+		t.Errorf("directory holds %v, want only config.json without a temporary file", names)
+	}
+}
+
+func TestWriteFileAtomicMissingDirectory(t *testing.T) {
+	dir := t.TempDir()
+	if err := writeFileAtomic(filepath.Join(dir, "missing", "config.json"), []byte("new"), 0600); err == nil {
+		t.Fatal("writing into a missing directory succeeded")
+	}
+	if names := dirEntries(t, dir); len(names) != 0 {
+		t.Errorf("directory holds %v, want nothing", names)
+	}
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// connectionsFileVersion is the format version written by ExportConnections
+const connectionsFileVersion = 1
+
+// How ImportConnections handles an imported connection whose name is already taken
+const (
+	ImportConflictRename    = "rename"    // Import under a name with a numeric suffix
+	ImportConflictSkip      = "skip"      // Keep the existing connection and drop the imported one
+	ImportConflictOverwrite = "overwrite" // Replace the existing connection
+)
+
+// connectionsFile is the shareable document produced by ExportConnections
+type connectionsFile struct {
+	Version     int                 `json:"version"`
+	Connections []ConnectionDetails `json:"connections"`
+}
+
+// ImportSummary lists, by name, what ImportConnections did with each imported connection
+type ImportSummary struct {
+	Added       []string `json:"added"`
+	Renamed     []string `json:"renamed"` // New names of connections imported under a suffixed name
+	Skipped     []string `json:"skipped"`
+	Overwritten []string `json:"overwritten"`
+	// ReplacedIDs are the IDs of overwritten connections, whose metadata no longer applies
+	ReplacedIDs []string `json:"replacedIds"`
+}
+
+// ExportConnections returns a JSON document of the given connections, sorted by name. Passwords are removed
+// unless includeSecrets is set, so the file is safe to share.
+func (s *ConfigService) ExportConnections(ids []string, includeSecrets bool) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	file := connectionsFile{Version: connectionsFileVersion, Connections: make([]ConnectionDetails, 0, len(ids))}
+	for _, id := range ids {
+		details, ok := s.config.Connections[id]
+		if !ok {
+			return nil, fmt.Errorf("connection '%s' not found", id)
+		}
+		details.ID = "" // IDs are assigned on import
+		if !includeSecrets {
+			details.Password = ""
+			if details.SSHTunnel != nil {
+				tunnel := *details.SSHTunnel
+				tunnel.Password = ""
+				details.SSHTunnel = &tunnel
+			}
+		}
+		file.Connections = append(file.Connections, details)
+	}
+	sort.Slice(file.Connections, func(i, j int) bool {
+		return file.Connections[i].Name < file.Connections[j].Name
+	})
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal connections: %w", err)
+	}
+	return data, nil
+}
+
+// ImportConnections adds the connections of a document created by ExportConnections under fresh IDs.
+// onConflict (ImportConflictRename, ImportConflictSkip or ImportConflictOverwrite) decides what happens
+// when a connection with the same name already exists.
+func (s *ConfigService) ImportConnections(data []byte, onConflict string) (ImportSummary, error) {
+	summary := ImportSummary{
+		Added:       make([]string, 0),
+		Renamed:     make([]string, 0),
+		Skipped:     make([]string, 0),
+		Overwritten: make([]string, 0),
+		ReplacedIDs: make([]string, 0),
+	}
+	switch onConflict {
+	case ImportConflictRename, ImportConflictSkip, ImportConflictOverwrite:
+	default:
+		return summary, fmt.Errorf("invalid conflict mode '%s', expected rename, skip or overwrite", onConflict)
+	}
+
+	var file connectionsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return summary, fmt.Errorf("invalid connections file: %w", err)
+	}
+	if file.Version > connectionsFileVersion {
+		return summary, fmt.Errorf("connections file version %d is newer than supported (%d)", file.Version, connectionsFileVersion)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, details := range file.Connections {
+		if details.Name == "" {
+			return summary, fmt.Errorf("invalid connections file: connection without a name")
+		}
+
+		existingID := ""
+		for id, existing := range s.config.Connections {
+			if sameConnectionName(existing.Name, details.Name) {
+				existingID = id
+				break
+			}
+		}
+
+		switch {
+		case existingID == "":
+			summary.Added = append(summary.Added, details.Name)
+		case onConflict == ImportConflictSkip:
+			summary.Skipped = append(summary.Skipped, details.Name)
+			continue
+		case onConflict == ImportConflictOverwrite:
+			delete(s.config.Connections, existingID)
+			s.deleteConnectionSecrets(existingID)
+			summary.Overwritten = append(summary.Overwritten, details.Name)
+			summary.ReplacedIDs = append(summary.ReplacedIDs, existingID)
+		default:
+			details.Name = s.uniqueConnectionName(details.Name)
+			summary.Renamed = append(summary.Renamed, details.Name)
+		}
+
+		details.ID = generateConnectionID()
+		for _, exists := s.config.Connections[details.ID]; exists; _, exists = s.config.Connections[details.ID] {
+			details.ID = generateConnectionID()
+		}
+		s.config.Connections[details.ID] = details
+		if s.usesKeychain() {
+			s.storeConnectionSecrets(details.ID, details)
+		}
+	}
+
+	if err := s.saveConfig(); err != nil {
+		return summary, err
+	}
+	return summary, nil
+}
@@ -0,0 +1,365 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// sharedConnections returns connections with database and SSH passwords, keyed by ID.
+func sharedConnections() []ConnectionDetails {
+	return []ConnectionDetails{
+		{ID: "prod-id", Name: "prod", Host: "prod.example.com", Port: "4000", User: "root", Password: "prod-secret",
+			SSHTunnel: &SSHTunnel{Host: "bastion.example.com", User: "ops", Password: "ssh-secret"}},
+		{ID: "staging-id", Name: "staging", Host: "staging.example.com", Port: "4000", User: "root", Password: "staging-secret"},
+	}
+}
+
+// connectionByName returns the connection of s named name.
+func connectionByName(t *testing.T, s *ConfigService, name string) (ConnectionDetails, bool) {
+	t.Helper()
+	connections, err := s.GetAllConnections()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, details := range connections {
+		if details.Name == name {
+			return details, true
+		}
+	}
+	return ConnectionDetails{}, false
+}
+
+func TestExportConnections(t *testing.T) {
+	s := newTestConfigService(t, sharedConnections()...)
+	for _, includeSecrets := range []bool{false, true} {
+		data, err := s.ExportConnections([]string{"staging-id", "prod-id"}, includeSecrets)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var file connectionsFile
+		if err := json.Unmarshal(data, &file); err != nil {
+			t.Fatal(err)
+		}
+		if file.Version != connectionsFileVersion || len(file.Connections) != 2 {
+			t.Fatalf("exported version %d with %d connections, want version %d with 2", file.Version, len(file.Connections), connectionsFileVersion)
+		}
+		prod, staging := file.Connections[0], file.Connections[1]
+		if prod.Name != "prod" || staging.Name != "staging" {
+			t.Errorf("exported %s, %s, want them sorted by name", prod.Name, staging.Name)
+		}
+		if prod.ID != "" || staging.ID != "" {
+			t.Error("exported connections keep their IDs")
+		}
+		hasSecrets := prod.Password == "prod-secret" && prod.SSHTunnel.Password == "ssh-secret" && staging.Password == "staging-secret"
+		noSecrets := prod.Password == "" && prod.SSHTunnel.Password == "" && staging.Password == ""
+		if includeSecrets && !hasSecrets || !includeSecrets && (!noSecrets || bytes.Contains(data, []byte("secret"))) {
+			t.Errorf("includeSecrets %v exported %s", includeSecrets, data)
+		}
+		if prod.SSHTunnel.Host != "bastion.example.com" || prod.Host != "prod.example.com" {
+			t.Errorf("exported %+v, want everything but the secrets", prod)
+		}
+	}
+
+	// Stripping secrets from the export leaves the saved connection alone
+	if saved, _, _ := s.GetConnection("prod-id"); saved.SSHTunnel.Password != "ssh-secret" {
+		t.Error("exporting without secrets removed the saved SSH password")
+	}
+	if _, err := s.ExportConnections([]string{"missing"}, false); err == nil {
+		t.Error("exporting an unknown connection succeeded")
+	}
+}
+
+func TestImportConnectionsSecrets(t *testing.T) {
+	source := newTestConfigService(t, sharedConnections()...)
+	for _, includeSecrets := range []bool{false, true} {
+		data, err := source.ExportConnections([]string{"prod-id", "staging-id"}, includeSecrets)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := newTestConfigService(t)
+		summary, err := s.ImportConnections(data, ImportConflictRename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(summary.Added, []string{"prod", "staging"}) {
+			t.Errorf("added %v, want prod and staging", summary.Added)
+		}
+
+		prod, ok := connectionByName(t, s, "prod")
+		if !ok {
+			t.Fatal("prod wasn't imported")
+		}
+		if prod.ID == "" || prod.ID == "prod-id" {
+			t.Errorf("imported ID = %q, want a fresh one", prod.ID)
+		}
+		wantPassword, wantSSHPassword := "", ""
+		if includeSecrets {
+			wantPassword, wantSSHPassword = "prod-secret", "ssh-secret"
+		}
+		if prod.Password != wantPassword || prod.SSHTunnel == nil || prod.SSHTunnel.Password != wantSSHPassword {
+			t.Errorf("includeSecrets %v imported passwords %q and %+v", includeSecrets, prod.Password, prod.SSHTunnel)
+		}
+
+		// The import is saved
+		saved, err := os.ReadFile(s.configPath)
+		if err != nil || !strings.Contains(string(saved), prod.ID) {
+			t.Errorf("config file doesn't hold the imported connection (%v)", err)
+		}
+	}
+}
+
+func TestImportConnectionsConflictModes(t *testing.T) {
+	data, err := newTestConfigService(t, sharedConnections()...).ExportConnections([]string{"prod-id", "staging-id"}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	existing := ConnectionDetails{ID: "old-prod", Name: "prod", Host: "old.example.com", Port: "4000"}
+
+	tests := []struct {
+		mode         string
+		wantSummary  ImportSummary
+		wantNames    []string
+		wantProdHost string // Host of the connection named prod afterwards
+	}{
+		{
+			mode:         ImportConflictRename,
+			wantSummary:  ImportSummary{Added: []string{"staging"}, Renamed: []string{"prod (2)"}, Skipped: []string{}, Overwritten: []string{}, ReplacedIDs: []string{}},
+			wantNames:    []string{"prod", "prod (2)", "staging"},
+			wantProdHost: "old.example.com",
+		},
+		{
+			mode:         ImportConflictSkip,
+			wantSummary:  ImportSummary{Added: []string{"staging"}, Renamed: []string{}, Skipped: []string{"prod"}, Overwritten: []string{}, ReplacedIDs: []string{}},
+			wantNames:    []string{"prod", "staging"},
+			wantProdHost: "old.example.com",
+		},
+		{
+			mode:         ImportConflictOverwrite,
+			wantSummary:  ImportSummary{Added: []string{"staging"}, Renamed: []string{}, Skipped: []string{}, Overwritten: []string{"prod"}, ReplacedIDs: []string{"old-prod"}},
+			wantNames:    []string{"prod", "staging"},
+			wantProdHost: "prod.example.com",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			s := newTestConfigService(t, existing)
+			summary, err := s.ImportConnections(data, tt.mode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(summary.Added, tt.wantSummary.Added) || !slices.Equal(summary.Renamed, tt.wantSummary.Renamed) ||
+				!slices.Equal(summary.Skipped, tt.wantSummary.Skipped) || !slices.Equal(summary.Overwritten, tt.wantSummary.Overwritten) ||
+				!slices.Equal(summary.ReplacedIDs, tt.wantSummary.ReplacedIDs) {
+				t.Errorf("summary = %+v, want %+v", summary, tt.wantSummary)
+			}
+
+			connections, err := s.GetAllConnections()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, details := range connections {
+				names = append(names, details.Name)
+			}
+			slices.Sort(names)
+			if !slices.Equal(names, tt.wantNames) {
+				t.Errorf("connections = %v, want %v", names, tt.wantNames)
+			}
+
+			prod, _ := connectionByName(t, s, "prod")
+			if prod.Host != tt.wantProdHost {
+				t.Errorf("prod host = %s, want %s", prod.Host, tt.wantProdHost)
+			}
+			// Only the connection left alone keeps the existing ID
+			if _, kept := connections["old-prod"]; kept != (tt.mode != ImportConflictOverwrite) {
+				t.Errorf("existing ID kept = %v", kept)
+			}
+		})
+	}
+}
+
+func TestImportConnectionsConflictIgnoresCase(t *testing.T) {
+	data, err := newTestConfigService(t, sharedConnections()...).ExportConnections([]string{"prod-id"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newTestConfigService(t, ConnectionDetails{ID: "old-prod", Name: "PROD", Host: "old.example.com", Port: "4000"})
+	summary, err := s.ImportConnections(data, ImportConflictRename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(summary.Renamed, []string{"prod (2)"}) || len(summary.Added) != 0 {
+		t.Errorf("summary = %+v, want prod renamed to prod (2) as it clashes with PROD", summary)
+	}
+}
+
+func TestImportConnectionsOverwriteReplacesMetadata(t *testing.T) {
+	data, err := newTestConfigService(t, sharedConnections()...).ExportConnections([]string{"prod-id"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	configService := newTestConfigService(t, ConnectionDetails{ID: "old-prod", Name: "prod", Host: "old.example.com"})
+	metadataService := newTestMetadataService(t, configService)
+	metadataService.metadata["old-prod"] = &ConnectionMetadata{ConnectionID: "old-prod", Databases: map[string]DatabaseMetadata{"shop": {Name: "shop"}}}
+	if err := metadataService.SaveMetadata("old-prod"); err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err := configService.ImportConnections(data, ImportConflictOverwrite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// As App.ImportConnections does, the metadata of replaced connections goes with them
+	for _, connectionID := range summary.ReplacedIDs {
+		if err := metadataService.DeleteConnectionMetadata(connectionID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := os.Stat(metadataService.getMetadataFilePath("old-prod")); !os.IsNotExist(err) {
+		t.Errorf("metadata file of the replaced connection is still there (%v)", err)
+	}
+
+	prod, _ := connectionByName(t, configService, "prod")
+	metadata, err := metadataService.LoadMetadata(context.Background(), prod.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !metadata.LastExtracted.IsZero() || len(metadata.Databases) != 0 {
+		t.Errorf("imported connection starts with metadata %+v, want none", metadata)
+	}
+}
+
+func TestImportWorkspaceRenamesMetadataFiles(t *testing.T) {
+	source := newTestMetadataService(t, newTestConfigService(t, sharedConnections()...))
+	source.metadata["prod-id"] = &ConnectionMetadata{ConnectionID: "prod-id", ConnectionName: "prod", Databases: map[string]DatabaseMetadata{"shop": {Name: "shop"}}}
+	if err := source.SaveMetadata("prod-id"); err != nil {
+		t.Fatal(err)
+	}
+	var archive bytes.Buffer
+	if err := source.ExportWorkspace(&archive, false); err != nil {
+		t.Fatal(err)
+	}
+
+	s := newTestMetadataService(t, newTestConfigService(t))
+	if err := s.ImportWorkspace(&archive, false); err != nil {
+		t.Fatal(err)
+	}
+	prod, ok := connectionByName(t, s.configService, "prod")
+	if !ok || prod.ID == "prod-id" {
+		t.Fatalf("prod imported as %+v, want it under a fresh ID", prod)
+	}
+	if _, err := os.Stat(s.getMetadataFilePath("prod-id")); !os.IsNotExist(err) {
+		t.Errorf("metadata kept the file name of the exported ID (%v)", err)
+	}
+	metadata, err := s.LoadMetadata(context.Background(), prod.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if metadata.ConnectionID != prod.ID || metadata.Databases["shop"].Name != "shop" {
+		t.Errorf("metadata = %+v, want the exported metadata under ID %s", metadata, prod.ID)
+	}
+}
+
+func TestImportConfigDataMerge(t *testing.T) {
+	s := newTestConfigService(t, ConnectionDetails{ID: "mine", Name: "Prod", Host: "mine.example.com", Port: "4000"})
+	imported := ConfigData{
+		Connections: map[string]ConnectionDetails{"prod-id": sharedConnections()[0]},
+		DataViewSettings: &DataViewSettings{DefaultPageSize: 50, TablePreferences: map[string]TablePreferences{
+			"prod-id/shop.orders": {PageSize: 20},
+			"gone-id/shop.orders": {PageSize: 30},
+		}},
+	}
+	idMapping, _, err := s.importConfigData(imported, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newID := idMapping["prod-id"]
+	if details, _, _ := s.GetConnection(newID); details.Name != "prod (2)" {
+		t.Errorf("imported prod named %q next to Prod, want %q", details.Name, "prod (2)")
+	}
+	want := map[string]TablePreferences{newID + "/shop.orders": {PageSize: 20}}
+	if got := s.config.DataViewSettings; got.DefaultPageSize != DefaultPageSize || !maps.Equal(got.TablePreferences, want) {
+		t.Errorf("data view settings = %+v, want the default page size kept and preferences %v", got, want)
+	}
+}
+
+func TestImportConfigDataOverwrite(t *testing.T) {
+	s := newTestConfigService(t, ConnectionDetails{ID: "mine", Name: "mine", Host: "mine.example.com", Port: "4000"})
+	s.config.DefaultConnectionID = "mine"
+	imported := ConfigData{
+		Connections:                 map[string]ConnectionDetails{"prod-id": sharedConnections()[0], "staging-id": sharedConnections()[1]},
+		DataViewSettings:            &DataViewSettings{DefaultPageSize: 50, TablePreferences: map[string]TablePreferences{"staging-id/shop.orders": {PageSize: 20}}},
+		MetadataDatabaseConcurrency: 3,
+		MetadataTableConcurrency:    7,
+		DefaultConnectionID:         "staging-id",
+	}
+	idMapping, removedIDs, err := s.importConfigData(imported, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(removedIDs, []string{"mine"}) {
+		t.Errorf("removed %v, want [mine]", removedIDs)
+	}
+	stagingID := idMapping["staging-id"]
+	if s.config.DefaultConnectionID != stagingID {
+		t.Errorf("default connection = %q, want the new ID of staging %q", s.config.DefaultConnectionID, stagingID)
+	}
+	want := map[string]TablePreferences{stagingID + "/shop.orders": {PageSize: 20}}
+	if got := s.config.DataViewSettings; got.DefaultPageSize != 50 || !maps.Equal(got.TablePreferences, want) {
+		t.Errorf("data view settings = %+v, want page size 50 and preferences %v", got, want)
+	}
+	if s.config.MetadataDatabaseConcurrency != 3 || s.config.MetadataTableConcurrency != 7 {
+		t.Errorf("concurrency = %d/%d, want 3/7", s.config.MetadataDatabaseConcurrency, s.config.MetadataTableConcurrency)
+	}
+
+	// A default connection that isn't in the import is cleared rather than left dangling
+	imported.DefaultConnectionID = "gone-id"
+	if _, _, err := s.importConfigData(imported, true); err != nil {
+		t.Fatal(err)
+	}
+	if s.config.DefaultConnectionID != "" {
+		t.Errorf("default connection = %q, want none", s.config.DefaultConnectionID)
+	}
+}
+
+func TestImportConfigDataInvalidConnection(t *testing.T) {
+	s := newTestConfigService(t, ConnectionDetails{ID: "mine", Name: "mine", Host: "mine.example.com", Port: "4000"})
+	imported := ConfigData{Connections: map[string]ConnectionDetails{
+		"prod-id": sharedConnections()[0],
+		"bad-id":  {Name: "bad", Host: "bad.example.com", Port: "not-a-port"},
+	}}
+	if _, _, err := s.importConfigData(imported, true); err == nil || !strings.Contains(err.Error(), "'bad'") {
+		t.Errorf("err = %v, want it to name the invalid connection", err)
+	}
+	if connections, _ := s.GetAllConnections(); len(connections) != 1 || connections["mine"].Name != "mine" {
+		t.Errorf("failed import left connections %v, want them untouched", connections)
+	}
+}
+
+func TestImportConnectionsInvalid(t *testing.T) {
+	tests := []struct {
+		name, mode, data, wantErr string
+	}{
+		{"unknown mode", "merge", `{"version":1,"connections":[]}`, "invalid conflict mode"},
+		{"not JSON", ImportConflictRename, `connections`, "invalid connections file"},
+		{"newer version", ImportConflictRename, `{"version":2,"connections":[]}`, "newer than supported"},
+		{"nameless connection", ImportConflictRename, `{"version":1,"connections":[{"host":"db"}]}`, "without a name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestConfigService(t)
+			if _, err := s.ImportConnections([]byte(tt.data), tt.mode); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want it to mention %q", err, tt.wantErr)
+			}
+			if connections, _ := s.GetAllConnections(); len(connections) != 0 {
+				t.Errorf("failed import added %d connections", len(connections))
+			}
+		})
+	}
+}
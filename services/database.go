@@ -2,11 +2,22 @@ package services
 
 import (
 	"context"
+	"crypto/sha256"
 	"crypto/tls"
+	"crypto/x509"
 	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"math"
+	"math/big"
+	"net"
+	"os"
+	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	mysql "github.com/go-sql-driver/mysql"
@@ -23,43 +34,294 @@ type ConnectionDetails struct {
 	DBName   string `json:"dbName"`
 	UseTLS   bool   `json:"useTLS"`
 	LastUsed string `json:"lastUsed,omitempty"`
+	// Group is the folder the connection is listed under, empty for ungrouped
+	Group string `json:"group,omitempty"`
+	// TLS options for servers with a private CA or requiring client certificates. The cert and key
+	// files must be given together.
+	TLSCAFile     string `json:"tlsCAFile,omitempty"`
+	TLSCertFile   string `json:"tlsCertFile,omitempty"`
+	TLSKeyFile    string `json:"tlsKeyFile,omitempty"`
+	TLSSkipVerify bool   `json:"tlsSkipVerify,omitempty"` // Don't verify the server certificate
+	// SSHTunnel reaches the server through a bastion host when set
+	SSHTunnel *SSHTunnel `json:"sshTunnel,omitempty"`
+	// ReadHost, if set, receives read-only statements (SELECT, SHOW, EXPLAIN) while everything else goes
+	// to Host. ReadPort defaults to Port.
+	ReadHost string `json:"readHost,omitempty"`
+	ReadPort string `json:"readPort,omitempty"`
+	// SafeMode blocks SELECTs whose plan fully scans more than SafeModeRowThreshold rows
+	SafeMode             bool  `json:"safeMode,omitempty"`
+	SafeModeRowThreshold int64 `json:"safeModeRowThreshold,omitempty"` // Defaults to DefaultSafeModeRowThreshold
+	// ResourceGroup routes this client's sessions into a TiDB resource group when set
+	ResourceGroup string `json:"resourceGroup,omitempty"`
+	// Pool limits, 0 means the default for the host (lower for TiDB Cloud)
+	MaxOpenConns int `json:"maxOpenConns,omitempty"`
+	MaxIdleConns int `json:"maxIdleConns,omitempty"`
+	// ShowWarnings attaches SHOW WARNINGS output to results of statements that return no rows
+	ShowWarnings bool `json:"showWarnings,omitempty"`
+	// QueryTimeoutSeconds stops queries run from the editor after this long, 0 means no timeout
+	QueryTimeoutSeconds int `json:"queryTimeoutSeconds,omitempty"`
+	// MetadataStaleMinutes is the age after which cached metadata is re-extracted automatically. nil uses
+	// StaleMetadataThreshold, 0 never goes stale so extraction only happens on explicit refresh.
+	MetadataStaleMinutes *int `json:"metadataStaleMinutes,omitempty"`
+	// AutoCommit false keeps writes from the editor and row edits in a pending transaction until they are
+	// explicitly committed or rolled back. nil means true.
+	AutoCommit *bool `json:"autoCommit,omitempty"`
+}
+
+// Default pool limits. TiDB Cloud (especially serverless) clusters have a small connection budget.
+const (
+	defaultMaxOpenConns      = 50
+	defaultMaxIdleConns      = 25
+	defaultCloudMaxOpenConns = 10
+	defaultCloudMaxIdleConns = 5
+)
+
+// poolLimits returns the maximum open and idle connections for a connection's pool.
+func poolLimits(details ConnectionDetails) (maxOpen int, maxIdle int) {
+	maxOpen, maxIdle = defaultMaxOpenConns, defaultMaxIdleConns
+	if strings.Contains(details.Host, ".tidbcloud.com") {
+		maxOpen, maxIdle = defaultCloudMaxOpenConns, defaultCloudMaxIdleConns
+	}
+	if details.MaxOpenConns > 0 {
+		maxOpen = details.MaxOpenConns
+	}
+	if details.MaxIdleConns > 0 {
+		maxIdle = details.MaxIdleConns
+	}
+	if maxIdle > maxOpen {
+		maxIdle = maxOpen
+	}
+	return maxOpen, maxIdle
+}
+
+// readEndpoint returns the connection details for the read endpoint, or details itself when the
+// connection has no separate read endpoint.
+func readEndpoint(details ConnectionDetails) ConnectionDetails {
+	if details.ReadHost == "" {
+		return details
+	}
+	read := details
+	read.Host = details.ReadHost
+	if details.ReadPort != "" {
+		read.Port = details.ReadPort
+	}
+	read.ReadHost, read.ReadPort = "", ""
+	if details.SSHTunnel != nil && details.SSHTunnel.RemoteAddr != "" {
+		// The tunnel's remote address points at the write endpoint
+		tunnel := *details.SSHTunnel
+		tunnel.RemoteAddr = ""
+		read.SSHTunnel = &tunnel
+	}
+	return read
+}
+
+// endpointFor returns the connection details a statement runs with: the read endpoint for read-only
+// statements when one is configured, the main endpoint otherwise.
+func endpointFor(details ConnectionDetails, query string) ConnectionDetails {
+	if details.ReadHost != "" && IsReadOnlyStatement(query) {
+		return readEndpoint(details)
+	}
+	return details
 }
 
 // SQLResult defines a standard structure for SQL execution results.
 type SQLResult struct {
-	Columns      []string         `json:"columns,omitempty"`      // Ordered list of column names for SELECT
-	Rows         []map[string]any `json:"rows,omitempty"`         // Used for SELECT queries
-	RowsAffected *int64           `json:"rowsAffected,omitempty"` // Used for INSERT/UPDATE/DELETE
-	LastInsertId *int64           `json:"lastInsertId,omitempty"` // Used for INSERT
-	Message      string           `json:"message,omitempty"`      // Optional message (e.g., for commands like USE)
+	Columns      []string          `json:"columns,omitempty"`      // Ordered list of column names for SELECT
+	ColumnTypes  []ColumnTypeInfo  `json:"columnTypes,omitempty"`  // Parallel to Columns, only set for statements returning rows
+	Rows         []map[string]any  `json:"rows,omitempty"`         // Used for SELECT queries
+	RowsAffected *int64            `json:"rowsAffected,omitempty"` // Used for INSERT/UPDATE/DELETE
+	LastInsertId *int64            `json:"lastInsertId,omitempty"` // Used for INSERT
+	Message      string            `json:"message,omitempty"`      // Optional message (e.g., for commands like USE)
+	DurationMs   int64             `json:"durationMs,omitempty"`   // Wall-clock execution time, including row iteration for SELECT
+	Warnings     []string          `json:"warnings,omitempty"`     // Only collected when ConnectionDetails.ShowWarnings is set
+	ColumnHints  map[string]string `json:"columnHints,omitempty"`  // Column name to rendering hint (url, email, boolean, timestamp)
+	// LimitInjected is set when the app appended a LIMIT to the query, so the rows may be truncated
+	LimitInjected bool `json:"limitInjected,omitempty"`
+	// Cached is set when the result was served from the query cache instead of the server
+	Cached bool `json:"cached,omitempty"`
 }
 
 // DatabaseService handles DB operations.
-type DatabaseService struct{}
+type DatabaseService struct {
+	// Connection pools shared across calls, keyed by DSN fingerprint
+	pools   map[string]*sql.DB
+	poolsMu sync.Mutex
+	// Open transactions by ID, see BeginTx
+	txs  map[string]*liveTx
+	txMu sync.Mutex
+	// txIdleTimeout is TxIdleTimeout, shortened in tests
+	txIdleTimeout time.Duration
+	// OnTxExpired is called after an abandoned transaction was rolled back
+	OnTxExpired func(txID string)
+}
 
 // NewDatabaseService creates a new DatabaseService.
 func NewDatabaseService() *DatabaseService {
-	return &DatabaseService{}
+	return &DatabaseService{
+		pools:         make(map[string]*sql.DB),
+		txs:           make(map[string]*liveTx),
+		txIdleTimeout: TxIdleTimeout,
+	}
+}
+
+// poolKey returns the key of the shared connection pool for the connection details.
+func poolKey(details ConnectionDetails) string {
+	dsn, _ := buildDSN(details)
+	maxOpen, maxIdle := poolLimits(details)
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%d\x00%d", dsn, maxOpen, maxIdle)))
+	return hex.EncodeToString(sum[:])
+}
+
+// getDB returns the shared connection pool for the connection details, creating it on first use.
+func (s *DatabaseService) getDB(details ConnectionDetails) (*sql.DB, error) {
+	key := poolKey(details)
+
+	s.poolsMu.Lock()
+	defer s.poolsMu.Unlock()
+
+	if db, ok := s.pools[key]; ok {
+		return db, nil
+	}
+	db, err := getDBConnection(details)
+	if err != nil {
+		return nil, err
+	}
+	s.pools[key] = db
+	return db, nil
+}
+
+// Close rolls back open transactions and closes every shared connection pool.
+func (s *DatabaseService) Close() {
+	s.RollbackAllTx()
+
+	s.poolsMu.Lock()
+	defer s.poolsMu.Unlock()
+
+	for key, db := range s.pools {
+		if err := db.Close(); err != nil {
+			LogError("Failed to close connection pool: %v", err)
+		}
+		delete(s.pools, key)
+	}
+	CloseSSHTunnels()
+	LogInfo("Closed all connection pools")
 }
 
 // buildDSN creates the Data Source Name string for the connection.
+// The driver's Config handles escaping, so passwords with reserved characters and IPv6 hosts work.
 func buildDSN(details ConnectionDetails) (string, bool) {
 	port := details.Port
 	if port == "" {
 		port = "4000" // Default TiDB port
 	}
-
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true",
-		details.User, details.Password, details.Host, port, details.DBName)
+	// Accept bracketed IPv6 literals as typed by users; JoinHostPort adds the brackets back
+	host := strings.TrimSuffix(strings.TrimPrefix(details.Host, "["), "]")
+
+	cfg := mysql.NewConfig()
+	cfg.User = details.User
+	cfg.Passwd = details.Password
+	cfg.Net = "tcp"
+	cfg.Addr = net.JoinHostPort(host, port)
+	if details.SSHTunnel != nil {
+		cfg.Net = sshNetworkName(*details.SSHTunnel)
+		if details.SSHTunnel.RemoteAddr != "" {
+			cfg.Addr = details.SSHTunnel.RemoteAddr
+		}
+	}
+	cfg.DBName = details.DBName
+	cfg.ParseTime = true
 
 	// Determine if TLS should be used based on flag or host.
 	useTLS := details.UseTLS || strings.Contains(details.Host, ".tidbcloud.com")
 
 	if useTLS {
-		dsn += "&tls=tidb"
+		cfg.TLSConfig = tlsConfigName(details)
+	}
+
+	// Unknown DSN params are applied by the driver as session variables on every new connection
+	if details.ResourceGroup != "" {
+		cfg.Params = map[string]string{"tidb_resource_group": quoteIdentifier(details.ResourceGroup)}
 	}
 
-	return dsn, useTLS
+	return cfg.FormatDSN(), useTLS
+}
+
+// MaskedDSN returns the DSN that would be used for the connection, with the password masked.
+func MaskedDSN(details ConnectionDetails) string {
+	if details.Password != "" {
+		details.Password = "****"
+	}
+	dsn, _ := buildDSN(details)
+	return dsn
+}
+
+var (
+	// registeredTLSConfigs tracks the TLS config names already registered with the driver
+	registeredTLSConfigs = make(map[string]bool)
+	tlsConfigMu          sync.Mutex
+)
+
+// tlsConfigName returns the driver TLS config name for a connection, derived from its ID and TLS
+// parameters so connections never share (and overwrite) each other's config.
+func tlsConfigName(details ConnectionDetails) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		details.ID, details.Host, details.TLSCAFile, details.TLSCertFile, details.TLSKeyFile,
+		fmt.Sprint(details.TLSSkipVerify),
+	}, "\x00")))
+	return "tidb-" + hex.EncodeToString(sum[:8])
+}
+
+// buildTLSConfig creates the TLS config for a connection from its TLS options.
+func buildTLSConfig(details ConnectionDetails) (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         strings.TrimSuffix(strings.TrimPrefix(details.Host, "["), "]"),
+		InsecureSkipVerify: details.TLSSkipVerify,
+	}
+	if details.TLSCAFile != "" {
+		pem, err := os.ReadFile(details.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificates found in CA file %s", details.TLSCAFile)
+		}
+		cfg.RootCAs = pool
+	}
+	if (details.TLSCertFile == "") != (details.TLSKeyFile == "") {
+		return nil, fmt.Errorf("client certificate and key files must be set together")
+	}
+	if details.TLSCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(details.TLSCertFile, details.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// registerTLSConfig registers the connection's TLS config with the driver once per distinct config.
+func registerTLSConfig(details ConnectionDetails) error {
+	name := tlsConfigName(details)
+
+	tlsConfigMu.Lock()
+	defer tlsConfigMu.Unlock()
+
+	if registeredTLSConfigs[name] {
+		return nil
+	}
+	cfg, err := buildTLSConfig(details)
+	if err != nil {
+		return err
+	}
+	if err := mysql.RegisterTLSConfig(name, cfg); err != nil {
+		return fmt.Errorf("failed to register TLS config: %w", err)
+	}
+	registeredTLSConfigs[name] = true
+	LogInfo("TLS config %s registered for host: %s", name, details.Host)
+	return nil
 }
 
 // getDBConnection handles creating the DB connection, including TLS setup.
@@ -68,15 +330,12 @@ func getDBConnection(details ConnectionDetails) (*sql.DB, error) {
 	LogInfo("Attempting to connect to database %s on %s:%s", details.DBName, details.Host, details.Port)
 
 	if useTLS {
-		// Register TLS config, allowing re-registration for different hosts.
-		err := mysql.RegisterTLSConfig("tidb", &tls.Config{
-			MinVersion: tls.VersionTLS12,
-			ServerName: details.Host,
-		})
-		if err != nil && !strings.Contains(err.Error(), "already registered") {
-			return nil, fmt.Errorf("failed to register TLS config: %w", err)
+		if err := registerTLSConfig(details); err != nil {
+			return nil, err
 		}
-		LogInfo("TLS config registered for host: %s", details.Host)
+	}
+	if details.SSHTunnel != nil {
+		registerSSHTunnel(*details.SSHTunnel)
 	}
 
 	db, err := sql.Open("mysql", dsn)
@@ -86,8 +345,9 @@ func getDBConnection(details ConnectionDetails) (*sql.DB, error) {
 	LogInfo("Database connection established successfully")
 
 	// Configure connection pool
-	db.SetMaxOpenConns(50)                  // Increased to handle high concurrency
-	db.SetMaxIdleConns(25)                  // Half of max open connections
+	maxOpen, maxIdle := poolLimits(details)
+	db.SetMaxOpenConns(maxOpen)
+	db.SetMaxIdleConns(maxIdle)
 	db.SetConnMaxLifetime(10 * time.Minute) // Increased slightly for better connection reuse
 	db.SetConnMaxIdleTime(5 * time.Minute)  // Keep idle time shorter to free up resources
 
@@ -96,31 +356,135 @@ func getDBConnection(details ConnectionDetails) (*sql.DB, error) {
 
 // TestConnection attempts to ping the database.
 func (s *DatabaseService) TestConnection(ctx context.Context, details ConnectionDetails) (bool, error) {
+	if details.ResourceGroup != "" {
+		if err := s.ValidateResourceGroup(ctx, details); err != nil {
+			return false, err
+		}
+	}
+
+	if err := pingEndpoint(details); err != nil {
+		return false, err
+	}
+	if details.ReadHost != "" {
+		read := readEndpoint(details)
+		if err := pingEndpoint(read); err != nil {
+			return false, fmt.Errorf("read endpoint %s:%s: %w", read.Host, read.Port, err)
+		}
+	}
+	return true, nil
+}
+
+// pingEndpoint opens a dedicated connection with the details and pings the server.
+func pingEndpoint(details ConnectionDetails) error {
 	db, err := getDBConnection(details)
 	if err != nil {
-		return false, fmt.Errorf("connection setup failed: %w", err)
+		return fmt.Errorf("connection setup failed: %w", err)
 	}
 	defer db.Close()
 
-	err = db.Ping()
-	if err != nil {
-		return false, fmt.Errorf("failed to ping database: %w", err)
+	if err := db.Ping(); err != nil {
+		return fmt.Errorf("failed to ping database: %w", err)
 	}
-	return true, nil
+	return nil
+}
+
+// ConnectionTestResult reports the outcome of TestConnectionAutoTLS
+type ConnectionTestResult struct {
+	Success    bool              `json:"success"`
+	UseTLS     bool              `json:"useTLS"`     // The TLS mode that worked
+	TLSToggled bool              `json:"tlsToggled"` // True when the working mode differs from the configured one
+	Details    ConnectionDetails `json:"details"`    // The configuration that worked, to be saved
+}
+
+// TestConnectionAutoTLS tests the connection with the configured TLS setting and, if that fails
+// during the TLS handshake, retries once with TLS toggled. Other failures, such as wrong credentials
+// or an unreachable host, are returned without retrying.
+func (s *DatabaseService) TestConnectionAutoTLS(ctx context.Context, details ConnectionDetails) (*ConnectionTestResult, error) {
+	_, err := s.TestConnection(ctx, details)
+	if err == nil {
+		return &ConnectionTestResult{Success: true, UseTLS: details.UseTLS, Details: details}, nil
+	}
+	if !isTLSHandshakeError(err) {
+		return nil, err
+	}
+
+	toggled := details
+	toggled.UseTLS = !details.UseTLS
+	_, currentTLS := buildDSN(details)
+	if _, toggledTLS := buildDSN(toggled); toggledTLS == currentTLS {
+		return nil, err // TLS is forced for this host, toggling changes nothing
+	}
+
+	LogInfo("Connection to %s failed during TLS negotiation (%v), retrying with TLS %v", details.Host, err, toggled.UseTLS)
+	if _, retryErr := s.TestConnection(ctx, toggled); retryErr != nil {
+		return nil, fmt.Errorf("connection failed with TLS both enabled and disabled: %w", err)
+	}
+	return &ConnectionTestResult{Success: true, UseTLS: toggled.UseTLS, TLSToggled: true, Details: toggled}, nil
+}
+
+// isTLSHandshakeError reports whether err indicates a TLS mismatch between client and server,
+// as opposed to e.g. an authentication or network failure.
+func isTLSHandshakeError(err error) bool {
+	if errors.Is(err, mysql.ErrNoTLS) {
+		return true // TLS requested but the server doesn't support it
+	}
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		// The server requires TLS (3159 on MySQL, 1105 with this message on TiDB)
+		return mysqlErr.Number == 3159 || strings.Contains(strings.ToLower(mysqlErr.Message), "insecure transport")
+	}
+	var recordErr tls.RecordHeaderError
+	if errors.As(err, &recordErr) {
+		return true
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "tls:") || strings.Contains(msg, "x509:")
 }
 
 // ExecuteSQL runs a query and returns results or execution status in a structured format.
-func (s *DatabaseService) ExecuteSQL(ctx context.Context, details ConnectionDetails, query string) (*SQLResult, error) {
+// Optional args are bound to `?` placeholders in the query.
+func (s *DatabaseService) ExecuteSQL(ctx context.Context, details ConnectionDetails, query string, args ...any) (*SQLResult, error) {
 	LogInfo("Executing SQL query: %s", query)
-
-	db, err := getDBConnection(details)
+	details = endpointFor(details, query)
+
+	var db *sql.DB
+	var err error
+	if changesSessionState(query) {
+		// Run on a throwaway connection so the session change can't leak into the shared pool
+		db, err = getDBConnection(details)
+		if err == nil {
+			defer db.Close()
+		}
+	} else {
+		db, err = s.getDB(details)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("connection setup failed: %w", err)
 	}
-	defer db.Close()
 
+	return retryRegionUnavailable(ctx, query, func() (*SQLResult, error) {
+		// Hold a single connection so SHOW WARNINGS reports on this statement
+		conn, err := db.Conn(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to acquire connection: %w", err)
+		}
+		defer conn.Close()
+
+		return s.runSQL(ctx, conn, details, query, args...)
+	})
+}
+
+// sqlRunner is implemented by *sql.Conn and *sql.Tx
+type sqlRunner interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// runSQL executes a statement on runner and builds its SQLResult.
+func (s *DatabaseService) runSQL(ctx context.Context, conn sqlRunner, details ConnectionDetails, query string, args ...any) (*SQLResult, error) {
 	// Attempt to execute as a query first (SELECT, SHOW, DESCRIBE, etc.)
-	rows, queryErr := db.QueryContext(ctx, query)
+	start := time.Now()
+	rows, queryErr := conn.QueryContext(ctx, query, args...)
 	if queryErr == nil {
 		LogInfo("Query executed successfully, processing results")
 		defer rows.Close()
@@ -128,51 +492,46 @@ func (s *DatabaseService) ExecuteSQL(ctx context.Context, details ConnectionDeta
 		if err != nil {
 			// This specific error check is useful for queries like `USE database;` which succeed but return no columns/rows.
 			if strings.Contains(err.Error(), "no columns in result set") {
-				return &SQLResult{Message: fmt.Sprintf("Command executed successfully: %s", query)}, nil
+				duration := time.Since(start)
+				rows.Close()
+				return &SQLResult{
+					Message:    fmt.Sprintf("Command executed successfully: %s", query),
+					DurationMs: duration.Milliseconds(),
+					Warnings:   s.collectWarnings(ctx, conn, details),
+				}, nil
 			}
 			return nil, fmt.Errorf("failed to get columns: %w", err)
 		}
 
-		var results []map[string]any
-		for rows.Next() {
-			values := make([]any, len(columns))
-			scanArgs := make([]any, len(columns))
-			for i := range values {
-				scanArgs[i] = &values[i]
-			}
-
-			err = rows.Scan(scanArgs...)
-			if err != nil {
-				// Log the specific row scanning error
-				log.Printf("Error scanning row for query [%s]: %v", query, err)
-				// Depending on requirements, you might want to return partial results or a specific error
-				return nil, fmt.Errorf("failed to scan row: %w", err)
-			}
-
-			rowMap := make(map[string]any)
-			for i, col := range columns {
-				val := values[i]
-				// Convert []byte to string for better JSON representation
-				if b, ok := val.([]byte); ok {
-					rowMap[col] = string(b)
-				} else {
-					rowMap[col] = val // Keep other types as they are (int, float, null, etc.)
-				}
-			}
-			results = append(results, rowMap)
+		columnTypes, err := columnTypeInfos(rows)
+		if err != nil {
+			return nil, err
 		}
 
-		// Check for errors encountered during iteration
-		if err = rows.Err(); err != nil {
-			return nil, fmt.Errorf("error iterating rows: %w", err)
+		var results []map[string]any
+		err = scanRows(rows, columns, func(row map[string]any) error {
+			results = append(results, row)
+			return nil
+		})
+		if err != nil {
+			log.Printf("Error reading rows for query [%s]: %v", query, err)
+			return nil, err
 		}
 
 		// Success, return rows and columns
-		return &SQLResult{Columns: columns, Rows: results}, nil
+		result := &SQLResult{Columns: columns, ColumnTypes: columnTypes, Rows: results, DurationMs: time.Since(start).Milliseconds()}
+		if len(columns) == 0 {
+			// A statement without a result set (e.g. INSERT) run through the query path
+			rows.Close()
+			result.Warnings = s.collectWarnings(ctx, conn, details)
+		}
+		return result, nil
 	}
 
 	// If db.Query failed, try db.Exec (INSERT, UPDATE, DELETE, etc.)
-	result, execErr := db.ExecContext(ctx, query)
+	start = time.Now()
+	result, execErr := conn.ExecContext(ctx, query, args...)
+	duration := time.Since(start)
 	if execErr != nil {
 		// If both Query and Exec failed, return a combined or more specific error.
 		// The initial queryErr might be more indicative (e.g., syntax error)
@@ -209,15 +568,49 @@ func (s *DatabaseService) ExecuteSQL(ctx context.Context, details ConnectionDeta
 		RowsAffected: rowsAffectedPtr,
 		LastInsertId: lastInsertIdPtr,
 		Message:      "Command executed successfully.", // Provide a generic success message for Exec results
+		DurationMs:   duration.Milliseconds(),
+		Warnings:     s.collectWarnings(ctx, conn, details),
 	}, nil
 }
 
+// collectWarnings returns the warnings of the previous statement on conn, formatted like the mysql client
+// ("Warning 1264: Out of range value ..."), if the connection has ShowWarnings enabled.
+func (s *DatabaseService) collectWarnings(ctx context.Context, conn sqlRunner, details ConnectionDetails) []string {
+	if !details.ShowWarnings {
+		return nil
+	}
+
+	rows, err := conn.QueryContext(ctx, "SHOW WARNINGS")
+	if err != nil {
+		LogWarning("Failed to read warnings: %v", err)
+		return nil
+	}
+	defer rows.Close()
+
+	var warnings []string
+	for rows.Next() {
+		var level, message string
+		var code int64
+		if err := rows.Scan(&level, &code, &message); err != nil {
+			LogWarning("Failed to scan warning: %v", err)
+			return warnings
+		}
+		warnings = append(warnings, fmt.Sprintf("%s %d: %s", level, code, message))
+	}
+	return warnings
+}
+
 // --- Database Schema/Data Inspection Methods ---
 
 // TableColumn represents metadata for a table column.
 type TableColumn struct {
-	Name string `json:"name"`
-	Type string `json:"type"`
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	Ordinal   int    `json:"ordinal"` // 1-based position in the table definition
+	Charset   string `json:"charset,omitempty"`
+	Collation string `json:"collation,omitempty"`
+	Comment   string `json:"comment,omitempty"`
+	Key       string `json:"key,omitempty"` // COLUMN_KEY: PRI, UNI, MUL or empty
 }
 
 // ColumnSchema holds detailed metadata for a table column based on information_schema.
@@ -243,6 +636,8 @@ type TableDataResponse struct {
 	Columns   []TableColumn    `json:"columns"`
 	Rows      []map[string]any `json:"rows"`
 	TotalRows *int64           `json:"totalRows,omitempty"`
+	// NullDisplay is the configured text to render NULL cells with; the rows themselves keep nil
+	NullDisplay string `json:"nullDisplay"`
 }
 
 // ListDatabases retrieves a list of database/schema names accessible by the connection.
@@ -351,136 +746,28 @@ func (s *DatabaseService) GetTableData(ctx context.Context, details ConnectionDe
 		return nil, fmt.Errorf("table name is required")
 	}
 
-	// 1. Get column information using DESCRIBE.
-	descQuery := fmt.Sprintf("DESCRIBE `%s`.`%s`;", targetDB, tableName)
-	descSQLResult, err := s.ExecuteSQL(ctx, details, descQuery)
+	// 1. Get column information from information_schema.
+	columns, err := s.getTableColumns(ctx, details, targetDB, tableName)
 	if err != nil {
-		return nil, fmt.Errorf("failed to describe table '%s.%s': %w", targetDB, tableName, err)
+		return nil, err
 	}
-	if descSQLResult == nil || descSQLResult.Rows == nil {
-		// Check if table exists before failing completely
+	if len(columns) == 0 {
+		// information_schema returns no rows for a missing table rather than an error
 		exists, existsErr := s.checkTableExists(ctx, details, targetDB, tableName)
 		if existsErr != nil {
-			log.Printf("Warning: Failed to verify existence of table %s.%s after DESCRIBE failed: %v", targetDB, tableName, existsErr)
+			log.Printf("Warning: Failed to verify existence of table %s.%s: %v", targetDB, tableName, existsErr)
 		}
 		if !exists {
 			return nil, fmt.Errorf("table '%s.%s' not found", targetDB, tableName)
 		}
-		return nil, fmt.Errorf("unexpected result format when describing table '%s.%s' (expected rows)", targetDB, tableName)
-	}
-
-	descRows := descSQLResult.Rows // Extract rows from SQLResult
-	var columns []TableColumn
-	for _, row := range descRows {
-		colName, _ := row["Field"].(string)
-		colType, _ := row["Type"].(string)
-		if colName != "" {
-			columns = append(columns, TableColumn{Name: colName, Type: colType})
-		}
-	}
-	if len(columns) == 0 {
-		log.Printf("Warning: No columns found for table %s.%s after DESCRIBE query.", targetDB, tableName)
+		log.Printf("Warning: No columns found for table %s.%s in information_schema.", targetDB, tableName)
 		return &TableDataResponse{Columns: []TableColumn{}, Rows: []map[string]any{}}, nil // Return empty response
 	}
 
 	// 2. Build the WHERE clause from filterParams.
-	whereClause := ""
-	if filterParams != nil {
-		filters, filtersExist := (*filterParams)["filters"]
-		if filtersExist {
-			if filtersArr, ok := filters.([]interface{}); ok && len(filtersArr) > 0 {
-				conditions := []string{}
-				for _, filter := range filtersArr {
-					if filterMap, ok := filter.(map[string]interface{}); ok {
-						columnId, hasColumnId := filterMap["columnId"].(string)
-						operator, hasOperator := filterMap["operator"].(string)
-						filterType, hasType := filterMap["type"].(string)
-						values, hasValues := filterMap["values"].([]interface{})
-
-						if hasColumnId && hasOperator && hasType && hasValues && len(values) > 0 {
-							condition := ""
-							// WARNING: Parameterize these values for security!
-							// Simplified filter mapping - Needs proper escaping/parameterization.
-							switch filterType {
-							case "text":
-								if operator == "contains" {
-									condition = fmt.Sprintf("`%s` LIKE '%%%v%%'", columnId, values[0])
-								} else if operator == "does not contain" {
-									condition = fmt.Sprintf("`%s` NOT LIKE '%%%v%%'", columnId, values[0])
-								}
-							case "number":
-								switch operator {
-								case "is":
-									condition = fmt.Sprintf("`%s` = %v", columnId, values[0])
-								case "is not":
-									condition = fmt.Sprintf("`%s` != %v", columnId, values[0])
-								case "is greater than":
-									condition = fmt.Sprintf("`%s` > %v", columnId, values[0])
-								case "is greater than or equal to":
-									condition = fmt.Sprintf("`%s` >= %v", columnId, values[0])
-								case "is less than":
-									condition = fmt.Sprintf("`%s` < %v", columnId, values[0])
-								case "is less than or equal to":
-									condition = fmt.Sprintf("`%s` <= %v", columnId, values[0])
-								case "is between":
-									if len(values) >= 2 {
-										condition = fmt.Sprintf("`%s` BETWEEN %v AND %v", columnId, values[0], values[1])
-									}
-								case "is not between":
-									if len(values) >= 2 {
-										condition = fmt.Sprintf("`%s` NOT BETWEEN %v AND %v", columnId, values[0], values[1])
-									}
-								}
-							case "date":
-								switch operator {
-								case "is":
-									condition = fmt.Sprintf("DATE(`%s`) = DATE('%v')", columnId, values[0])
-								case "is not":
-									condition = fmt.Sprintf("DATE(`%s`) != DATE('%v')", columnId, values[0])
-								case "is between":
-									if len(values) >= 2 {
-										condition = fmt.Sprintf("DATE(`%s`) BETWEEN DATE('%v') AND DATE('%v')", columnId, values[0], values[1])
-									}
-								case "is not between":
-									if len(values) >= 2 {
-										condition = fmt.Sprintf("DATE(`%s`) NOT BETWEEN DATE('%v') AND DATE('%v')", columnId, values[0], values[1])
-									}
-								}
-							case "option", "multiOption":
-								var valueStrings []string
-								if multiValues, ok := values[0].([]interface{}); ok {
-									for _, v := range multiValues {
-										if strVal, ok := v.(string); ok {
-											valueStrings = append(valueStrings, fmt.Sprintf("'%s'", strVal))
-										}
-									}
-								} else if strVal, ok := values[0].(string); ok {
-									valueStrings = append(valueStrings, fmt.Sprintf("'%s'", strVal))
-								}
-
-								if len(valueStrings) > 0 {
-									valuesStr := strings.Join(valueStrings, ", ")
-									switch operator {
-									case "is", "is any of", "include", "include any of":
-										condition = fmt.Sprintf("`%s` IN (%s)", columnId, valuesStr)
-									case "is not", "is none of", "exclude", "exclude if any of":
-										condition = fmt.Sprintf("`%s` NOT IN (%s)", columnId, valuesStr)
-									}
-								}
-							}
-
-							if condition != "" {
-								conditions = append(conditions, condition)
-							}
-						}
-					}
-				}
-
-				if len(conditions) > 0 {
-					whereClause = " WHERE " + strings.Join(conditions, " AND ")
-				}
-			}
-		}
+	whereClause, whereArgs, err := buildFilterWhereClause(filterParams)
+	if err != nil {
+		return nil, err
 	}
 
 	// 3. Construct the SELECT query for data rows.
@@ -488,7 +775,10 @@ func (s *DatabaseService) GetTableData(ctx context.Context, details ConnectionDe
 	dataQuery := fmt.Sprintf("SELECT %s FROM `%s`.`%s`%s", selectCols, targetDB, tableName, whereClause)
 
 	if limit <= 0 {
-		limit = 100 // Default limit
+		limit = DefaultPageSize
+	}
+	if limit > MaxPageSize {
+		limit = MaxPageSize
 	}
 	dataQuery += fmt.Sprintf(" LIMIT %d", limit)
 	if offset > 0 {
@@ -497,7 +787,7 @@ func (s *DatabaseService) GetTableData(ctx context.Context, details ConnectionDe
 	dataQuery += ";"
 
 	// 4. Execute the data query.
-	dataSQLResult, err := s.ExecuteSQL(ctx, details, dataQuery)
+	dataSQLResult, err := s.ExecuteSQL(ctx, details, dataQuery, whereArgs...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch data for table '%s.%s': %w", targetDB, tableName, err)
 	}
@@ -514,7 +804,7 @@ func (s *DatabaseService) GetTableData(ctx context.Context, details ConnectionDe
 	// 5. Get Total Row Count (with the same filters).
 	var totalRows *int64
 	countQuery := fmt.Sprintf("SELECT COUNT(*) as total FROM `%s`.`%s`%s;", targetDB, tableName, whereClause)
-	countSQLResult, countErr := s.ExecuteSQL(ctx, details, countQuery) // Use ExecuteSQL here too
+	countSQLResult, countErr := s.ExecuteSQL(ctx, details, countQuery, whereArgs...) // Use ExecuteSQL here too
 	if countErr == nil && countSQLResult != nil && countSQLResult.Rows != nil && len(countSQLResult.Rows) > 0 {
 		countRows := countSQLResult.Rows // Extract rows
 		if totalValRaw, ok := countRows[0]["total"]; ok {
@@ -547,6 +837,49 @@ func (s *DatabaseService) GetTableData(ctx context.Context, details ConnectionDe
 	return resp, nil
 }
 
+// getTableColumns lists a table's columns from information_schema.COLUMNS in definition order.
+func (s *DatabaseService) getTableColumns(ctx context.Context, details ConnectionDetails, dbName string, tableName string) ([]TableColumn, error) {
+	query := `
+		SELECT
+			COLUMN_NAME,
+			COLUMN_TYPE,
+			ORDINAL_POSITION,
+			CHARACTER_SET_NAME,
+			COLLATION_NAME,
+			COLUMN_COMMENT,
+			COLUMN_KEY
+		FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?
+		ORDER BY ORDINAL_POSITION;`
+
+	db, err := s.getDB(details)
+	if err != nil {
+		return nil, fmt.Errorf("connection setup failed for table columns: %w", err)
+	}
+
+	rows, err := db.QueryContext(ctx, query, dbName, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query columns for '%s.%s': %w", dbName, tableName, err)
+	}
+	defer rows.Close()
+
+	columns := make([]TableColumn, 0)
+	for rows.Next() {
+		var col TableColumn
+		var charset, collation sql.NullString
+		if err := rows.Scan(&col.Name, &col.Type, &col.Ordinal, &charset, &collation, &col.Comment, &col.Key); err != nil {
+			return nil, fmt.Errorf("failed to scan column for '%s.%s': %w", dbName, tableName, err)
+		}
+		col.Charset = charset.String
+		col.Collation = collation.String
+		columns = append(columns, col)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating columns for '%s.%s': %w", dbName, tableName, err)
+	}
+	return columns, nil
+}
+
 // GetTableSchema retrieves the detailed schema/structure for a specific table using information_schema.
 // This needs direct *sql.DB access for Scan handling with nulls, so it doesn't use ExecuteSQL.
 func (s *DatabaseService) GetTableSchema(ctx context.Context, details ConnectionDetails, dbName string, tableName string) (*TableSchema, error) {
@@ -577,11 +910,10 @@ func (s *DatabaseService) GetTableSchema(ctx context.Context, details Connection
 		ORDER BY ORDINAL_POSITION;`
 
 	// Need to use the raw *sql.DB connection here to handle potential nulls correctly with Scan
-	db, err := getDBConnection(details)
+	db, err := s.getDB(details)
 	if err != nil {
 		return nil, fmt.Errorf("connection setup failed for GetTableSchema: %w", err)
 	}
-	defer db.Close()
 
 	rows, err := db.QueryContext(ctx, query, targetDB, tableName)
 	if err != nil {
@@ -637,11 +969,10 @@ func (s *DatabaseService) GetTableSchema(ctx context.Context, details Connection
 
 // Helper function to check if a table exists (used in GetTableData error handling)
 func (s *DatabaseService) checkTableExists(ctx context.Context, details ConnectionDetails, dbName string, tableName string) (bool, error) {
-	db, err := getDBConnection(details)
+	db, err := s.getDB(details)
 	if err != nil {
 		return false, fmt.Errorf("connection setup failed for table existence check: %w", err)
 	}
-	defer db.Close()
 
 	query := "SELECT 1 FROM information_schema.TABLES WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? LIMIT 1;"
 	var exists int
@@ -654,3 +985,244 @@ func (s *DatabaseService) checkTableExists(ctx context.Context, details Connecti
 	}
 	return exists == 1, nil
 }
+
+var resourceGroupNamePattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// ListResourceGroups returns the names of the server's resource groups.
+// supported is false when the server has no resource group support (e.g. MySQL or older TiDB).
+func (s *DatabaseService) ListResourceGroups(ctx context.Context, details ConnectionDetails) (groups []string, supported bool, err error) {
+	// Query without the configured group, otherwise an invalid group would fail the connection itself
+	details.ResourceGroup = ""
+	db, err := getDBConnection(details)
+	if err != nil {
+		return nil, false, fmt.Errorf("connection setup failed: %w", err)
+	}
+	defer db.Close()
+
+	rows, err := db.QueryContext(ctx, "SELECT NAME FROM information_schema.RESOURCE_GROUPS ORDER BY NAME")
+	if err != nil {
+		if isMissingTableError(err) {
+			return []string{}, false, nil // Resource groups unsupported
+		}
+		return nil, false, fmt.Errorf("failed to list resource groups: %w", err)
+	}
+	defer rows.Close()
+
+	groups = make([]string, 0)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, false, fmt.Errorf("failed to scan resource group: %w", err)
+		}
+		groups = append(groups, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, fmt.Errorf("error iterating resource groups: %w", err)
+	}
+	return groups, true, nil
+}
+
+// ValidateResourceGroup checks that the connection's resource group is well-formed and exists on the server.
+func (s *DatabaseService) ValidateResourceGroup(ctx context.Context, details ConnectionDetails) error {
+	if details.ResourceGroup == "" {
+		return nil
+	}
+	if !resourceGroupNamePattern.MatchString(details.ResourceGroup) {
+		return fmt.Errorf("invalid resource group name '%s'", details.ResourceGroup)
+	}
+
+	groups, supported, err := s.ListResourceGroups(ctx, details)
+	if err != nil {
+		return err
+	}
+	if !supported {
+		return fmt.Errorf("server does not support resource groups, clear the resource group setting to connect")
+	}
+	for _, group := range groups {
+		if strings.EqualFold(group, details.ResourceGroup) {
+			return nil
+		}
+	}
+	return fmt.Errorf("resource group '%s' does not exist", details.ResourceGroup)
+}
+
+// isMissingTableError reports whether err means a table doesn't exist, e.g. an information_schema
+// table only provided by newer TiDB versions.
+func isMissingTableError(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && (mysqlErr.Number == 1146 || mysqlErr.Number == 1109)
+}
+
+// quoteIdentifier wraps a schema object name in backticks, escaping embedded backticks.
+func quoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// getPrimaryKeyColumns returns the primary key column names of a table in key order.
+func (s *DatabaseService) getPrimaryKeyColumns(ctx context.Context, details ConnectionDetails, dbName string, tableName string) ([]string, error) {
+	query := `
+		SELECT COLUMN_NAME
+		FROM information_schema.KEY_COLUMN_USAGE
+		WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? AND CONSTRAINT_NAME = 'PRIMARY'
+		ORDER BY ORDINAL_POSITION`
+	result, err := s.ExecuteSQL(ctx, details, query, dbName, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get primary key for '%s.%s': %w", dbName, tableName, err)
+	}
+
+	var pkColumns []string
+	for _, row := range result.Rows {
+		if name, ok := row["COLUMN_NAME"].(string); ok {
+			pkColumns = append(pkColumns, name)
+		}
+	}
+	return pkColumns, nil
+}
+
+// MaxRowContextWindow caps how many neighbors can be fetched on each side of a row.
+const MaxRowContextWindow = 500
+
+// RowContextResponse holds a row and its neighbors ordered by primary key.
+type RowContextResponse struct {
+	Columns     []string         `json:"columns"`
+	Rows        []map[string]any `json:"rows"`
+	PrimaryKey  string           `json:"primaryKey"`
+	TargetIndex int              `json:"targetIndex"` // Index of the requested row in Rows, -1 if it doesn't exist
+}
+
+// GetRowsAroundPK fetches up to `before` rows with a smaller primary key and `after` rows with a larger one,
+// plus the row itself, ordered by primary key. The key is the table's row key (see ResolveRowKey), which
+// must be a single column.
+func (s *DatabaseService) GetRowsAroundPK(ctx context.Context, details ConnectionDetails, dbName string, tableName string, pkValue any, before int, after int) (*RowContextResponse, error) {
+	targetDB := dbName
+	if targetDB == "" {
+		targetDB = details.DBName
+	}
+	if targetDB == "" {
+		return nil, fmt.Errorf("database name is required either explicitly or in connection details")
+	}
+	if tableName == "" {
+		return nil, fmt.Errorf("table name is required")
+	}
+	if pkValue == nil {
+		return nil, fmt.Errorf("primary key value is required")
+	}
+	if before < 0 || after < 0 || before > MaxRowContextWindow || after > MaxRowContextWindow {
+		return nil, fmt.Errorf("before and after must be between 0 and %d", MaxRowContextWindow)
+	}
+
+	rowKey, err := s.ResolveRowKey(ctx, details, targetDB, tableName)
+	if err != nil {
+		return nil, err
+	}
+	if len(rowKey.Columns) != 1 {
+		return nil, fmt.Errorf("table '%s.%s' must have a single-column row key (found %d columns)", targetDB, tableName, len(rowKey.Columns))
+	}
+	pkColumns := rowKey.Columns
+	pk := quoteIdentifier(pkColumns[0])
+	table := quoteIdentifier(targetDB) + "." + quoteIdentifier(tableName)
+	selectList := rowKey.selectList()
+
+	query := fmt.Sprintf(
+		"(SELECT %s FROM %s WHERE %s < ? ORDER BY %s DESC LIMIT %d) UNION ALL (SELECT %s FROM %s WHERE %s >= ? ORDER BY %s ASC LIMIT %d) ORDER BY %s ASC",
+		selectList, table, pk, pk, before, selectList, table, pk, pk, after+1, pk)
+	result, err := s.ExecuteSQL(ctx, details, query, pkValue, pkValue)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch rows around primary key in '%s.%s': %w", targetDB, tableName, err)
+	}
+
+	resp := &RowContextResponse{
+		Columns:     result.Columns,
+		Rows:        result.Rows,
+		PrimaryKey:  pkColumns[0],
+		TargetIndex: -1,
+	}
+	if resp.Rows == nil {
+		resp.Rows = []map[string]any{}
+	}
+	numeric := false
+	for _, columnType := range result.ColumnTypes {
+		if columnType.Name == pkColumns[0] {
+			numeric = isNumericType(columnType.DatabaseTypeName)
+		}
+	}
+	for i, row := range resp.Rows {
+		if sameKeyValue(pkValue, row[pkColumns[0]], numeric) {
+			resp.TargetIndex = i
+			break
+		}
+	}
+	return resp, nil
+}
+
+// isNumericType reports whether a database type name, as in ColumnTypeInfo, is a number type.
+func isNumericType(databaseType string) bool {
+	switch strings.TrimPrefix(databaseType, "UNSIGNED ") {
+	case "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "BIGINT", "YEAR", "DECIMAL", "FLOAT", "DOUBLE":
+		return true
+	}
+	return false
+}
+
+// sameKeyValue reports whether a key value given by the frontend and one read from a row are the same.
+// Numbers compare by value, so 1e+06 decoded from JSON matches BIGINT 1000000 and 12.5 matches DECIMAL
+// "12.50". Strings only compare by value when numeric says the key column holds numbers.
+func sameKeyValue(a, b any, numeric bool) bool {
+	if numeric || isNumber(a) || isNumber(b) {
+		if x, ok := keyNumber(a); ok {
+			if y, ok := keyNumber(b); ok {
+				return x.Cmp(y) == 0
+			}
+		}
+	}
+	return keyText(a) == keyText(b)
+}
+
+func isNumber(v any) bool {
+	switch v.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, json.Number:
+		return true
+	}
+	return false
+}
+
+// keyNumber returns the exact value of a number, or of a string or bytes spelling one.
+func keyNumber(v any) (*big.Rat, bool) {
+	r := new(big.Rat)
+	switch v := v.(type) {
+	case int:
+		return r.SetInt64(int64(v)), true
+	case int8:
+		return r.SetInt64(int64(v)), true
+	case int16:
+		return r.SetInt64(int64(v)), true
+	case int32:
+		return r.SetInt64(int64(v)), true
+	case int64:
+		return r.SetInt64(v), true
+	case uint:
+		return r.SetUint64(uint64(v)), true
+	case uint8:
+		return r.SetUint64(uint64(v)), true
+	case uint16:
+		return r.SetUint64(uint64(v)), true
+	case uint32:
+		return r.SetUint64(uint64(v)), true
+	case uint64:
+		return r.SetUint64(v), true
+	case float32:
+		return keyNumber(float64(v))
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, false
+		}
+		return r.SetFloat64(v), true
+	case json.Number:
+		return keyNumber(string(v))
+	case []byte:
+		return keyNumber(string(v))
+	case string:
+		return r.SetString(strings.TrimSpace(v))
+	}
+	return nil, false
+}
@@ -0,0 +1,286 @@
+package services
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	mysql "github.com/go-sql-driver/mysql"
+)
+
+func TestTLSConfigNamePerConnection(t *testing.T) {
+	base := ConnectionDetails{ID: "a1", Host: "db.example.com", Port: "4000", UseTLS: true}
+
+	same := base
+	same.Password = "other" // Not part of the TLS config
+	if tlsConfigName(base) != tlsConfigName(same) {
+		t.Error("identical TLS details got different config names")
+	}
+
+	otherID := base
+	otherID.ID = "b2"
+	if tlsConfigName(base) == tlsConfigName(otherID) {
+		t.Error("different connections share a TLS config name")
+	}
+
+	otherHost := base
+	otherHost.Host = "replica.example.com"
+	if tlsConfigName(base) == tlsConfigName(otherHost) {
+		t.Error("different hosts share a TLS config name")
+	}
+}
+
+func TestTLSConfigNameTracksTLSOptions(t *testing.T) {
+	base := ConnectionDetails{
+		ID: "a1", Host: "db.example.com", UseTLS: true,
+		TLSCAFile: "/etc/tidb/ca.pem", TLSCertFile: "/etc/tidb/client.pem", TLSKeyFile: "/etc/tidb/client.key",
+	}
+	if tlsConfigName(base) != tlsConfigName(base) {
+		t.Fatal("identical details got different config names")
+	}
+
+	changes := map[string]func(d *ConnectionDetails){
+		"CA file":     func(d *ConnectionDetails) { d.TLSCAFile = "/etc/tidb/other-ca.pem" },
+		"cert file":   func(d *ConnectionDetails) { d.TLSCertFile = "/etc/tidb/other.pem" },
+		"key file":    func(d *ConnectionDetails) { d.TLSKeyFile = "/etc/tidb/other.key" },
+		"skip verify": func(d *ConnectionDetails) { d.TLSSkipVerify = true },
+	}
+	for name, change := range changes {
+		changed := base
+		change(&changed)
+		if tlsConfigName(changed) == tlsConfigName(base) {
+			t.Errorf("changing the %s kept the TLS config name", name)
+		}
+	}
+}
+
+func TestBuildTLSConfig(t *testing.T) {
+	cfg, err := buildTLSConfig(ConnectionDetails{Host: "[::1]", TLSSkipVerify: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ServerName != "::1" || !cfg.InsecureSkipVerify {
+		t.Errorf("server name %q, skip verify %v, want ::1 and true", cfg.ServerName, cfg.InsecureSkipVerify)
+	}
+
+	if _, err := buildTLSConfig(ConnectionDetails{Host: "db", TLSCAFile: filepath.Join(t.TempDir(), "missing.pem")}); err == nil {
+		t.Error("missing CA file accepted")
+	}
+	notPEM := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(notPEM, []byte("not a certificate"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := buildTLSConfig(ConnectionDetails{Host: "db", TLSCAFile: notPEM}); err == nil {
+		t.Error("CA file without certificates accepted")
+	}
+	if _, err := buildTLSConfig(ConnectionDetails{Host: "db", TLSKeyFile: "/etc/tidb/client.key"}); err == nil {
+		t.Error("client key without certificate accepted")
+	}
+}
+
+func TestBuildDSNRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		details  ConnectionDetails
+		wantAddr string
+	}{
+		{
+			name:     "reserved characters in password",
+			details:  ConnectionDetails{Host: "db.local", Port: "4000", User: "root", Password: "p@ss:w/rd?x=1&y#", DBName: "shop"},
+			wantAddr: "db.local:4000",
+		},
+		{
+			name:     "reserved characters in user",
+			details:  ConnectionDetails{Host: "db.local", Port: "4000", User: "app@prefix", Password: "a/b", DBName: "shop"},
+			wantAddr: "db.local:4000",
+		},
+		{
+			name:     "unbracketed IPv6",
+			details:  ConnectionDetails{Host: "::1", Port: "4001", User: "root", Password: "x"},
+			wantAddr: "[::1]:4001",
+		},
+		{
+			name:     "bracketed IPv6",
+			details:  ConnectionDetails{Host: "[fe80::1]", Port: "4000", User: "root"},
+			wantAddr: "[fe80::1]:4000",
+		},
+		{
+			name:     "default port",
+			details:  ConnectionDetails{Host: "127.0.0.1", User: "root"},
+			wantAddr: "127.0.0.1:4000",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dsn, useTLS := buildDSN(tt.details)
+			if useTLS {
+				t.Error("TLS enabled for a plain connection")
+			}
+			cfg, err := mysql.ParseDSN(dsn)
+			if err != nil {
+				t.Fatalf("ParseDSN(%q): %v", dsn, err)
+			}
+			if cfg.User != tt.details.User || cfg.Passwd != tt.details.Password {
+				t.Errorf("credentials = %q/%q, want %q/%q", cfg.User, cfg.Passwd, tt.details.User, tt.details.Password)
+			}
+			if cfg.Net != "tcp" || cfg.Addr != tt.wantAddr {
+				t.Errorf("address = %s(%s), want tcp(%s)", cfg.Net, cfg.Addr, tt.wantAddr)
+			}
+			if cfg.DBName != tt.details.DBName {
+				t.Errorf("database = %q, want %q", cfg.DBName, tt.details.DBName)
+			}
+			if !cfg.ParseTime {
+				t.Error("parseTime is off")
+			}
+		})
+	}
+}
+
+func TestBuildDSNResourceGroup(t *testing.T) {
+	for _, group := range []string{"rg_batch", "odd`name"} {
+		dsn, _ := buildDSN(ConnectionDetails{Host: "127.0.0.1", User: "root", ResourceGroup: group})
+		cfg, err := mysql.ParseDSN(dsn)
+		if err != nil {
+			t.Fatalf("ParseDSN(%q): %v", dsn, err)
+		}
+		// The driver runs SET tidb_resource_group=<value> as given, so the value must be a quoted identifier
+		if got, want := cfg.Params["tidb_resource_group"], quoteIdentifier(group); got != want {
+			t.Errorf("tidb_resource_group = %s, want %s", got, want)
+		}
+	}
+}
+
+func TestBuildDSNTLS(t *testing.T) {
+	details := ConnectionDetails{ID: "a1", Host: "gateway01.us-west-2.prod.aws.tidbcloud.com", Port: "4000", User: "u.root", Password: "s3cr@t"}
+	dsn, useTLS := buildDSN(details)
+	if !useTLS {
+		t.Fatal("TLS not enabled for a TiDB Cloud host")
+	}
+	// The driver only parses TLS config names that are registered
+	if err := registerTLSConfig(details); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.TLSConfig != tlsConfigName(details) {
+		t.Errorf("tls = %q, want %q", cfg.TLSConfig, tlsConfigName(details))
+	}
+}
+
+func TestRunSQLCollectsWarnings(t *testing.T) {
+	db, fake := newFakeDB(t, func(_ context.Context, query string, _ []any) fakeResponse {
+		if query == "SHOW WARNINGS" {
+			return fakeResponse{
+				columns: []string{"Level", "Code", "Message"},
+				rows: [][]driver.Value{
+					{"Warning", int64(1265), "Data truncated for column 'name' at row 1"},
+					{"Note", int64(1051), "Unknown table 'shop.tmp'"},
+				},
+			}
+		}
+		return fakeResponse{affected: 1}
+	})
+	s := NewDatabaseService()
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	const insert = "INSERT INTO t (name) VALUES ('a very long name')"
+	result, err := s.runSQL(ctx, conn, ConnectionDetails{ShowWarnings: true}, insert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"Warning 1265: Data truncated for column 'name' at row 1",
+		"Note 1051: Unknown table 'shop.tmp'",
+	}
+	if !slices.Equal(result.Warnings, want) {
+		t.Errorf("warnings = %q, want %q", result.Warnings, want)
+	}
+	statements := fake.Statements()
+	if len(statements) != 2 || statements[1].query != "SHOW WARNINGS" || statements[0].conn != statements[1].conn {
+		t.Errorf("statements = %+v, want the insert then SHOW WARNINGS on the same connection", statements)
+	}
+
+	result, err = s.runSQL(ctx, conn, ConnectionDetails{}, insert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Warnings != nil {
+		t.Errorf("warnings = %q without ShowWarnings", result.Warnings)
+	}
+	if n := len(fake.Statements()); n != 3 {
+		t.Errorf("%d statements run, want SHOW WARNINGS skipped without ShowWarnings", n)
+	}
+}
+
+func TestSameKeyValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    any
+		numeric bool
+		want    bool
+	}{
+		{"JSON float and BIGINT", float64(1000000), int64(1000000), true, true},
+		{"JSON float and BIGINT differ", float64(1000000), int64(1000001), true, false},
+		{"large JSON float and UNSIGNED BIGINT", float64(1 << 53), uint64(1 << 53), true, true},
+		{"JSON float and DECIMAL", 12.5, "12.50", true, true},
+		{"string and BIGINT", "1000000", int64(1000000), true, true},
+		{"string and DECIMAL", "12.5", "12.50", true, true},
+		{"bytes and string", []byte("abc"), "abc", false, true},
+		{"strings of a text key compare as text", "007", "7", false, false},
+		{"text key", "a", "b", false, false},
+		{"number and text that isn't one", float64(1), "one", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sameKeyValue(tt.a, tt.b, tt.numeric); got != tt.want {
+				t.Errorf("sameKeyValue(%#v, %#v, %v) = %v, want %v", tt.a, tt.b, tt.numeric, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetRowsAroundPKLargeFloatKey(t *testing.T) {
+	keys := fakeTableKeys([]string{"id"}, nil, true)
+	db, _ := newFakeDB(t, func(ctx context.Context, query string, args []any) fakeResponse {
+		if !strings.Contains(query, "UNION ALL") {
+			return keys(ctx, query, args)
+		}
+		return fakeResponse{
+			columns: []string{"id", "name"},
+			types:   []string{"BIGINT", "VARCHAR"},
+			rows: [][]driver.Value{
+				{[]byte("999999"), []byte("before")},
+				{[]byte("1000000"), []byte("target")},
+				{[]byte("1000001"), []byte("after")},
+			},
+		}
+	})
+	details := ConnectionDetails{ID: "conn", Host: "127.0.0.1"}
+	s := NewDatabaseService()
+	useFakeDB(s, details, db)
+
+	// A key sent by the frontend arrives as a JSON number, which decodes to float64
+	var pkValue any
+	if err := json.Unmarshal([]byte("1000000"), &pkValue); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := s.GetRowsAroundPK(context.Background(), details, "shop", "orders", pkValue, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.TargetIndex != 1 || resp.Rows[1]["name"] != "target" {
+		t.Errorf("target index = %d in %v, want 1", resp.TargetIndex, resp.Rows)
+	}
+}
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+)
+
+// diagnosticLogTailBytes limits how much of the log file goes into a diagnostic bundle
+const diagnosticLogTailBytes = 1 << 20
+
+// DiagnosticInfo is the app-level information included in a diagnostic bundle
+type DiagnosticInfo struct {
+	AppName     string                          `json:"appName"`
+	Version     string                          `json:"version"`
+	Commit      string                          `json:"commit"`
+	GoVersion   string                          `json:"goVersion"`
+	OS          string                          `json:"os"`
+	Arch        string                          `json:"arch"`
+	GeneratedAt time.Time                       `json:"generatedAt"`
+	TestResults map[string]ConnectionTestRecord `json:"-"` // Keyed by connection ID
+}
+
+// ConnectionTestRecord is the outcome of the last connection test of a saved connection
+type ConnectionTestRecord struct {
+	Success  bool      `json:"success"`
+	Error    string    `json:"error,omitempty"`
+	TestedAt time.Time `json:"testedAt"`
+}
+
+// diagnosticConnection is a saved connection as listed in a diagnostic bundle, without secrets
+type diagnosticConnection struct {
+	ID            string                `json:"id"`
+	Name          string                `json:"name"`
+	Host          string                `json:"host"`
+	Port          string                `json:"port"`
+	DBName        string                `json:"dbName,omitempty"`
+	UseTLS        bool                  `json:"useTLS"`
+	HasPassword   bool                  `json:"hasPassword"`
+	LastUsed      string                `json:"lastUsed,omitempty"`
+	ResourceGroup string                `json:"resourceGroup,omitempty"`
+	LastTest      *ConnectionTestRecord `json:"lastTest,omitempty"`
+}
+
+// MetadataCacheStats summarizes the cached metadata of a connection
+type MetadataCacheStats struct {
+	ConnectionID  string    `json:"connectionId"`
+	Loaded        bool      `json:"loaded"` // Held in memory
+	FileSizeBytes int64     `json:"fileSizeBytes"`
+	LastExtracted time.Time `json:"lastExtracted,omitempty"`
+	Databases     int       `json:"databases"`
+	Tables        int       `json:"tables"`
+}
+
+// MetadataCacheStats returns cache statistics for every saved connection.
+func (s *MetadataService) MetadataCacheStats() ([]MetadataCacheStats, error) {
+	connections, err := s.configService.GetAllConnections()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list connections: %w", err)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stats := make([]MetadataCacheStats, 0, len(connections))
+	for connectionID := range connections {
+		entry := MetadataCacheStats{ConnectionID: connectionID}
+		if info, err := os.Stat(s.getMetadataFilePath(connectionID)); err == nil {
+			entry.FileSizeBytes = info.Size()
+		}
+		if metadata, ok := s.metadata[connectionID]; ok {
+			entry.Loaded = true
+			entry.LastExtracted = metadata.LastExtracted
+			entry.Databases = len(metadata.Databases)
+			for _, db := range metadata.Databases {
+				entry.Tables += len(db.Tables)
+			}
+		}
+		stats = append(stats, entry)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].ConnectionID < stats[j].ConnectionID })
+	return stats, nil
+}
+
+// WriteDiagnosticBundle writes a zip archive for support requests with app and system information, the
+// config and saved connections without secrets, metadata cache statistics and the tail of the log.
+// Everything is passed through ScrubSecrets with the saved passwords and API keys as known secrets.
+func (s *MetadataService) WriteDiagnosticBundle(writer io.Writer, info DiagnosticInfo) error {
+	configData, err := s.configService.exportConfigData(false)
+	if err != nil {
+		return err
+	}
+	secrets := s.configService.secrets()
+
+	connections, err := s.configService.GetAllConnections()
+	if err != nil {
+		return fmt.Errorf("failed to list connections: %w", err)
+	}
+	connectionList := make([]diagnosticConnection, 0, len(connections))
+	for id, details := range connections {
+		entry := diagnosticConnection{
+			ID:            id,
+			Name:          details.Name,
+			Host:          details.Host,
+			Port:          details.Port,
+			DBName:        details.DBName,
+			UseTLS:        details.UseTLS,
+			HasPassword:   details.Password != "",
+			LastUsed:      details.LastUsed,
+			ResourceGroup: details.ResourceGroup,
+		}
+		if record, ok := info.TestResults[id]; ok {
+			entry.LastTest = &record
+		}
+		connectionList = append(connectionList, entry)
+	}
+	sort.Slice(connectionList, func(i, j int) bool { return connectionList[i].Name < connectionList[j].Name })
+
+	cacheStats, err := s.MetadataCacheStats()
+	if err != nil {
+		return err
+	}
+
+	logTail, err := readLogTail(diagnosticLogTailBytes)
+	if err != nil {
+		LogWarning("Diagnostic bundle will not include logs: %v", err)
+		logTail = []byte(fmt.Sprintf("log unavailable: %v\n", err))
+	}
+
+	files := []struct {
+		name string
+		data any
+	}{
+		{"app.json", info},
+		{"connections.json", connectionList},
+		{"metadata-cache.json", cacheStats},
+	}
+
+	zw := zip.NewWriter(writer)
+	write := func(name string, data []byte) error {
+		fw, err := zw.Create(name)
+		if err != nil {
+			return fmt.Errorf("failed to add %s to bundle: %w", name, err)
+		}
+		if _, err := fw.Write([]byte(ScrubSecrets(string(data), secrets...))); err != nil {
+			return fmt.Errorf("failed to write %s to bundle: %w", name, err)
+		}
+		return nil
+	}
+
+	for _, f := range files {
+		data, err := json.MarshalIndent(f.data, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s: %w", f.name, err)
+		}
+		if err := write(f.name, data); err != nil {
+			return err
+		}
+	}
+	if err := write(ConfigFileName, configData); err != nil {
+		return err
+	}
+	if err := write(LogFileName, logTail); err != nil {
+		return err
+	}
+
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to finalize bundle: %w", err)
+	}
+	LogInfo("Generated diagnostic bundle")
+	return nil
+}
+
+// readLogTail returns up to maxBytes from the end of the log file, starting at a line boundary.
+func readLogTail(maxBytes int64) ([]byte, error) {
+	path, err := LogFilePath()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat log file: %w", err)
+	}
+	offset := max(info.Size()-maxBytes, 0)
+	data, err := io.ReadAll(io.NewSectionReader(f, offset, info.Size()-offset))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read log file: %w", err)
+	}
+	if offset > 0 {
+		for i, b := range data {
+			if b == '\n' {
+				return data[i+1:], nil
+			}
+		}
+	}
+	return data, nil
+}
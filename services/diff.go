@@ -0,0 +1,355 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// csvNullValue is the conventional MySQL representation of NULL in CSV files
+const csvNullValue = `\N`
+
+// ValueChange holds the current and incoming value of a single cell
+type ValueChange struct {
+	Old *string `json:"old"` // nil for NULL
+	New *string `json:"new"` // nil for NULL
+}
+
+// RowChange describes a row that exists on both sides but has different values
+type RowChange struct {
+	Key     map[string]string      `json:"key"`
+	Changes map[string]ValueChange `json:"changes"` // Keyed by column name
+}
+
+// DataDiff is the dry-run result of comparing a table's rows with a CSV file
+type DataDiff struct {
+	KeyColumns     []string            `json:"keyColumns"`
+	CompareColumns []string            `json:"compareColumns"` // Columns present in both the CSV and the table
+	Inserts        []map[string]string `json:"inserts"`        // CSV rows missing from the table
+	Updates        []RowChange         `json:"updates"`
+	Deletes        []map[string]string `json:"deletes"` // Key values of table rows missing from the CSV
+	UnchangedCount int64               `json:"unchangedCount"`
+}
+
+// DiffTableWithCSV compares a table's rows against a CSV file (with a header row) keyed by keyColumns and
+// reports which rows would be inserted, updated or deleted, without changing anything. The CSV is indexed
+// in memory while the table side is streamed ordered by key. `\N` in the CSV stands for NULL.
+func (s *DatabaseService) DiffTableWithCSV(ctx context.Context, details ConnectionDetails, dbName string, tableName string, csvReader io.Reader, keyColumns []string) (*DataDiff, error) {
+	targetDB := dbName
+	if targetDB == "" {
+		targetDB = details.DBName
+	}
+	if targetDB == "" {
+		return nil, fmt.Errorf("database name is required either explicitly or in connection details")
+	}
+	if tableName == "" {
+		return nil, fmt.Errorf("table name is required")
+	}
+	if len(keyColumns) == 0 {
+		return nil, fmt.Errorf("at least one key column is required")
+	}
+
+	reader := csv.NewReader(csvReader)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	schema, err := s.GetTableSchema(ctx, details, targetDB, tableName)
+	if err != nil {
+		return nil, err
+	}
+	tableColumns := make(map[string]bool, len(schema.Columns))
+	for _, col := range schema.Columns {
+		tableColumns[col.ColumnName] = true
+	}
+
+	headerIndex := make(map[string]int, len(header))
+	diff := &DataDiff{
+		KeyColumns:     keyColumns,
+		CompareColumns: make([]string, 0, len(header)),
+		Inserts:        make([]map[string]string, 0),
+		Updates:        make([]RowChange, 0),
+		Deletes:        make([]map[string]string, 0),
+	}
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		if !tableColumns[name] {
+			LogInfo("CSV column '%s' does not exist in %s.%s, ignoring it", name, targetDB, tableName)
+			continue
+		}
+		headerIndex[name] = i
+		diff.CompareColumns = append(diff.CompareColumns, name)
+	}
+	for _, key := range keyColumns {
+		if _, ok := headerIndex[key]; !ok {
+			return nil, fmt.Errorf("key column '%s' must exist in both the CSV header and the table", key)
+		}
+	}
+
+	// Index the CSV rows by key, preserving file order for reporting inserts
+	csvRows := make(map[string][]string)
+	var csvOrder []string
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV line %d: %w", line, err)
+		}
+		key := csvRowKey(record, headerIndex, keyColumns)
+		if _, dup := csvRows[key]; dup {
+			return nil, fmt.Errorf("duplicate key on CSV line %d", line)
+		}
+		csvRows[key] = record
+		csvOrder = append(csvOrder, key)
+	}
+
+	// Stream the table ordered by key
+	quotedCols := make([]string, len(diff.CompareColumns))
+	for i, col := range diff.CompareColumns {
+		quotedCols[i] = quoteIdentifier(col)
+	}
+	quotedKeys := make([]string, len(keyColumns))
+	for i, key := range keyColumns {
+		quotedKeys[i] = quoteIdentifier(key)
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s.%s ORDER BY %s",
+		strings.Join(quotedCols, ", "), quoteIdentifier(targetDB), quoteIdentifier(tableName), strings.Join(quotedKeys, ", "))
+
+	db, err := s.getDB(details)
+	if err != nil {
+		return nil, fmt.Errorf("connection setup failed for DiffTableWithCSV: %w", err)
+	}
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read table '%s.%s': %w", targetDB, tableName, err)
+	}
+	defer rows.Close()
+
+	values := make([]sql.NullString, len(diff.CompareColumns))
+	scanArgs := make([]any, len(values))
+	for i := range values {
+		scanArgs[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(scanArgs...); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		dbRow := make(map[string]*string, len(values))
+		keyParts := make([]string, len(keyColumns))
+		for i, col := range diff.CompareColumns {
+			if values[i].Valid {
+				v := values[i].String
+				dbRow[col] = &v
+			} else {
+				dbRow[col] = nil
+			}
+		}
+		for i, key := range keyColumns {
+			keyParts[i] = nullableString(dbRow[key])
+		}
+		key := strings.Join(keyParts, "\x00")
+
+		record, inCSV := csvRows[key]
+		if !inCSV {
+			deleted := make(map[string]string, len(keyColumns))
+			for _, k := range keyColumns {
+				deleted[k] = nullableString(dbRow[k])
+			}
+			diff.Deletes = append(diff.Deletes, deleted)
+			continue
+		}
+		delete(csvRows, key)
+
+		changes := make(map[string]ValueChange)
+		for _, col := range diff.CompareColumns {
+			incoming := csvValue(record, headerIndex[col])
+			if !sameNullableString(dbRow[col], incoming) {
+				changes[col] = ValueChange{Old: dbRow[col], New: incoming}
+			}
+		}
+		if len(changes) == 0 {
+			diff.UnchangedCount++
+			continue
+		}
+		rowKey := make(map[string]string, len(keyColumns))
+		for _, k := range keyColumns {
+			rowKey[k] = nullableString(dbRow[k])
+		}
+		diff.Updates = append(diff.Updates, RowChange{Key: rowKey, Changes: changes})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating table rows: %w", err)
+	}
+
+	for _, key := range csvOrder {
+		record, remaining := csvRows[key]
+		if !remaining {
+			continue
+		}
+		inserted := make(map[string]string, len(diff.CompareColumns))
+		for _, col := range diff.CompareColumns {
+			inserted[col] = record[headerIndex[col]]
+		}
+		diff.Inserts = append(diff.Inserts, inserted)
+	}
+
+	LogInfo("CSV diff for %s.%s: %d inserts, %d updates, %d deletes, %d unchanged",
+		targetDB, tableName, len(diff.Inserts), len(diff.Updates), len(diff.Deletes), diff.UnchangedCount)
+	return diff, nil
+}
+
+func csvRowKey(record []string, headerIndex map[string]int, keyColumns []string) string {
+	parts := make([]string, len(keyColumns))
+	for i, key := range keyColumns {
+		parts[i] = nullableString(csvValue(record, headerIndex[key]))
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// csvValue returns the field at idx, or nil for NULL and missing fields.
+func csvValue(record []string, idx int) *string {
+	if idx >= len(record) || record[idx] == csvNullValue {
+		return nil
+	}
+	return &record[idx]
+}
+
+func nullableString(v *string) string {
+	if v == nil {
+		return csvNullValue
+	}
+	return *v
+}
+
+func sameNullableString(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// ResultRowMismatch is a position where two order-sensitive result sets differ
+type ResultRowMismatch struct {
+	Index int            `json:"index"`
+	Left  map[string]any `json:"left"`
+	Right map[string]any `json:"right"`
+}
+
+// ResultDiff is the comparison of two query results. Rows are compared on CommonColumns only.
+type ResultDiff struct {
+	Identical          bool                `json:"identical"`
+	CommonColumns      []string            `json:"commonColumns"`
+	LeftOnlyColumns    []string            `json:"leftOnlyColumns"`
+	RightOnlyColumns   []string            `json:"rightOnlyColumns"`
+	ColumnOrderChanged bool                `json:"columnOrderChanged"`
+	LeftRowCount       int                 `json:"leftRowCount"`
+	RightRowCount      int                 `json:"rightRowCount"`
+	LeftOnlyRows       []map[string]any    `json:"leftOnlyRows"`
+	RightOnlyRows      []map[string]any    `json:"rightOnlyRows"`
+	Mismatches         []ResultRowMismatch `json:"mismatches,omitempty"` // Only for order-sensitive comparisons
+}
+
+// DiffQueryResults compares two result sets. By default rows are matched regardless of order, with
+// duplicates counted; with orderSensitive, rows are compared position by position. NULL never equals
+// a non-NULL value, including the string "NULL".
+func DiffQueryResults(left, right *SQLResult, orderSensitive bool) (*ResultDiff, error) {
+	if left == nil || right == nil {
+		return nil, fmt.Errorf("both results are required")
+	}
+	if len(left.Columns) == 0 || len(right.Columns) == 0 {
+		return nil, fmt.Errorf("both results must contain rows and columns, not command results")
+	}
+
+	diff := &ResultDiff{
+		CommonColumns:    make([]string, 0),
+		LeftOnlyColumns:  make([]string, 0),
+		RightOnlyColumns: make([]string, 0),
+		LeftRowCount:     len(left.Rows),
+		RightRowCount:    len(right.Rows),
+		LeftOnlyRows:     make([]map[string]any, 0),
+		RightOnlyRows:    make([]map[string]any, 0),
+	}
+
+	rightColumns := make(map[string]bool, len(right.Columns))
+	for _, col := range right.Columns {
+		rightColumns[col] = true
+	}
+	leftColumns := make(map[string]bool, len(left.Columns))
+	for _, col := range left.Columns {
+		leftColumns[col] = true
+		if rightColumns[col] {
+			diff.CommonColumns = append(diff.CommonColumns, col)
+		} else {
+			diff.LeftOnlyColumns = append(diff.LeftOnlyColumns, col)
+		}
+	}
+	var rightCommon []string
+	for _, col := range right.Columns {
+		if leftColumns[col] {
+			rightCommon = append(rightCommon, col)
+		} else {
+			diff.RightOnlyColumns = append(diff.RightOnlyColumns, col)
+		}
+	}
+	diff.ColumnOrderChanged = strings.Join(diff.CommonColumns, "\x00") != strings.Join(rightCommon, "\x00")
+
+	if orderSensitive {
+		n := min(len(left.Rows), len(right.Rows))
+		for i := 0; i < n; i++ {
+			if resultRowKey(left.Rows[i], diff.CommonColumns) != resultRowKey(right.Rows[i], diff.CommonColumns) {
+				diff.Mismatches = append(diff.Mismatches, ResultRowMismatch{Index: i, Left: left.Rows[i], Right: right.Rows[i]})
+			}
+		}
+		diff.LeftOnlyRows = append(diff.LeftOnlyRows, left.Rows[n:]...)
+		diff.RightOnlyRows = append(diff.RightOnlyRows, right.Rows[n:]...)
+	} else {
+		// Match rows as multisets: each right row cancels out one identical left row
+		pending := make(map[string][]int)
+		for i, row := range left.Rows {
+			key := resultRowKey(row, diff.CommonColumns)
+			pending[key] = append(pending[key], i)
+		}
+		for _, row := range right.Rows {
+			key := resultRowKey(row, diff.CommonColumns)
+			if indexes := pending[key]; len(indexes) > 0 {
+				pending[key] = indexes[1:]
+				continue
+			}
+			diff.RightOnlyRows = append(diff.RightOnlyRows, row)
+		}
+		for i, row := range left.Rows {
+			key := resultRowKey(row, diff.CommonColumns)
+			if indexes := pending[key]; len(indexes) > 0 && indexes[0] == i {
+				pending[key] = indexes[1:]
+				diff.LeftOnlyRows = append(diff.LeftOnlyRows, row)
+			}
+		}
+	}
+
+	diff.Identical = len(diff.LeftOnlyColumns) == 0 && len(diff.RightOnlyColumns) == 0 &&
+		len(diff.LeftOnlyRows) == 0 && len(diff.RightOnlyRows) == 0 && len(diff.Mismatches) == 0
+	return diff, nil
+}
+
+// resultRowKey renders the values of the given columns into a comparable key, keeping NULL distinct.
+func resultRowKey(row map[string]any, columns []string) string {
+	var b strings.Builder
+	for _, col := range columns {
+		if row[col] == nil {
+			b.WriteString("\x01")
+		} else {
+			b.WriteString("\x02")
+			b.WriteString(valueString(row[col]))
+		}
+		b.WriteString("\x00")
+	}
+	return b.String()
+}
@@ -0,0 +1,255 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultDumpBatchSize is the number of rows per INSERT statement when SQLDumpOptions.BatchSize is 0
+const DefaultDumpBatchSize = 100
+
+// SQLDumpOptions controls the output of ExportTableSQL
+type SQLDumpOptions struct {
+	BatchSize  int  `json:"batchSize,omitempty"` // Rows per INSERT statement, defaults to DefaultDumpBatchSize
+	IncludeDDL bool `json:"includeDDL"`          // Prepend the SHOW CREATE TABLE statement
+	// Upsert appends ON DUPLICATE KEY UPDATE for every non-key column, so the dump can be re-run against a
+	// table that already has some of the rows. Requires a primary key or NOT NULL unique index.
+	Upsert bool `json:"upsert"`
+}
+
+// dumpValueKind decides how the values of a column are written as SQL literals
+type dumpValueKind int
+
+const (
+	dumpString dumpValueKind = iota
+	dumpNumber
+	dumpBinary
+)
+
+// dumpKind returns how values of a column with the given database type are written.
+func dumpKind(databaseType string) dumpValueKind {
+	switch strings.ToUpper(databaseType) {
+	case "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "BIGINT", "YEAR",
+		"UNSIGNED TINYINT", "UNSIGNED SMALLINT", "UNSIGNED MEDIUMINT", "UNSIGNED INT", "UNSIGNED BIGINT",
+		"DECIMAL", "FLOAT", "DOUBLE":
+		return dumpNumber
+	case "BINARY", "VARBINARY", "TINYBLOB", "BLOB", "MEDIUMBLOB", "LONGBLOB", "BIT", "GEOMETRY":
+		return dumpBinary
+	}
+	return dumpString
+}
+
+// dumpLiteral renders a scanned value as a SQL literal for a column of the given kind. Binary data
+// becomes a hex literal, so any byte sequence survives regardless of the connection charset.
+func dumpLiteral(v any, kind dumpValueKind) string {
+	switch val := v.(type) {
+	case nil:
+		return "NULL"
+	case int64:
+		return strconv.FormatInt(val, 10)
+	case uint64:
+		return strconv.FormatUint(val, 10)
+	case float32:
+		return strconv.FormatFloat(float64(val), 'g', -1, 32)
+	case float64:
+		return strconv.FormatFloat(val, 'g', -1, 64)
+	case time.Time:
+		return "'" + val.Format(exportTimeLayout) + "'"
+	case []byte:
+		switch kind {
+		case dumpBinary:
+			if len(val) == 0 {
+				return "''"
+			}
+			return "0x" + hex.EncodeToString(val)
+		case dumpNumber:
+			return string(val)
+		}
+		return sqlQuoteValue(string(val))
+	}
+	return sqlQuoteValue(v)
+}
+
+// upsertClause returns the ON DUPLICATE KEY UPDATE clause that overwrites every non-key column. A table
+// made up only of key columns gets a no-op assignment so duplicates are still ignored.
+func upsertClause(columns []string, keyColumns []string) string {
+	isKey := make(map[string]bool, len(keyColumns))
+	for _, col := range keyColumns {
+		isKey[strings.ToLower(col)] = true
+	}
+	assignments := make([]string, 0, len(columns))
+	for _, col := range columns {
+		if !isKey[strings.ToLower(col)] {
+			assignments = append(assignments, fmt.Sprintf("%s = VALUES(%s)", quoteIdentifier(col), quoteIdentifier(col)))
+		}
+	}
+	if len(assignments) == 0 {
+		col := quoteIdentifier(keyColumns[0])
+		assignments = append(assignments, col+" = "+col)
+	}
+	return "\nON DUPLICATE KEY UPDATE " + strings.Join(assignments, ", ")
+}
+
+// ExportTableSQL writes the rows of a table to writer as multi-row INSERT statements that can be run
+// against another server, optionally preceded by the table's CREATE TABLE statement. Rows are streamed.
+func (s *DatabaseService) ExportTableSQL(ctx context.Context, details ConnectionDetails, dbName string, tableName string, writer io.Writer, opts SQLDumpOptions) error {
+	targetDB := dbName
+	if targetDB == "" {
+		targetDB = details.DBName
+	}
+	if targetDB == "" {
+		return fmt.Errorf("database name is required either explicitly or in connection details")
+	}
+	if tableName == "" {
+		return fmt.Errorf("table name is required")
+	}
+	var keyColumns []string
+	if opts.Upsert {
+		rowKey, err := s.ResolveRowKey(ctx, details, targetDB, tableName)
+		if err != nil {
+			return err
+		}
+		if rowKey.Kind == RowKeyRowID {
+			return fmt.Errorf("table '%s.%s' needs a primary key or NOT NULL unique index for upserts", targetDB, tableName)
+		}
+		keyColumns = rowKey.Columns
+	}
+
+	db, err := s.getDB(details)
+	if err != nil {
+		return fmt.Errorf("connection setup failed for ExportTableSQL: %w", err)
+	}
+
+	out := bufio.NewWriter(writer)
+	if err := writeSQLDumpHeader(ctx, db, out, targetDB, tableName, opts.IncludeDDL); err != nil {
+		return err
+	}
+
+	rows, err := db.QueryContext(ctx, "SELECT * FROM "+quoteIdentifier(targetDB)+"."+quoteIdentifier(tableName))
+	if err != nil {
+		return fmt.Errorf("failed to read table '%s.%s': %w", targetDB, tableName, err)
+	}
+	defer rows.Close()
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return fmt.Errorf("failed to get column types: %w", err)
+	}
+	dump := newSQLRowWriter(out, tableName, columnTypes, keyColumns, opts)
+
+	values := make([]any, len(columnTypes))
+	scanArgs := make([]any, len(columnTypes))
+	for i := range values {
+		scanArgs[i] = &values[i]
+	}
+
+	var count int64
+	for rows.Next() {
+		if err := rows.Scan(scanArgs...); err != nil {
+			return fmt.Errorf("failed to scan row: %w", err)
+		}
+		dump.writeRow(values)
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating rows: %w", err)
+	}
+	if err := dump.flush(); err != nil {
+		return fmt.Errorf("failed to write SQL dump: %w", err)
+	}
+
+	LogInfo("Exported %d rows of %s.%s as SQL", count, targetDB, tableName)
+	return nil
+}
+
+// writeSQLDumpHeader writes the comment that starts a dump and, with includeDDL, the table's CREATE TABLE
+// statement.
+func writeSQLDumpHeader(ctx context.Context, db *sql.DB, out *bufio.Writer, dbName string, tableName string, includeDDL bool) error {
+	fmt.Fprintf(out, "-- Dump of %s.%s, generated %s\n\n", dbName, tableName, time.Now().Format(time.DateTime))
+	if includeDDL {
+		var name, ddl string
+		query := "SHOW CREATE TABLE " + quoteIdentifier(dbName) + "." + quoteIdentifier(tableName)
+		if err := db.QueryRowContext(ctx, query).Scan(&name, &ddl); err != nil {
+			return fmt.Errorf("failed to get CREATE TABLE for '%s.%s': %w", dbName, tableName, err)
+		}
+		fmt.Fprintf(out, "%s;\n\n", ddl)
+	}
+	return nil
+}
+
+// sqlRowWriter writes scanned rows as multi-row INSERT statements
+type sqlRowWriter struct {
+	out          *bufio.Writer
+	kinds        []dumpValueKind
+	insertPrefix string
+	statementEnd string
+	batchSize    int
+	inStatement  int // Rows written to the open statement
+	literals     []string
+}
+
+// newSQLRowWriter returns a writer of INSERT statements for rows with the given columns. keyColumns are
+// only used for upserts.
+func newSQLRowWriter(out *bufio.Writer, tableName string, columnTypes []*sql.ColumnType, keyColumns []string, opts SQLDumpOptions) *sqlRowWriter {
+	w := &sqlRowWriter{
+		out:       out,
+		kinds:     make([]dumpValueKind, len(columnTypes)),
+		batchSize: opts.BatchSize,
+		literals:  make([]string, len(columnTypes)),
+	}
+	if w.batchSize <= 0 {
+		w.batchSize = DefaultDumpBatchSize
+	}
+	columns := make([]string, len(columnTypes))
+	quotedColumns := make([]string, len(columnTypes))
+	for i, ct := range columnTypes {
+		w.kinds[i] = dumpKind(ct.DatabaseTypeName())
+		columns[i] = ct.Name()
+		quotedColumns[i] = quoteIdentifier(ct.Name())
+	}
+	w.insertPrefix = fmt.Sprintf("INSERT INTO %s (%s) VALUES\n", quoteIdentifier(tableName), strings.Join(quotedColumns, ", "))
+	w.statementEnd = ";\n"
+	if opts.Upsert {
+		w.statementEnd = upsertClause(columns, keyColumns) + ";\n"
+	}
+	return w
+}
+
+// writeRow adds a row to the open INSERT statement, starting a new one every batchSize rows.
+func (w *sqlRowWriter) writeRow(values []any) {
+	for i, v := range values {
+		w.literals[i] = dumpLiteral(v, w.kinds[i])
+	}
+	if w.inStatement == 0 {
+		w.out.WriteString(w.insertPrefix)
+	} else {
+		w.out.WriteString(",\n")
+	}
+	w.out.WriteString("(" + strings.Join(w.literals, ", ") + ")")
+	w.inStatement++
+	if w.inStatement == w.batchSize {
+		w.endStatement()
+	}
+}
+
+// endStatement terminates the open INSERT statement, if any.
+func (w *sqlRowWriter) endStatement() {
+	if w.inStatement > 0 {
+		w.out.WriteString(w.statementEnd)
+		w.inStatement = 0
+	}
+}
+
+// flush terminates the open statement and writes out everything buffered, so the output ends with a
+// complete statement.
+func (w *sqlRowWriter) flush() error {
+	w.endStatement()
+	return w.out.Flush()
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// parseEnumValues extracts the declared members of an ENUM or SET column type such as enum('a','b').
+// isSet reports whether the type is SET; ok is false for any other type.
+func parseEnumValues(columnType string) (values []string, isSet bool, ok bool) {
+	lower := strings.ToLower(strings.TrimSpace(columnType))
+	var body string
+	switch {
+	case strings.HasPrefix(lower, "enum("):
+		body = strings.TrimSpace(columnType)[len("enum("):]
+	case strings.HasPrefix(lower, "set("):
+		body = strings.TrimSpace(columnType)[len("set("):]
+		isSet = true
+	default:
+		return nil, false, false
+	}
+
+	values = make([]string, 0)
+	var current strings.Builder
+	inQuote := false
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		switch {
+		case inQuote && c == '\'' && i+1 < len(body) && body[i+1] == '\'':
+			current.WriteByte('\'')
+			i++
+		case inQuote && c == '\\' && i+1 < len(body):
+			current.WriteByte(body[i+1])
+			i++
+		case c == '\'':
+			if inQuote {
+				values = append(values, current.String())
+				current.Reset()
+			}
+			inQuote = !inQuote
+		case inQuote:
+			current.WriteByte(c)
+		case c == ')':
+			return values, isSet, true
+		}
+	}
+	return nil, false, false
+}
+
+// GetEnumValueUsage counts how many rows use each value of an ENUM or SET column. Every declared value is
+// included, with a count of 0 if unused. For SET columns each member is counted once per row containing it.
+// NULLs are not counted.
+func (s *DatabaseService) GetEnumValueUsage(ctx context.Context, details ConnectionDetails, dbName string, tableName string, column string) (map[string]int64, error) {
+	targetDB := dbName
+	if targetDB == "" {
+		targetDB = details.DBName
+	}
+	if targetDB == "" {
+		return nil, fmt.Errorf("database name is required either explicitly or in connection details")
+	}
+	if tableName == "" || column == "" {
+		return nil, fmt.Errorf("table name and column name are required")
+	}
+
+	typeResult, err := s.ExecuteSQL(ctx, details,
+		"SELECT COLUMN_TYPE FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? AND COLUMN_NAME = ?",
+		targetDB, tableName, column)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get type of column '%s': %w", column, err)
+	}
+	if len(typeResult.Rows) == 0 {
+		return nil, fmt.Errorf("column '%s' not found in table '%s.%s'", column, targetDB, tableName)
+	}
+	columnType := valueString(typeResult.Rows[0]["COLUMN_TYPE"])
+
+	declared, isSet, ok := parseEnumValues(columnType)
+	if !ok {
+		return nil, fmt.Errorf("column '%s' is not an ENUM or SET column (type: %s)", column, columnType)
+	}
+
+	usage := make(map[string]int64, len(declared))
+	for _, value := range declared {
+		usage[value] = 0
+	}
+
+	col := quoteIdentifier(column)
+	query := fmt.Sprintf("SELECT %s AS value, COUNT(*) AS total FROM %s.%s WHERE %s IS NOT NULL GROUP BY %s",
+		col, quoteIdentifier(targetDB), quoteIdentifier(tableName), col, col)
+	result, err := s.ExecuteSQL(ctx, details, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count values of column '%s': %w", column, err)
+	}
+
+	for _, row := range result.Rows {
+		value := valueString(row["value"])
+		count, ok := valueFloat(row["total"])
+		if !ok {
+			continue
+		}
+		if isSet && value != "" {
+			for _, member := range strings.Split(value, ",") {
+				usage[member] += int64(count)
+			}
+			continue
+		}
+		usage[value] += int64(count)
+	}
+
+	return usage, nil
+}
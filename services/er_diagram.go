@@ -0,0 +1,254 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ER diagram output formats
+const (
+	ERDiagramFormatMermaid = "mermaid" // Mermaid erDiagram definition
+	ERDiagramFormatDBML    = "dbml"    // DBML, as used by dbdiagram.io
+)
+
+// ExportERDiagram renders the tables and foreign keys of a database from cached metadata as an ER diagram
+// in the given format. Every foreign key is drawn as many-to-one from the referencing table.
+func (s *MetadataService) ExportERDiagram(connectionID, dbName, format string) (string, error) {
+	if format != ERDiagramFormatMermaid && format != ERDiagramFormatDBML {
+		return "", fmt.Errorf("unsupported ER diagram format: %s", format)
+	}
+
+	dbMeta, err := s.getCachedDatabase(connectionID, dbName)
+	if err != nil {
+		return "", err
+	}
+	if format == ERDiagramFormatDBML {
+		return renderDBML(dbMeta), nil
+	}
+	return renderMermaid(dbMeta), nil
+}
+
+func renderMermaid(dbMeta DatabaseMetadata) string {
+	var b strings.Builder
+	b.WriteString("erDiagram\n")
+	for _, table := range dbMeta.Tables {
+		fkColumns := make(map[string]bool)
+		for _, fk := range table.ForeignKeys {
+			for _, col := range fk.ColumnNames {
+				fkColumns[col] = true
+			}
+		}
+
+		if len(table.Columns) == 0 {
+			fmt.Fprintf(&b, "    %s\n", mermaidName(table.Name))
+			continue
+		}
+		fmt.Fprintf(&b, "    %s {\n", mermaidName(table.Name))
+		for _, col := range table.Columns {
+			var keys []string
+			if col.IsPrimaryKey {
+				keys = append(keys, "PK")
+			}
+			if fkColumns[col.Name] {
+				keys = append(keys, "FK")
+			}
+			line := mermaidType(col.DataType) + " " + mermaidName(col.Name)
+			if len(keys) > 0 {
+				line += " " + strings.Join(keys, ", ")
+			}
+			if col.DBComment != "" {
+				line += " " + mermaidQuote(col.DBComment)
+			}
+			fmt.Fprintf(&b, "        %s\n", line)
+		}
+		b.WriteString("    }\n")
+	}
+
+	// Relationships reference entities by name, so self references and tables outside this database just work
+	for _, table := range dbMeta.Tables {
+		for _, fk := range table.ForeignKeys {
+			label := fk.Name
+			if label == "" {
+				label = strings.Join(fk.ColumnNames, ", ")
+			}
+			fmt.Fprintf(&b, "    %s }o--|| %s : %s\n", mermaidName(table.Name), mermaidName(fk.RefTableName), mermaidQuote(label))
+		}
+	}
+	return b.String()
+}
+
+// mermaidName replaces characters Mermaid doesn't accept in entity and attribute names with underscores.
+func mermaidName(name string) string {
+	return strings.Map(mermaidNameRune, name)
+}
+
+func mermaidNameRune(r rune) rune {
+	if r == '_' || r == '-' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+		return r
+	}
+	return '_'
+}
+
+// mermaidType renders a column type as a Mermaid attribute type, e.g. "decimal(10,2) unsigned" becomes
+// "decimal(10-2)_unsigned".
+func mermaidType(dataType string) string {
+	if dataType == "" {
+		return "unknown"
+	}
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '(', ')', '[', ']':
+			return r
+		case ',':
+			return '-'
+		}
+		return mermaidNameRune(r)
+	}, dataType)
+}
+
+// mermaidQuote quotes a label or comment, replacing the double quotes Mermaid can't escape.
+func mermaidQuote(s string) string {
+	s = strings.ReplaceAll(s, `"`, "'")
+	s = strings.ReplaceAll(s, "\n", " ")
+	return `"` + s + `"`
+}
+
+func renderDBML(dbMeta DatabaseMetadata) string {
+	var b strings.Builder
+	for i, table := range dbMeta.Tables {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+
+		// Single-column unique indexes become column settings, the rest go in an indexes block
+		uniqueColumns := make(map[string]bool)
+		var compositeIndexes []Index
+		for _, idx := range table.Indexes {
+			if idx.Name == "PRIMARY" {
+				continue
+			}
+			if idx.IsUnique && len(idx.ColumnNames) == 1 {
+				uniqueColumns[idx.ColumnNames[0]] = true
+				continue
+			}
+			compositeIndexes = append(compositeIndexes, idx)
+		}
+		pkColumns := make([]string, 0)
+		for _, col := range table.Columns {
+			if col.IsPrimaryKey {
+				pkColumns = append(pkColumns, col.Name)
+			}
+		}
+
+		fmt.Fprintf(&b, "Table %s {\n", dbmlName(table.Name))
+		for _, col := range table.Columns {
+			var settings []string
+			if col.IsPrimaryKey && len(pkColumns) == 1 {
+				settings = append(settings, "pk")
+			}
+			if col.AutoIncrement {
+				settings = append(settings, "increment")
+			}
+			if !col.IsNullable {
+				settings = append(settings, "not null")
+			}
+			if uniqueColumns[col.Name] {
+				settings = append(settings, "unique")
+			}
+			if note := descriptionOf(col.DBComment, col.AIDescription); note != "" {
+				settings = append(settings, "note: "+dbmlString(note))
+			}
+			line := dbmlName(col.Name) + " " + dbmlName(col.DataType)
+			if len(settings) > 0 {
+				line += " [" + strings.Join(settings, ", ") + "]"
+			}
+			fmt.Fprintf(&b, "  %s\n", line)
+		}
+
+		if len(pkColumns) > 1 || len(compositeIndexes) > 0 {
+			b.WriteString("\n  indexes {\n")
+			if len(pkColumns) > 1 {
+				fmt.Fprintf(&b, "    (%s) [pk]\n", dbmlNames(pkColumns))
+			}
+			for _, idx := range compositeIndexes {
+				settings := "name: " + dbmlString(idx.Name)
+				if idx.IsUnique {
+					settings = "unique, " + settings
+				}
+				fmt.Fprintf(&b, "    (%s) [%s]\n", dbmlNames(idx.ColumnNames), settings)
+			}
+			b.WriteString("  }\n")
+		}
+
+		if note := descriptionOf(table.DBComment, table.AIDescription); note != "" {
+			fmt.Fprintf(&b, "\n  Note: %s\n", dbmlString(note))
+		}
+		b.WriteString("}\n")
+	}
+
+	for _, table := range dbMeta.Tables {
+		for _, fk := range table.ForeignKeys {
+			if len(fk.ColumnNames) == 0 || len(fk.ColumnNames) != len(fk.RefColumnNames) {
+				continue
+			}
+			b.WriteString("\nRef")
+			if fk.Name != "" {
+				b.WriteString(" " + dbmlName(fk.Name))
+			}
+			fmt.Fprintf(&b, ": %s.%s > %s.%s", dbmlName(table.Name), dbmlColumns(fk.ColumnNames),
+				dbmlName(fk.RefTableName), dbmlColumns(fk.RefColumnNames))
+		}
+	}
+	if b.Len() > 0 && !strings.HasSuffix(b.String(), "\n") {
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// descriptionOf prefers the database comment over the AI description.
+func descriptionOf(dbComment, aiDescription string) string {
+	if dbComment != "" {
+		return dbComment
+	}
+	return aiDescription
+}
+
+// dbmlName double-quotes a name unless it is a plain identifier.
+func dbmlName(name string) string {
+	plain := name != ""
+	for _, r := range name {
+		if r != '_' && !(r >= 'a' && r <= 'z') && !(r >= 'A' && r <= 'Z') && !(r >= '0' && r <= '9') {
+			plain = false
+			break
+		}
+	}
+	if plain {
+		return name
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `\"`) + `"`
+}
+
+// dbmlNames renders a comma separated list of names, as used in index definitions.
+func dbmlNames(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = dbmlName(name)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// dbmlColumns renders the column side of a Ref, parenthesized for composite keys.
+func dbmlColumns(names []string) string {
+	if len(names) == 1 {
+		return dbmlName(names[0])
+	}
+	return "(" + dbmlNames(names) + ")"
+}
+
+// dbmlString renders a single-quoted DBML string.
+func dbmlString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "'", `\'`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return "'" + s + "'"
+}
@@ -0,0 +1,331 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// dbmlToken is a token of a DBML line: a plain word, a quoted name, a string or punctuation
+type dbmlToken struct {
+	text string
+	kind byte // 'w' word, 'q' quoted name, 's' string, 'p' punctuation
+}
+
+// tokenizeDBML splits a line of DBML into tokens, unquoting names and strings.
+func tokenizeDBML(t *testing.T, line string) []dbmlToken {
+	t.Helper()
+	var tokens []dbmlToken
+	for i := 0; i < len(line); {
+		c := line[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '"' || c == '\'':
+			var text strings.Builder
+			j := i + 1
+			for ; j < len(line) && line[j] != c; j++ {
+				if line[j] == '\\' && j+1 < len(line) {
+					j++
+					if line[j] == 'n' {
+						text.WriteByte('\n')
+						continue
+					}
+				}
+				text.WriteByte(line[j])
+			}
+			if j == len(line) {
+				t.Fatalf("unterminated %c in DBML line %q", c, line)
+			}
+			kind := byte('q')
+			if c == '\'' {
+				kind = 's'
+			}
+			tokens = append(tokens, dbmlToken{text.String(), kind})
+			i = j + 1
+		case strings.IndexByte("{}[](),:.>", c) >= 0:
+			tokens = append(tokens, dbmlToken{string(c), 'p'})
+			i++
+		default:
+			j := i
+			for j < len(line) && strings.IndexByte(" \t\"'{}[](),:.>", line[j]) < 0 {
+				j++
+			}
+			tokens = append(tokens, dbmlToken{line[i:j], 'w'})
+			i = j
+		}
+	}
+	return tokens
+}
+
+// dbmlSettings reads a bracketed settings list starting at tokens[0], returning each setting as text
+// ("not null", "note: ...") and the tokens after it.
+func dbmlSettings(t *testing.T, tokens []dbmlToken) ([]string, []dbmlToken) {
+	t.Helper()
+	if len(tokens) == 0 || tokens[0].text != "[" {
+		return nil, tokens
+	}
+	var settings []string
+	var current []string
+	for i := 1; i < len(tokens); i++ {
+		switch tok := tokens[i]; {
+		case tok.kind == 'p' && (tok.text == "," || tok.text == "]"):
+			settings = append(settings, strings.Join(current, " "))
+			current = nil
+			if tok.text == "]" {
+				return settings, tokens[i+1:]
+			}
+		case tok.kind == 'p' && tok.text == ":":
+			current[len(current)-1] += ":"
+		default:
+			current = append(current, tok.text)
+		}
+	}
+	t.Fatal("unterminated DBML settings")
+	return nil, nil
+}
+
+// dbmlNameList reads a name or a parenthesized list of names starting at tokens[0].
+func dbmlNameList(tokens []dbmlToken) ([]string, []dbmlToken) {
+	if len(tokens) == 0 {
+		return nil, tokens
+	}
+	if tokens[0].text != "(" {
+		return []string{tokens[0].text}, tokens[1:]
+	}
+	var names []string
+	for i := 1; i < len(tokens); i++ {
+		switch tokens[i].text {
+		case ")":
+			return names, tokens[i+1:]
+		case ",":
+		default:
+			names = append(names, tokens[i].text)
+		}
+	}
+	return names, nil
+}
+
+type parsedDBMLColumn struct {
+	Name, Type string
+	Settings   []string
+}
+
+type parsedDBMLIndex struct {
+	Columns  []string
+	Settings []string
+}
+
+type parsedDBMLTable struct {
+	Name    string
+	Columns []parsedDBMLColumn
+	Indexes []parsedDBMLIndex
+	Note    string
+}
+
+type parsedDBMLRef struct {
+	Name                   string
+	FromTable, ToTable     string
+	FromColumns, ToColumns []string
+}
+
+// parseDBML parses the subset of DBML renderDBML emits, failing the test on anything else.
+func parseDBML(t *testing.T, dbml string) ([]parsedDBMLTable, []parsedDBMLRef) {
+	t.Helper()
+	var tables []parsedDBMLTable
+	var refs []parsedDBMLRef
+	var table *parsedDBMLTable
+	inIndexes := false
+	for n, line := range strings.Split(dbml, "\n") {
+		tokens := tokenizeDBML(t, line)
+		switch {
+		case len(tokens) == 0:
+		case table == nil && tokens[0].text == "Table":
+			if len(tokens) != 3 || tokens[2].text != "{" {
+				t.Fatalf("line %d: bad table header %q", n+1, line)
+			}
+			tables = append(tables, parsedDBMLTable{Name: tokens[1].text})
+			table = &tables[len(tables)-1]
+		case table == nil && tokens[0].text == "Ref":
+			ref := parsedDBMLRef{}
+			rest := tokens[1:]
+			if rest[0].text != ":" {
+				ref.Name, rest = rest[0].text, rest[1:]
+			}
+			if len(rest) < 4 || rest[0].text != ":" || rest[2].text != "." {
+				t.Fatalf("line %d: bad ref %q", n+1, line)
+			}
+			ref.FromTable = rest[1].text
+			ref.FromColumns, rest = dbmlNameList(rest[3:])
+			if len(rest) < 3 || rest[0].text != ">" || rest[2].text != "." {
+				t.Fatalf("line %d: bad ref %q", n+1, line)
+			}
+			ref.ToTable = rest[1].text
+			ref.ToColumns, rest = dbmlNameList(rest[3:])
+			if len(rest) != 0 {
+				t.Fatalf("line %d: trailing tokens in ref %q", n+1, line)
+			}
+			refs = append(refs, ref)
+		case table == nil:
+			t.Fatalf("line %d: unexpected %q outside a table", n+1, line)
+		case tokens[0].text == "}":
+			if inIndexes {
+				inIndexes = false
+			} else {
+				table = nil
+			}
+		case inIndexes:
+			columns, rest := dbmlNameList(tokens)
+			settings, rest := dbmlSettings(t, rest)
+			if len(rest) != 0 {
+				t.Fatalf("line %d: trailing tokens in index %q", n+1, line)
+			}
+			table.Indexes = append(table.Indexes, parsedDBMLIndex{Columns: columns, Settings: settings})
+		case tokens[0].text == "indexes" && len(tokens) == 2 && tokens[1].text == "{":
+			inIndexes = true
+		case tokens[0].text == "Note" && len(tokens) == 3 && tokens[1].text == ":" && tokens[2].kind == 's':
+			table.Note = tokens[2].text
+		default:
+			if len(tokens) < 2 || tokens[0].kind == 's' || tokens[1].kind == 's' {
+				t.Fatalf("line %d: bad column %q", n+1, line)
+			}
+			settings, rest := dbmlSettings(t, tokens[2:])
+			if len(rest) != 0 {
+				t.Fatalf("line %d: trailing tokens in column %q", n+1, line)
+			}
+			table.Columns = append(table.Columns, parsedDBMLColumn{Name: tokens[0].text, Type: tokens[1].text, Settings: settings})
+		}
+	}
+	if table != nil {
+		t.Fatalf("table %s isn't closed", table.Name)
+	}
+	return tables, refs
+}
+
+func TestRenderDBML(t *testing.T) {
+	dbMeta := DatabaseMetadata{
+		Name: "shop",
+		Tables: []Table{
+			{
+				Name: "orders",
+				Columns: []Column{
+					{Name: "id", DataType: "bigint", IsPrimaryKey: true, AutoIncrement: true},
+					{Name: "code", DataType: "varchar(32)"},
+					{Name: "total", DataType: "decimal(10,2)", IsNullable: true, DBComment: "it's \"gross\"\nin cents"},
+				},
+				Indexes:   []Index{{Name: "PRIMARY", ColumnNames: []string{"id"}, IsUnique: true}, {Name: "uk_code", ColumnNames: []string{"code"}, IsUnique: true}},
+				DBComment: "Customer orders",
+			},
+			{
+				Name: "order items",
+				Columns: []Column{
+					{Name: "order_id", DataType: "bigint", IsPrimaryKey: true},
+					{Name: "line", DataType: "int", IsPrimaryKey: true},
+					{Name: "sku", DataType: "varchar(64)", IsNullable: true, AIDescription: "Stock keeping unit"},
+				},
+				ForeignKeys:   []ForeignKey{{Name: "fk_order", ColumnNames: []string{"order_id"}, RefTableName: "orders", RefColumnNames: []string{"id"}}},
+				Indexes:       []Index{{Name: "idx_sku_line", ColumnNames: []string{"sku", "line"}}},
+				AIDescription: "Lines of an order",
+			},
+		},
+	}
+
+	tables, refs := parseDBML(t, renderDBML(dbMeta))
+
+	wantTables := []parsedDBMLTable{
+		{
+			Name: "orders",
+			Columns: []parsedDBMLColumn{
+				{Name: "id", Type: "bigint", Settings: []string{"pk", "increment", "not null"}},
+				{Name: "code", Type: "varchar(32)", Settings: []string{"not null", "unique"}},
+				{Name: "total", Type: "decimal(10,2)", Settings: []string{"note: it's \"gross\"\nin cents"}},
+			},
+			Note: "Customer orders",
+		},
+		{
+			Name: "order items",
+			Columns: []parsedDBMLColumn{
+				{Name: "order_id", Type: "bigint", Settings: []string{"not null"}},
+				{Name: "line", Type: "int", Settings: []string{"not null"}},
+				{Name: "sku", Type: "varchar(64)", Settings: []string{"note: Stock keeping unit"}},
+			},
+			Indexes: []parsedDBMLIndex{
+				{Columns: []string{"order_id", "line"}, Settings: []string{"pk"}},
+				{Columns: []string{"sku", "line"}, Settings: []string{"name: idx_sku_line"}},
+			},
+			Note: "Lines of an order",
+		},
+	}
+	if !reflect.DeepEqual(tables, wantTables) {
+		t.Errorf("tables =\n%+v\nwant\n%+v", tables, wantTables)
+	}
+	wantRefs := []parsedDBMLRef{{Name: "fk_order", FromTable: "order items", FromColumns: []string{"order_id"}, ToTable: "orders", ToColumns: []string{"id"}}}
+	if !reflect.DeepEqual(refs, wantRefs) {
+		t.Errorf("refs = %+v, want %+v", refs, wantRefs)
+	}
+}
+
+func TestCompositeForeignKeyDiagram(t *testing.T) {
+	cluster := &fakeCluster{databases: map[string]map[string]fakeSchemaTable{"shop": {
+		"orders": {columns: []string{"tenant_id", "id"}, pk: []string{"tenant_id", "id"}},
+		"order_lines": {
+			columns: []string{"tenant_id", "order_id", "line"},
+			pk:      []string{"tenant_id", "order_id", "line"},
+			fks: [][4]string{
+				{"fk_order", "tenant_id", "orders", "tenant_id"},
+				{"fk_order", "order_id", "orders", "id"},
+			},
+		},
+	}}}
+	s := newTestExtraction(t, cluster)
+	metadata, err := s.ExtractMetadata(context.Background(), "conn")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edges := metadata.Databases["shop"].Graph["order_lines"]
+	if len(edges) != 1 {
+		t.Fatalf("order_lines has %d edges, want 1", len(edges))
+	}
+	edge := edges[0]
+	if edge.ToTable != "orders" || edge.FromColumn != "tenant_id" || edge.ToColumn != "tenant_id" ||
+		!slices.Equal(edge.FromColumns, []string{"tenant_id", "order_id"}) || !slices.Equal(edge.ToColumns, []string{"tenant_id", "id"}) {
+		t.Errorf("edge = %+v, want every column pair of fk_order in order", edge)
+	}
+
+	dbml, err := s.ExportERDiagram("conn", "shop", ERDiagramFormatDBML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, refs := parseDBML(t, dbml)
+	wantRefs := []parsedDBMLRef{{
+		Name:        "fk_order",
+		FromTable:   "order_lines",
+		FromColumns: []string{"tenant_id", "order_id"},
+		ToTable:     "orders",
+		ToColumns:   []string{"tenant_id", "id"},
+	}}
+	if !reflect.DeepEqual(refs, wantRefs) {
+		t.Errorf("DBML refs = %+v, want %+v", refs, wantRefs)
+	}
+
+	mermaid, err := s.ExportERDiagram("conn", "shop", ERDiagramFormatMermaid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"int tenant_id PK, FK",
+		"int order_id PK, FK",
+		"int line PK\n",
+		"order_lines }o--|| orders : \"fk_order\"",
+	} {
+		if !strings.Contains(mermaid, want) {
+			t.Errorf("Mermaid diagram doesn't contain %q:\n%s", want, mermaid)
+		}
+	}
+	if n := strings.Count(mermaid, "}o--||"); n != 1 {
+		t.Errorf("Mermaid diagram has %d relationships, want 1 for the composite key", n)
+	}
+}
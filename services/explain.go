@@ -0,0 +1,246 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// EstimationErrorThreshold is the estimated/actual row ratio above which an operator is flagged
+	EstimationErrorThreshold = 10.0
+	// DefaultSafeModeRowThreshold is the full scan size above which safe mode blocks a query
+	DefaultSafeModeRowThreshold int64 = 100000
+)
+
+// PlanOperator is a single operator row of TiDB's EXPLAIN or EXPLAIN ANALYZE output
+type PlanOperator struct {
+	ID            string   `json:"id"`    // Operator ID with the tree prefix stripped, e.g. TableFullScan_5
+	Depth         int      `json:"depth"` // Nesting level in the plan tree, 0 for the root
+	EstRows       float64  `json:"estRows"`
+	ActRows       *float64 `json:"actRows,omitempty"` // Only present for EXPLAIN ANALYZE
+	Task          string   `json:"task"`
+	AccessObject  string   `json:"accessObject,omitempty"`
+	OperatorInfo  string   `json:"operatorInfo,omitempty"`
+	ExecutionInfo string   `json:"executionInfo,omitempty"` // Only present for EXPLAIN ANALYZE
+}
+
+// OperatorEstimate compares estimated and actual rows for a single plan operator
+type OperatorEstimate struct {
+	ID           string  `json:"id"`
+	Depth        int     `json:"depth"`
+	AccessObject string  `json:"accessObject,omitempty"`
+	EstRows      float64 `json:"estRows"`
+	ActRows      float64 `json:"actRows"`
+	ErrorRatio   float64 `json:"errorRatio"` // Always >= 1, regardless of the direction of the error
+	Misestimated bool    `json:"misestimated"`
+}
+
+// EstimationReport summarizes how accurate the optimizer's row estimates were for a query
+type EstimationReport struct {
+	Query             string             `json:"query"`
+	Operators         []OperatorEstimate `json:"operators"`
+	MisestimatedCount int                `json:"misestimatedCount"`
+	TablesToAnalyze   []string           `json:"tablesToAnalyze,omitempty"` // Tables accessed by misestimated operators
+	Suggestion        string             `json:"suggestion,omitempty"`
+}
+
+var accessObjectTablePattern = regexp.MustCompile(`table:([^\s,]+)`)
+
+// Explain runs EXPLAIN (or EXPLAIN ANALYZE, which executes the query) and parses the plan.
+// Optional args are bound to `?` placeholders in the query. Only TiDB's plan format is supported.
+func (s *DatabaseService) Explain(ctx context.Context, details ConnectionDetails, dbName string, query string, analyze bool, args ...any) ([]PlanOperator, error) {
+	if dbName != "" {
+		details.DBName = dbName
+	}
+
+	prefix := "EXPLAIN "
+	if analyze {
+		prefix = "EXPLAIN ANALYZE "
+	}
+
+	result, err := s.ExecuteSQL(ctx, details, prefix+strings.TrimSuffix(strings.TrimSpace(query), ";"), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to explain query: %w", err)
+	}
+	return parsePlanRows(result)
+}
+
+// parsePlanRows converts the rows of a TiDB EXPLAIN result into plan operators.
+func parsePlanRows(result *SQLResult) ([]PlanOperator, error) {
+	if result == nil || len(result.Columns) == 0 {
+		return nil, fmt.Errorf("EXPLAIN returned no plan")
+	}
+	hasEstRows := false
+	for _, col := range result.Columns {
+		if col == "estRows" {
+			hasEstRows = true
+			break
+		}
+	}
+	if !hasEstRows {
+		return nil, fmt.Errorf("unsupported EXPLAIN format (expected TiDB plan with estRows column)")
+	}
+
+	operators := make([]PlanOperator, 0, len(result.Rows))
+	for _, row := range result.Rows {
+		rawID := valueString(row["id"])
+		id := strings.TrimLeft(rawID, " │├└─")
+		op := PlanOperator{
+			ID:            id,
+			Depth:         (len([]rune(rawID)) - len([]rune(id))) / 2,
+			Task:          valueString(row["task"]),
+			AccessObject:  valueString(row["access object"]),
+			OperatorInfo:  valueString(row["operator info"]),
+			ExecutionInfo: valueString(row["execution info"]),
+		}
+		if est, ok := valueFloat(row["estRows"]); ok {
+			op.EstRows = est
+		}
+		if act, ok := valueFloat(row["actRows"]); ok {
+			op.ActRows = &act
+		}
+		operators = append(operators, op)
+	}
+	return operators, nil
+}
+
+// CheckFullScanSafety runs EXPLAIN on a SELECT and returns a non-empty reason if the plan contains a full
+// table or index scan over more than threshold rows. Other statement types are never blocked.
+// Optional args are bound to `?` placeholders in the query.
+func (s *DatabaseService) CheckFullScanSafety(ctx context.Context, details ConnectionDetails, query string, threshold int64, args ...any) (string, error) {
+	if !isSelectStatement(query) {
+		return "", nil
+	}
+	if threshold <= 0 {
+		threshold = DefaultSafeModeRowThreshold
+	}
+
+	operators, err := s.Explain(ctx, details, "", query, false, args...)
+	if err != nil {
+		return "", err
+	}
+
+	for _, op := range operators {
+		if !strings.HasPrefix(op.ID, "TableFullScan") && !strings.HasPrefix(op.ID, "IndexFullScan") {
+			continue
+		}
+		if op.EstRows > float64(threshold) {
+			target := op.AccessObject
+			if target == "" {
+				target = "a table"
+			}
+			return fmt.Sprintf("plan performs a full scan of %s (~%.0f rows, threshold %d)", target, op.EstRows, threshold), nil
+		}
+	}
+	return "", nil
+}
+
+// AnalyzeEstimationAccuracy runs EXPLAIN ANALYZE on the query and compares estimated and actual rows
+// per operator. Note that EXPLAIN ANALYZE executes the query.
+func (s *DatabaseService) AnalyzeEstimationAccuracy(ctx context.Context, details ConnectionDetails, dbName string, query string) (*EstimationReport, error) {
+	operators, err := s.Explain(ctx, details, dbName, query, true)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &EstimationReport{
+		Query:     query,
+		Operators: make([]OperatorEstimate, 0, len(operators)),
+	}
+	tables := make(map[string]bool)
+
+	for _, op := range operators {
+		if op.ActRows == nil {
+			continue
+		}
+		estimate := OperatorEstimate{
+			ID:           op.ID,
+			Depth:        op.Depth,
+			AccessObject: op.AccessObject,
+			EstRows:      op.EstRows,
+			ActRows:      *op.ActRows,
+			ErrorRatio:   estimationErrorRatio(op.EstRows, *op.ActRows),
+		}
+		if estimate.ErrorRatio >= EstimationErrorThreshold {
+			estimate.Misestimated = true
+			report.MisestimatedCount++
+			if m := accessObjectTablePattern.FindStringSubmatch(op.AccessObject); m != nil {
+				tables[m[1]] = true
+			}
+		}
+		report.Operators = append(report.Operators, estimate)
+	}
+
+	if len(report.Operators) == 0 {
+		return nil, fmt.Errorf("EXPLAIN ANALYZE returned no actual row counts")
+	}
+
+	for table := range tables {
+		report.TablesToAnalyze = append(report.TablesToAnalyze, table)
+	}
+	sort.Strings(report.TablesToAnalyze)
+	if len(report.TablesToAnalyze) > 0 {
+		report.Suggestion = fmt.Sprintf("Statistics look stale, consider running ANALYZE TABLE on: %s", strings.Join(report.TablesToAnalyze, ", "))
+	} else if report.MisestimatedCount > 0 {
+		report.Suggestion = "Some operators were badly misestimated, statistics for the involved tables may be stale"
+	}
+
+	return report, nil
+}
+
+// estimationErrorRatio returns how many times the estimate was off, in either direction.
+// One is added to both sides so empty results don't divide by zero.
+func estimationErrorRatio(est, act float64) float64 {
+	est, act = est+1, act+1
+	return math.Max(est, act) / math.Min(est, act)
+}
+
+// valueString renders a scanned result value as a string.
+func valueString(v any) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case []byte:
+		return string(val)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// valueFloat converts a scanned numeric or numeric-string result value to float64.
+func valueFloat(v any) (float64, bool) {
+	switch val := v.(type) {
+	case float64:
+		return val, true
+	case int64:
+		return float64(val), true
+	case nil:
+		return 0, false
+	default:
+		f, err := strconv.ParseFloat(strings.TrimSpace(valueString(val)), 64)
+		return f, err == nil
+	}
+}
+
+// valueInt64 converts a scanned integer or integer-string result value to int64 without the precision
+// loss of valueFloat.
+func valueInt64(v any) (int64, bool) {
+	switch val := v.(type) {
+	case int64:
+		return val, true
+	case uint64:
+		return int64(val), true
+	case nil:
+		return 0, false
+	default:
+		i, err := strconv.ParseInt(strings.TrimSpace(valueString(val)), 10, 64)
+		return i, err == nil
+	}
+}
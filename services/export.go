@@ -0,0 +1,241 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+)
+
+// exportTimeLayout formats DATETIME and TIMESTAMP values in exports
+const exportTimeLayout = "2006-01-02 15:04:05.999999"
+
+// exportTemplateFuncs are the only functions available to export templates besides text/template's
+// built-ins. None of them touch the filesystem, network or processes.
+var exportTemplateFuncs = template.FuncMap{
+	"upper":    strings.ToUpper,
+	"lower":    strings.ToLower,
+	"trim":     strings.TrimSpace,
+	"replace":  strings.ReplaceAll,
+	"sqlQuote": sqlQuoteValue,
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"isNull": func(v any) bool { return v == nil },
+}
+
+// sqlQuoteValue renders a value as a SQL literal.
+func sqlQuoteValue(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return "NULL"
+	case int64, float64, bool:
+		return fmt.Sprint(val)
+	case time.Time:
+		return "'" + val.Format(exportTimeLayout) + "'"
+	default:
+		escaped := strings.NewReplacer(`\`, `\\`, `'`, `''`, "\x00", `\0`, "\n", `\n`, "\r", `\r`, "\x1a", `\Z`).Replace(valueString(val))
+		return "'" + escaped + "'"
+	}
+}
+
+// ExportWithTemplate runs a query and writes each row through a Go text/template, with the row's column
+// values (keyed by column name) as data. Rows are streamed, so results of any size can be exported.
+// Templates only have access to text/template built-ins and a few string helpers.
+func (s *DatabaseService) ExportWithTemplate(ctx context.Context, details ConnectionDetails, query string, goTemplate string, writer io.Writer) error {
+	tmpl, err := template.New("export").Funcs(exportTemplateFuncs).Option("missingkey=zero").Parse(goTemplate)
+	if err != nil {
+		return fmt.Errorf("invalid template: %w", err)
+	}
+
+	out := bufio.NewWriter(writer)
+	var count int64
+	_, err = s.ExecuteSQLStream(ctx, details, query, func(row map[string]any) error {
+		if err := tmpl.Execute(out, row); err != nil {
+			return fmt.Errorf("template failed on row %d: %w", count+1, err)
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := out.Flush(); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+
+	LogInfo("Exported %d rows with template", count)
+	return nil
+}
+
+// exportValueString renders a non-NULL value as text for file exports.
+func exportValueString(v any) string {
+	if t, ok := v.(time.Time); ok {
+		return t.Format(exportTimeLayout)
+	}
+	return valueString(v)
+}
+
+// CSVOptions controls the format of ExportQueryCSV
+type CSVOptions struct {
+	Delimiter  string `json:"delimiter,omitempty"`  // Single character, defaults to a comma
+	Header     bool   `json:"header"`               // Write the column names as the first record
+	NullString string `json:"nullString,omitempty"` // Written for NULL values, e.g. \N; if empty, NULL and empty strings look the same
+}
+
+// DefaultCSVOptions returns comma-separated output with a header row and NULLs written as \N,
+// the convention understood by LOAD DATA.
+func DefaultCSVOptions() CSVOptions {
+	return CSVOptions{Delimiter: ",", Header: true, NullString: `\N`}
+}
+
+// newCSVWriter returns a CSV writer using the delimiter of opts.
+func newCSVWriter(writer io.Writer, opts CSVOptions) (*csv.Writer, error) {
+	out := csv.NewWriter(writer)
+	if opts.Delimiter != "" {
+		delimiter, size := utf8.DecodeRuneInString(opts.Delimiter)
+		if size != len(opts.Delimiter) {
+			return nil, fmt.Errorf("CSV delimiter must be a single character, got %q", opts.Delimiter)
+		}
+		out.Comma = delimiter
+	}
+	return out, nil
+}
+
+// ExportQueryCSV runs a query and streams its rows to writer as RFC 4180 CSV, in the column order of
+// the result set. Optional args are bound to `?` placeholders in the query.
+func (s *DatabaseService) ExportQueryCSV(ctx context.Context, details ConnectionDetails, query string, writer io.Writer, opts CSVOptions, args ...any) error {
+	out, err := newCSVWriter(writer, opts)
+	if err != nil {
+		return err
+	}
+
+	var columns []string
+	var record []string
+	summary, err := s.streamQuery(ctx, details, query, func(cols []string) error {
+		columns = cols
+		record = make([]string, len(cols))
+		if opts.Header {
+			return out.Write(cols)
+		}
+		return nil
+	}, func(row map[string]any) error {
+		for i, col := range columns {
+			if v := row[col]; v == nil {
+				record[i] = opts.NullString
+			} else {
+				record[i] = exportValueString(v)
+			}
+		}
+		return out.Write(record)
+	}, args...)
+	if err != nil {
+		return err
+	}
+	out.Flush()
+	if err := out.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV export: %w", err)
+	}
+
+	LogInfo("Exported %d rows as CSV", summary.RowCount)
+	return nil
+}
+
+// JSONOptions controls the format of ExportQueryJSON
+type JSONOptions struct {
+	NDJSON bool `json:"ndjson"` // One object per line instead of a single array
+}
+
+// ExportQueryJSON runs a query and streams its rows to writer as JSON objects with keys in result column
+// order. Numbers stay numbers and NULL stays null. Rows form a single array, or with NDJSON are written
+// one object per line. Optional args are bound to `?` placeholders in the query.
+func (s *DatabaseService) ExportQueryJSON(ctx context.Context, details ConnectionDetails, query string, writer io.Writer, opts JSONOptions, args ...any) error {
+	out := bufio.NewWriter(writer)
+
+	var columns []string
+	var keys [][]byte // JSON-encoded column names
+	var count int64
+	_, err := s.streamQuery(ctx, details, query, func(cols []string) error {
+		columns = cols
+		keys = make([][]byte, len(cols))
+		for i, col := range cols {
+			key, err := json.Marshal(col)
+			if err != nil {
+				return err
+			}
+			keys[i] = key
+		}
+		return nil
+	}, func(row map[string]any) error {
+		switch {
+		case opts.NDJSON:
+		case count == 0:
+			out.WriteByte('[')
+		default:
+			out.WriteByte(',')
+		}
+		if err := writeJSONRow(out, columns, keys, row); err != nil {
+			return fmt.Errorf("failed to encode row %d: %w", count+1, err)
+		}
+		if opts.NDJSON {
+			out.WriteByte('\n')
+		}
+		count++
+		return nil
+	}, args...)
+	if err != nil {
+		return err
+	}
+	if !opts.NDJSON {
+		if count == 0 {
+			out.WriteByte('[')
+		}
+		out.WriteString("]\n")
+	}
+	if err := out.Flush(); err != nil {
+		return fmt.Errorf("failed to write JSON export: %w", err)
+	}
+
+	LogInfo("Exported %d rows as JSON", count)
+	return nil
+}
+
+// writeJSONRow writes row as a JSON object with its keys in column order.
+func writeJSONRow(out *bufio.Writer, columns []string, keys [][]byte, row map[string]any) error {
+	out.WriteByte('{')
+	for i, col := range columns {
+		if i > 0 {
+			out.WriteByte(',')
+		}
+		out.Write(keys[i])
+		out.WriteByte(':')
+
+		var value any = row[col]
+		if t, ok := value.(time.Time); ok {
+			value = t.Format(exportTimeLayout)
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		out.Write(encoded)
+	}
+	return out.WriteByte('}')
+}
+
+// BuildTableQuery returns the SELECT statement and its args for all rows of a table matching the data
+// grid's filterParams, as used by GetTableData but without paging.
+func BuildTableQuery(dbName string, tableName string, filterParams *map[string]any) (string, []any, error) {
+	where, args, err := buildFilterWhereClause(filterParams)
+	if err != nil {
+		return "", nil, err
+	}
+	return fmt.Sprintf("SELECT * FROM %s.%s%s", quoteIdentifier(dbName), quoteIdentifier(tableName), where), args, nil
+}
@@ -0,0 +1,318 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// exportCheckpointRows is how many rows a resumable export writes between checkpoints
+const exportCheckpointRows = 10000
+
+// ExportCheckpoint records how far a resumable table export got. It is kept next to the export file, see
+// ExportCheckpointPath, and removed once the export completes.
+type ExportCheckpoint struct {
+	Database   string   `json:"database"`
+	Table      string   `json:"table"`
+	Format     string   `json:"format"` // "csv" or "sql"
+	KeyColumns []string `json:"keyColumns"`
+	// LastKey holds the key column values of the last row written, as text
+	LastKey []string  `json:"lastKey"`
+	Rows    int64     `json:"rows"`   // Rows written so far
+	Offset  int64     `json:"offset"` // Size of the export file at the checkpoint, anything after it is rewritten
+	Updated time.Time `json:"updated"`
+}
+
+// TableExportOptions controls ExportTableResumable. Only the options of the chosen format apply.
+type TableExportOptions struct {
+	Format string         `json:"format"` // "csv" or "sql"
+	CSV    CSVOptions     `json:"csv"`
+	SQL    SQLDumpOptions `json:"sql"`
+}
+
+// ExportCheckpointPath returns the path of the checkpoint file of an export to exportPath.
+func ExportCheckpointPath(exportPath string) string {
+	return exportPath + ".checkpoint"
+}
+
+// LoadExportCheckpoint reads the checkpoint of an interrupted export to exportPath, nil if there is none.
+func LoadExportCheckpoint(exportPath string) (*ExportCheckpoint, error) {
+	data, err := os.ReadFile(ExportCheckpointPath(exportPath))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read export checkpoint: %w", err)
+	}
+	var checkpoint ExportCheckpoint
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return nil, fmt.Errorf("invalid export checkpoint: %w", err)
+	}
+	return &checkpoint, nil
+}
+
+// save writes the checkpoint next to the export file.
+func (c *ExportCheckpoint) save(exportPath string) error {
+	c.Updated = time.Now()
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal export checkpoint: %w", err)
+	}
+	if err := writeFileAtomic(ExportCheckpointPath(exportPath), data, 0600); err != nil {
+		return fmt.Errorf("failed to write export checkpoint: %w", err)
+	}
+	return nil
+}
+
+// keysetResumeQuery returns the query reading a table in key order, after lastKey when it is set.
+func keysetResumeQuery(dbName string, tableName string, rowKey *RowKey, lastKey []string) (string, []any) {
+	quotedKey := make([]string, len(rowKey.Columns))
+	for i, col := range rowKey.Columns {
+		quotedKey[i] = quoteIdentifier(col)
+	}
+	keyList := strings.Join(quotedKey, ", ")
+
+	query := fmt.Sprintf("SELECT %s FROM %s.%s", rowKey.selectList(), quoteIdentifier(dbName), quoteIdentifier(tableName))
+	var args []any
+	if len(lastKey) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(lastKey)), ", ")
+		query += fmt.Sprintf(" WHERE (%s) > (%s)", keyList, placeholders)
+		for _, v := range lastKey {
+			args = append(args, v)
+		}
+	}
+	return query + " ORDER BY " + keyList, args
+}
+
+// keyText renders a scanned key value as text to store in a checkpoint.
+func keyText(v any) string {
+	if b, ok := v.([]byte); ok {
+		return string(b)
+	}
+	return exportValueString(v)
+}
+
+// ExportTableResumable writes the rows of a table to the file at path as CSV or SQL INSERT statements, in
+// row key order, saving a checkpoint next to the file every exportCheckpointRows rows. With resume, an
+// earlier export to the same file that was interrupted continues after its last checkpoint instead of
+// starting over; whatever it wrote after the checkpoint is discarded. The checkpoint is removed once the
+// export completes. Returns the number of rows in the finished file.
+func (s *DatabaseService) ExportTableResumable(ctx context.Context, details ConnectionDetails, dbName string, tableName string, path string, opts TableExportOptions, resume bool) (int64, error) {
+	targetDB := dbName
+	if targetDB == "" {
+		targetDB = details.DBName
+	}
+	if targetDB == "" {
+		return 0, fmt.Errorf("database name is required either explicitly or in connection details")
+	}
+	if tableName == "" {
+		return 0, fmt.Errorf("table name is required")
+	}
+	format := strings.ToLower(opts.Format)
+	if format != "csv" && format != "sql" {
+		return 0, fmt.Errorf("unsupported export format '%s', expected csv or sql", opts.Format)
+	}
+
+	rowKey, err := s.ResolveRowKey(ctx, details, targetDB, tableName)
+	if err != nil {
+		return 0, err
+	}
+	if format == "sql" && opts.SQL.Upsert && rowKey.Kind == RowKeyRowID {
+		return 0, fmt.Errorf("table '%s.%s' needs a primary key or NOT NULL unique index for upserts", targetDB, tableName)
+	}
+
+	checkpoint := &ExportCheckpoint{Database: targetDB, Table: tableName, Format: format, KeyColumns: rowKey.Columns}
+	var f *os.File
+	if resume {
+		saved, err := LoadExportCheckpoint(path)
+		if err != nil {
+			return 0, err
+		}
+		if saved == nil {
+			return 0, fmt.Errorf("no checkpoint to resume the export to %s from", path)
+		}
+		if saved.Database != targetDB || saved.Table != tableName || saved.Format != format || !slices.Equal(saved.KeyColumns, rowKey.Columns) {
+			return 0, fmt.Errorf("the checkpoint of %s is for exporting %s.%s as %s", path, saved.Database, saved.Table, saved.Format)
+		}
+		checkpoint = saved
+
+		f, err = os.OpenFile(path, os.O_WRONLY, 0)
+		if err != nil {
+			return 0, fmt.Errorf("failed to open export file: %w", err)
+		}
+		info, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return 0, fmt.Errorf("failed to open export file: %w", err)
+		}
+		if info.Size() < checkpoint.Offset {
+			f.Close()
+			return 0, fmt.Errorf("export file %s is shorter than its checkpoint, start the export over", path)
+		}
+		// Rows written after the checkpoint are written again
+		if err := f.Truncate(checkpoint.Offset); err != nil {
+			f.Close()
+			return 0, fmt.Errorf("failed to truncate export file: %w", err)
+		}
+		if _, err := f.Seek(checkpoint.Offset, io.SeekStart); err != nil {
+			f.Close()
+			return 0, fmt.Errorf("failed to seek export file: %w", err)
+		}
+		LogInfo("Resuming export of %s.%s to %s after %d rows", targetDB, tableName, path, checkpoint.Rows)
+	} else {
+		f, err = os.Create(path)
+		if err != nil {
+			return 0, fmt.Errorf("failed to create export file: %w", err)
+		}
+		if err := os.Remove(ExportCheckpointPath(path)); err != nil && !os.IsNotExist(err) {
+			f.Close()
+			return 0, fmt.Errorf("failed to remove old export checkpoint: %w", err)
+		}
+	}
+	defer f.Close()
+
+	db, err := s.getDB(details)
+	if err != nil {
+		return 0, fmt.Errorf("connection setup failed for ExportTableResumable: %w", err)
+	}
+	query, args := keysetResumeQuery(targetDB, tableName, rowKey, checkpoint.LastKey)
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read table '%s.%s': %w", targetDB, tableName, err)
+	}
+	defer rows.Close()
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get column types: %w", err)
+	}
+	// The hidden row ID is selected after * to order by, but isn't part of the exported rows
+	exported := columnTypes
+	if rowKey.Kind == RowKeyRowID {
+		exported = columnTypes[:len(columnTypes)-1]
+	}
+	keyIndexes := make([]int, len(rowKey.Columns))
+	for i, col := range rowKey.Columns {
+		if rowKey.Kind == RowKeyRowID {
+			keyIndexes[i] = len(columnTypes) - 1
+		} else {
+			keyIndexes[i] = slices.IndexFunc(columnTypes, func(ct *sql.ColumnType) bool { return strings.EqualFold(ct.Name(), col) })
+		}
+		if keyIndexes[i] < 0 {
+			return 0, fmt.Errorf("key column '%s' missing from the result", col)
+		}
+		if dumpKind(columnTypes[keyIndexes[i]].DatabaseTypeName()) == dumpBinary {
+			return 0, fmt.Errorf("table '%s.%s' can't be exported resumably: key column '%s' is binary", targetDB, tableName, col)
+		}
+	}
+
+	// writeRow and flush write one row and complete the output up to the last row
+	var writeRow func(values []any) error
+	var flush func() error
+	fresh := checkpoint.Rows == 0 && checkpoint.Offset == 0
+	switch format {
+	case "csv":
+		out, err := newCSVWriter(f, opts.CSV)
+		if err != nil {
+			return 0, err
+		}
+		record := make([]string, len(exported))
+		if fresh && opts.CSV.Header {
+			for i, ct := range exported {
+				record[i] = ct.Name()
+			}
+			out.Write(record)
+		}
+		writeRow = func(values []any) error {
+			for i, v := range values {
+				switch val := v.(type) {
+				case nil:
+					record[i] = opts.CSV.NullString
+				case []byte:
+					record[i] = string(val)
+				default:
+					record[i] = exportValueString(val)
+				}
+			}
+			return out.Write(record)
+		}
+		flush = func() error {
+			out.Flush()
+			return out.Error()
+		}
+	case "sql":
+		out := bufio.NewWriter(f)
+		if fresh {
+			if err := writeSQLDumpHeader(ctx, db, out, targetDB, tableName, opts.SQL.IncludeDDL); err != nil {
+				return 0, err
+			}
+		}
+		dump := newSQLRowWriter(out, tableName, exported, rowKey.Columns, opts.SQL)
+		writeRow = func(values []any) error {
+			dump.writeRow(values)
+			return nil
+		}
+		flush = dump.flush
+	}
+
+	// saveCheckpoint records the position after the row in values, once its output is safely on disk
+	saveCheckpoint := func(values []any) error {
+		if err := flush(); err != nil {
+			return fmt.Errorf("failed to write export: %w", err)
+		}
+		if err := f.Sync(); err != nil {
+			return fmt.Errorf("failed to write export: %w", err)
+		}
+		offset, err := f.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return fmt.Errorf("failed to write export: %w", err)
+		}
+		checkpoint.Offset = offset
+		checkpoint.LastKey = make([]string, len(keyIndexes))
+		for i, idx := range keyIndexes {
+			checkpoint.LastKey[i] = keyText(values[idx])
+		}
+		return checkpoint.save(path)
+	}
+
+	values := make([]any, len(columnTypes))
+	scanArgs := make([]any, len(columnTypes))
+	for i := range values {
+		scanArgs[i] = &values[i]
+	}
+	var sinceCheckpoint int
+	for rows.Next() {
+		if err := rows.Scan(scanArgs...); err != nil {
+			return 0, fmt.Errorf("failed to scan row: %w", err)
+		}
+		if err := writeRow(values[:len(exported)]); err != nil {
+			return 0, fmt.Errorf("failed to write export: %w", err)
+		}
+		checkpoint.Rows++
+		if sinceCheckpoint++; sinceCheckpoint == exportCheckpointRows {
+			if err := saveCheckpoint(values); err != nil {
+				return 0, err
+			}
+			sinceCheckpoint = 0
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("error iterating rows: %w", err)
+	}
+	if err := flush(); err != nil {
+		return 0, fmt.Errorf("failed to write export: %w", err)
+	}
+
+	if err := os.Remove(ExportCheckpointPath(path)); err != nil && !os.IsNotExist(err) {
+		LogWarning("Failed to remove export checkpoint of %s: %v", path, err)
+	}
+	LogInfo("Exported %d rows of %s.%s to %s", checkpoint.Rows, targetDB, tableName, path)
+	return checkpoint.Rows, nil
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"testing"
+	"time"
+)
+
+// newTestExportService returns a DatabaseService whose connection answers every query with response.
+func newTestExportService(t *testing.T, response fakeResponse) (*DatabaseService, ConnectionDetails) {
+	t.Helper()
+	db, _ := newFakeDB(t, func(context.Context, string, []any) fakeResponse { return response })
+	details := ConnectionDetails{ID: "export", Host: "127.0.0.1"}
+	s := NewDatabaseService()
+	useFakeDB(s, details, db)
+	return s, details
+}
+
+func TestExportQueryJSON(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	s, details := newTestExportService(t, fakeResponse{
+		columns: []string{"id", "name", "note", "price", "created"},
+		types:   []string{"BIGINT", "VARCHAR", "TEXT", "DECIMAL", "DATETIME"},
+		rows: [][]driver.Value{
+			{int64(1), []byte("a\"b"), nil, []byte("19.90"), created},
+			{int64(2), []byte(""), []byte("x"), nil, nil},
+		},
+	})
+
+	tests := []struct {
+		name string
+		opts JSONOptions
+		want string
+	}{
+		{
+			name: "array",
+			want: `[{"id":1,"name":"a\"b","note":null,"price":"19.90","created":"2024-05-01 12:30:00"},` +
+				`{"id":2,"name":"","note":"x","price":null,"created":null}]` + "\n",
+		},
+		{
+			name: "ndjson",
+			opts: JSONOptions{NDJSON: true},
+			want: `{"id":1,"name":"a\"b","note":null,"price":"19.90","created":"2024-05-01 12:30:00"}` + "\n" +
+				`{"id":2,"name":"","note":"x","price":null,"created":null}` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := s.ExportQueryJSON(context.Background(), details, "SELECT * FROM t", &out, tt.opts); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("export =\n%s\nwant\n%s", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestExportQueryJSONEmpty(t *testing.T) {
+	s, details := newTestExportService(t, fakeResponse{columns: []string{"id"}, types: []string{"BIGINT"}})
+
+	var out bytes.Buffer
+	if err := s.ExportQueryJSON(context.Background(), details, "SELECT id FROM t", &out, JSONOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "[]\n" {
+		t.Errorf("export = %q, want an empty array", out.String())
+	}
+
+	out.Reset()
+	if err := s.ExportQueryJSON(context.Background(), details, "SELECT id FROM t", &out, JSONOptions{NDJSON: true}); err != nil {
+		t.Fatal(err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("NDJSON export = %q, want nothing", out.String())
+	}
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+)
+
+// fakeResponse is what the fake database answers a statement with. A statement without columns has no
+// result set.
+type fakeResponse struct {
+	columns  []string
+	types    []string // Database type names, parallel to columns
+	rows     [][]driver.Value
+	affected int64
+	err      error
+}
+
+// fakeStatement is a statement the fake database received, with the connection it ran on.
+type fakeStatement struct {
+	conn  int
+	query string
+	args  []any
+}
+
+// fakeConnector opens connections to a scripted in-memory database: respond answers every statement,
+// and every statement is recorded.
+type fakeConnector struct {
+	respond func(ctx context.Context, query string, args []any) fakeResponse
+
+	mu         sync.Mutex
+	conns      int
+	statements []fakeStatement
+}
+
+// newFakeDB returns a database whose statements are answered by respond.
+func newFakeDB(t *testing.T, respond func(ctx context.Context, query string, args []any) fakeResponse) (*sql.DB, *fakeConnector) {
+	t.Helper()
+	c := &fakeConnector{respond: respond}
+	db := sql.OpenDB(c)
+	t.Cleanup(func() { db.Close() })
+	return db, c
+}
+
+// Statements returns the statements run so far.
+func (c *fakeConnector) Statements() []fakeStatement {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]fakeStatement(nil), c.statements...)
+}
+
+func (c *fakeConnector) Connect(context.Context) (driver.Conn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.conns++
+	return &fakeConn{connector: c, id: c.conns}, nil
+}
+
+func (c *fakeConnector) Driver() driver.Driver { return fakeDriver{} }
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("fake driver connects through its connector only")
+}
+
+type fakeConn struct {
+	connector *fakeConnector
+	id        int
+}
+
+func (c *fakeConn) run(ctx context.Context, query string, named []driver.NamedValue) fakeResponse {
+	args := make([]any, len(named))
+	for i, arg := range named {
+		args[i] = arg.Value
+	}
+	c.connector.mu.Lock()
+	c.connector.statements = append(c.connector.statements, fakeStatement{conn: c.id, query: query, args: args})
+	c.connector.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return fakeResponse{err: err}
+	}
+	return c.connector.respond(context.WithValue(ctx, fakeConnKey{}, c.id), query, args)
+}
+
+// fakeConnKey is the context key under which respond finds the connection a statement runs on
+type fakeConnKey struct{}
+
+// fakeConnID returns the connection a statement given to respond runs on.
+func fakeConnID(ctx context.Context) int {
+	id, _ := ctx.Value(fakeConnKey{}).(int)
+	return id
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	response := c.run(ctx, query, args)
+	if response.err != nil {
+		return nil, response.err
+	}
+	return &fakeRows{response: response}, nil
+}
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	response := c.run(ctx, query, args)
+	if response.err != nil {
+		return nil, response.err
+	}
+	return driver.RowsAffected(response.affected), nil
+}
+
+func (c *fakeConn) BeginTx(ctx context.Context, _ driver.TxOptions) (driver.Tx, error) {
+	if response := c.run(ctx, "BEGIN", nil); response.err != nil {
+		return nil, response.err
+	}
+	return &fakeTx{conn: c}, nil
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("fake driver doesn't prepare statements")
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+type fakeTx struct{ conn *fakeConn }
+
+func (tx *fakeTx) Commit() error {
+	return tx.conn.run(context.Background(), "COMMIT", nil).err
+}
+
+func (tx *fakeTx) Rollback() error {
+	return tx.conn.run(context.Background(), "ROLLBACK", nil).err
+}
+
+type fakeRows struct {
+	response fakeResponse
+	next     int
+}
+
+func (r *fakeRows) Columns() []string { return r.response.columns }
+
+func (r *fakeRows) ColumnTypeDatabaseTypeName(i int) string {
+	if i < len(r.response.types) {
+		return r.response.types[i]
+	}
+	return ""
+}
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.response.rows) {
+		return io.EOF
+	}
+	copy(dest, r.response.rows[r.next])
+	r.next++
+	return nil
+}
+
+// useFakeDB makes s run the statements of details on db instead of connecting.
+func useFakeDB(s *DatabaseService, details ConnectionDetails, db *sql.DB) {
+	s.poolsMu.Lock()
+	defer s.poolsMu.Unlock()
+	s.pools[poolKey(details)] = db
+}
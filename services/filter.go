@@ -0,0 +1,197 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Filter group combinators
+const (
+	FilterAnd = "and"
+	FilterOr  = "or"
+)
+
+// buildFilterWhereClause turns the data grid's filterParams into a " WHERE ..." clause with ?
+// placeholders and its args, or "" when no filter applies. filterParams either holds a flat "filters"
+// list, whose conditions must all match, or a "filterGroup" tree: a group has a "combinator" ("and" or
+// "or") and "conditions", each of which is a filter or another group.
+func buildFilterWhereClause(filterParams *map[string]any) (string, []any, error) {
+	if filterParams == nil {
+		return "", nil, nil
+	}
+
+	group, ok := (*filterParams)["filterGroup"].(map[string]any)
+	if !ok {
+		filters, _ := (*filterParams)["filters"].([]any)
+		group = map[string]any{"combinator": FilterAnd, "conditions": filters}
+	}
+
+	args := make([]any, 0)
+	condition, err := renderFilterGroup(group, &args)
+	if err != nil || condition == "" {
+		return "", nil, err
+	}
+	return " WHERE " + condition, args, nil
+}
+
+// renderFilterGroup renders a filter group as a condition, parenthesized when it combines several.
+// Filters that don't apply and empty groups are left out.
+func renderFilterGroup(group map[string]any, args *[]any) (string, error) {
+	combinator, _ := group["combinator"].(string)
+	separator := " AND "
+	switch strings.ToLower(combinator) {
+	case "", FilterAnd:
+	case FilterOr:
+		separator = " OR "
+	default:
+		return "", fmt.Errorf("unsupported filter combinator '%s' (expected \"and\" or \"or\")", combinator)
+	}
+
+	items, _ := group["conditions"].([]any)
+	conditions := make([]string, 0, len(items))
+	for _, item := range items {
+		itemMap, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		var condition string
+		if _, isGroup := itemMap["conditions"]; isGroup {
+			var err error
+			if condition, err = renderFilterGroup(itemMap, args); err != nil {
+				return "", err
+			}
+		} else {
+			condition = renderFilter(itemMap, args)
+		}
+		if condition != "" {
+			conditions = append(conditions, condition)
+		}
+	}
+
+	if len(conditions) <= 1 {
+		return strings.Join(conditions, ""), nil
+	}
+	return "(" + strings.Join(conditions, separator) + ")", nil
+}
+
+// renderFilter renders a single filter as a condition, appending its values to args. Returns an empty
+// string for incomplete filters and unknown operators.
+func renderFilter(filter map[string]any, args *[]any) string {
+	columnId, hasColumnId := filter["columnId"].(string)
+	operator, hasOperator := filter["operator"].(string)
+	filterType, hasType := filter["type"].(string)
+	values, _ := filter["values"].([]any)
+	if !hasColumnId || !hasOperator {
+		return ""
+	}
+	column := quoteIdentifier(columnId)
+
+	// NULL and emptiness checks apply to any filter type and take no values
+	if condition := nullFilterCondition(column, operator); condition != "" {
+		return condition
+	}
+	if !hasType || len(values) == 0 {
+		return ""
+	}
+
+	bind := func(condition string, vals ...any) string {
+		*args = append(*args, vals...)
+		return condition
+	}
+
+	switch filterType {
+	case "text":
+		switch operator {
+		case "contains":
+			return bind(column+" LIKE ?"+likeEscapeClause, "%"+escapeLike(fmt.Sprint(values[0]))+"%")
+		case "does not contain":
+			return bind(column+" NOT LIKE ?"+likeEscapeClause, "%"+escapeLike(fmt.Sprint(values[0]))+"%")
+		}
+	case "number":
+		switch operator {
+		case "is":
+			return bind(column+" = ?", values[0])
+		case "is not":
+			return bind(column+" != ?", values[0])
+		case "is greater than":
+			return bind(column+" > ?", values[0])
+		case "is greater than or equal to":
+			return bind(column+" >= ?", values[0])
+		case "is less than":
+			return bind(column+" < ?", values[0])
+		case "is less than or equal to":
+			return bind(column+" <= ?", values[0])
+		case "is between":
+			if len(values) >= 2 {
+				return bind(column+" BETWEEN ? AND ?", values[0], values[1])
+			}
+		case "is not between":
+			if len(values) >= 2 {
+				return bind(column+" NOT BETWEEN ? AND ?", values[0], values[1])
+			}
+		}
+	case "date":
+		switch operator {
+		case "is":
+			return bind("DATE("+column+") = DATE(?)", values[0])
+		case "is not":
+			return bind("DATE("+column+") != DATE(?)", values[0])
+		case "is between":
+			if len(values) >= 2 {
+				return bind("DATE("+column+") BETWEEN DATE(?) AND DATE(?)", values[0], values[1])
+			}
+		case "is not between":
+			if len(values) >= 2 {
+				return bind("DATE("+column+") NOT BETWEEN DATE(?) AND DATE(?)", values[0], values[1])
+			}
+		}
+	case "option", "multiOption":
+		var options []any
+		if multiValues, ok := values[0].([]any); ok {
+			for _, v := range multiValues {
+				if strVal, ok := v.(string); ok {
+					options = append(options, strVal)
+				}
+			}
+		} else if strVal, ok := values[0].(string); ok {
+			options = append(options, strVal)
+		}
+		if len(options) == 0 {
+			return ""
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(options)), ", ")
+		switch operator {
+		case "is", "is any of", "include", "include any of":
+			return bind(column+" IN ("+placeholders+")", options...)
+		case "is not", "is none of", "exclude", "exclude if any of":
+			return bind(column+" NOT IN ("+placeholders+")", options...)
+		}
+	}
+	return ""
+}
+
+// likeEscapeClause declares the backslash as the escape character of a LIKE pattern built with escapeLike
+const likeEscapeClause = ` ESCAPE '\\'`
+
+// escapeLike escapes the LIKE wildcards % and _ and the escape character itself, so a search term
+// matches literally.
+func escapeLike(term string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(term)
+}
+
+// nullFilterCondition returns the condition for the value-less operators "is null", "is not null",
+// "is empty" and "is not empty" on a quoted column, or an empty string for any other operator. Empty
+// means a zero-length string, so NULL is neither empty nor not empty.
+func nullFilterCondition(column string, operator string) string {
+	switch operator {
+	case "is null":
+		return column + " IS NULL"
+	case "is not null":
+		return column + " IS NOT NULL"
+	case "is empty":
+		return column + " = ''"
+	case "is not empty":
+		return column + " <> ''"
+	}
+	return ""
+}
@@ -0,0 +1,236 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+// filterParams returns GetTableData filter params holding a flat list of filters.
+func filterParams(filters ...map[string]any) *map[string]any {
+	list := make([]any, len(filters))
+	for i, f := range filters {
+		list[i] = f
+	}
+	return &map[string]any{"filters": list}
+}
+
+func TestNullFilterOperators(t *testing.T) {
+	tests := []struct {
+		operator string
+		want     string
+	}{
+		{"is null", " WHERE `note` IS NULL"},
+		{"is not null", " WHERE `note` IS NOT NULL"},
+		{"is empty", " WHERE `note` = ''"},
+		{"is not empty", " WHERE `note` <> ''"},
+	}
+	for _, tt := range tests {
+		// The operators take no values and apply to every filter type, or none given
+		for _, filterType := range []any{"text", "number", "date", "option", nil} {
+			filter := map[string]any{"columnId": "note", "operator": tt.operator}
+			if filterType != nil {
+				filter["type"] = filterType
+			}
+			where, args, err := buildFilterWhereClause(filterParams(filter))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if where != tt.want || len(args) != 0 {
+				t.Errorf("%s on %v = %q %v, want %q without args", tt.operator, filterType, where, args, tt.want)
+			}
+		}
+	}
+}
+
+func TestNullFilterIgnoresValues(t *testing.T) {
+	where, args, err := buildFilterWhereClause(filterParams(
+		map[string]any{"columnId": "note", "operator": "is null", "type": "text", "values": []any{"x"}},
+		map[string]any{"columnId": "age", "operator": "is greater than", "type": "number", "values": []any{float64(18)}},
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := " WHERE (`note` IS NULL AND `age` > ?)"; where != want {
+		t.Errorf("where = %q, want %q", where, want)
+	}
+	if want := []any{float64(18)}; !reflect.DeepEqual(args, want) {
+		t.Errorf("args = %v, want %v", args, want)
+	}
+}
+
+func TestBuildTableQueryNullFilter(t *testing.T) {
+	query, args, err := BuildTableQuery("shop", "people", filterParams(
+		map[string]any{"columnId": "nickname", "operator": "is null", "type": "text"},
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "SELECT * FROM `shop`.`people` WHERE `nickname` IS NULL"; query != want || len(args) != 0 {
+		t.Errorf("query = %q %v, want %q", query, args, want)
+	}
+}
+
+func TestRenderFilterGroup(t *testing.T) {
+	text := func(column, term string) map[string]any {
+		return map[string]any{"columnId": column, "operator": "contains", "type": "text", "values": []any{term}}
+	}
+	number := func(column string, value float64) map[string]any {
+		return map[string]any{"columnId": column, "operator": "is", "type": "number", "values": []any{value}}
+	}
+	group := func(combinator string, conditions ...any) map[string]any {
+		return map[string]any{"combinator": combinator, "conditions": conditions}
+	}
+
+	tests := []struct {
+		name     string
+		group    map[string]any
+		want     string
+		wantArgs []any
+	}{
+		{
+			name:     "and",
+			group:    group("and", number("a", 1), number("b", 2)),
+			want:     "(`a` = ? AND `b` = ?)",
+			wantArgs: []any{1.0, 2.0},
+		},
+		{
+			name:     "or of ands keeps precedence",
+			group:    group("or", group("and", number("a", 1), number("b", 2)), group("and", number("c", 3), number("d", 4))),
+			want:     "((`a` = ? AND `b` = ?) OR (`c` = ? AND `d` = ?))",
+			wantArgs: []any{1.0, 2.0, 3.0, 4.0},
+		},
+		{
+			name:     "and of ors keeps precedence",
+			group:    group("AND", number("a", 1), group("OR", number("b", 2), number("c", 3))),
+			want:     "(`a` = ? AND (`b` = ? OR `c` = ?))",
+			wantArgs: []any{1.0, 2.0, 3.0},
+		},
+		{
+			name:     "args follow placeholder order",
+			group:    group("or", text("name", "x"), group("and", number("a", 1), text("note", "y")), number("b", 2)),
+			want:     "(`name` LIKE ? ESCAPE '\\\\' OR (`a` = ? AND `note` LIKE ? ESCAPE '\\\\') OR `b` = ?)",
+			wantArgs: []any{"%x%", 1.0, "%y%", 2.0},
+		},
+		{
+			name:     "single condition isn't parenthesized",
+			group:    group("or", group("and", number("a", 1))),
+			want:     "`a` = ?",
+			wantArgs: []any{1.0},
+		},
+		{
+			name:     "empty groups are left out",
+			group:    group("or", group("and"), group("or", group("and")), number("a", 1)),
+			want:     "`a` = ?",
+			wantArgs: []any{1.0},
+		},
+		{
+			name:     "incomplete filters are left out",
+			group:    group("and", map[string]any{"columnId": "a", "operator": "is", "type": "number"}, "not a filter", number("b", 2)),
+			want:     "`b` = ?",
+			wantArgs: []any{2.0},
+		},
+		{
+			name:  "all empty",
+			group: group("and", group("or")),
+			want:  "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := make([]any, 0)
+			got, err := renderFilterGroup(tt.group, &args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("condition = %s, want %s", got, tt.want)
+			}
+			if len(args) != len(tt.wantArgs) || (len(args) > 0 && !reflect.DeepEqual(args, tt.wantArgs)) {
+				t.Errorf("args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestRenderFilterGroupCombinator(t *testing.T) {
+	args := make([]any, 0)
+	if _, err := renderFilterGroup(map[string]any{"combinator": "xor", "conditions": []any{}}, &args); err == nil {
+		t.Error("unknown combinator accepted")
+	}
+
+	where, _, err := buildFilterWhereClause(&map[string]any{"filterGroup": map[string]any{"conditions": []any{}}})
+	if err != nil || where != "" {
+		t.Errorf("empty filter group = %q, %v, want no WHERE clause", where, err)
+	}
+}
+
+// likeMatch reports whether s matches a LIKE pattern with backslash as the escape character.
+func likeMatch(pattern, s string) bool {
+	if pattern == "" {
+		return s == ""
+	}
+	switch c := pattern[0]; {
+	case c == '%':
+		for i := 0; i <= len(s); i++ {
+			if likeMatch(pattern[1:], s[i:]) {
+				return true
+			}
+		}
+		return false
+	case c == '_':
+		return s != "" && likeMatch(pattern[1:], s[1:])
+	case c == '\\' && len(pattern) > 1:
+		return s != "" && s[0] == pattern[1] && likeMatch(pattern[2:], s[1:])
+	default:
+		return s != "" && s[0] == c && likeMatch(pattern[1:], s[1:])
+	}
+}
+
+func TestEscapeLike(t *testing.T) {
+	tests := []struct {
+		term     string
+		want     string
+		matches  []string
+		excludes []string
+	}{
+		{term: "50%", want: `50\%`, matches: []string{"50%", "save 50% now"}, excludes: []string{"500", "50 percent"}},
+		{term: "a_b", want: `a\_b`, matches: []string{"a_b", "xa_by"}, excludes: []string{"axb", "ab"}},
+		{term: `C:\temp`, want: `C:\\temp`, matches: []string{`C:\temp`, `dir C:\temp\x`}, excludes: []string{"C:temp", `C:\\temp`}},
+		{term: `\%`, want: `\\\%`, matches: []string{`\%`}, excludes: []string{`\x`, "%"}},
+		{term: "plain", want: "plain", matches: []string{"plain"}, excludes: []string{"plan"}},
+	}
+	for _, tt := range tests {
+		got := escapeLike(tt.term)
+		if got != tt.want {
+			t.Errorf("escapeLike(%q) = %q, want %q", tt.term, got, tt.want)
+		}
+		// The bound "contains" pattern matches the term literally
+		pattern := "%" + got + "%"
+		for _, s := range tt.matches {
+			if !likeMatch(pattern, s) {
+				t.Errorf("pattern %q for %q doesn't match %q", pattern, tt.term, s)
+			}
+		}
+		for _, s := range tt.excludes {
+			if likeMatch(pattern, s) {
+				t.Errorf("pattern %q for %q matches %q", pattern, tt.term, s)
+			}
+		}
+	}
+}
+
+func TestTextFilterBindsEscapedTerm(t *testing.T) {
+	where, args, err := buildFilterWhereClause(filterParams(
+		map[string]any{"columnId": "discount", "operator": "contains", "type": "text", "values": []any{"50%"}},
+		map[string]any{"columnId": "code", "operator": "does not contain", "type": "text", "values": []any{"a_b"}},
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := " WHERE (`discount` LIKE ? ESCAPE '\\\\' AND `code` NOT LIKE ? ESCAPE '\\\\')"; where != want {
+		t.Errorf("where = %s, want %s", where, want)
+	}
+	if want := []any{`%50\%%`, `%a\_b%`}; !reflect.DeepEqual(args, want) {
+		t.Errorf("args = %q, want %q", args, want)
+	}
+}
@@ -0,0 +1,262 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	mysql "github.com/go-sql-driver/mysql"
+)
+
+// Grant is a single privilege parsed from a GRANT statement
+type Grant struct {
+	Privilege       string   `json:"privilege"`         // e.g. SELECT, CREATE, ALL PRIVILEGES
+	Columns         []string `json:"columns,omitempty"` // Column list for column-level privileges
+	Scope           string   `json:"scope"`             // As written, e.g. *.*, `shop`.*, `shop`.`orders`
+	Database        string   `json:"database"`          // Unquoted, * for all databases
+	Table           string   `json:"table"`             // Unquoted, * for all tables
+	WithGrantOption bool     `json:"withGrantOption,omitempty"`
+}
+
+// UserGrants holds the raw grant statements of the current user along with their parsed form
+type UserGrants struct {
+	Raw    []string `json:"raw"`
+	Grants []Grant  `json:"grants"`
+	Roles  []string `json:"roles,omitempty"` // Granted roles, e.g. `analyst`@`%`
+}
+
+// GetCurrentUserGrants returns the grant statements of the connected user.
+func (s *DatabaseService) GetCurrentUserGrants(ctx context.Context, details ConnectionDetails) ([]string, error) {
+	result, err := s.ExecuteSQL(ctx, details, "SHOW GRANTS FOR CURRENT_USER()")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get grants: %w", err)
+	}
+
+	grants := make([]string, 0, len(result.Rows))
+	for _, row := range result.Rows {
+		// The single column is named after the user, e.g. "Grants for root@%"
+		for _, col := range result.Columns {
+			grants = append(grants, valueString(row[col]))
+			break
+		}
+	}
+	return grants, nil
+}
+
+// ParseGrants converts raw grant statements into structured grants. Statements that can't be parsed
+// are skipped and logged.
+func ParseGrants(statements []string) *UserGrants {
+	parsed := &UserGrants{
+		Raw:    statements,
+		Grants: make([]Grant, 0),
+	}
+	for _, stmt := range statements {
+		grants, role, ok := parseGrantStatement(stmt)
+		if !ok {
+			LogWarning("Unable to parse grant statement: %s", stmt)
+			continue
+		}
+		if role != "" {
+			parsed.Roles = append(parsed.Roles, role)
+			continue
+		}
+		parsed.Grants = append(parsed.Grants, grants...)
+	}
+	return parsed
+}
+
+// parseGrantStatement parses "GRANT <privileges> ON <scope> TO <user> [WITH GRANT OPTION]".
+// Role grants ("GRANT <role> TO <user>") are returned as role instead.
+func parseGrantStatement(stmt string) (grants []Grant, role string, ok bool) {
+	stmt = strings.TrimSpace(stmt)
+	if len(stmt) < 6 || !strings.EqualFold(stmt[:6], "GRANT ") {
+		return nil, "", false
+	}
+	rest := stmt[6:]
+	upper := strings.ToUpper(rest)
+
+	onIdx := indexOutsideQuotes(upper, " ON ")
+	if onIdx < 0 {
+		toIdx := indexOutsideQuotes(upper, " TO ")
+		if toIdx < 0 {
+			return nil, "", false
+		}
+		return nil, strings.TrimSpace(rest[:toIdx]), true
+	}
+
+	privileges := splitOutsideParens(rest[:onIdx])
+	afterOn := strings.TrimSpace(rest[onIdx+len(" ON "):])
+	scopeEnd := indexOutsideQuotes(afterOn, " ")
+	if scopeEnd < 0 {
+		return nil, "", false
+	}
+	scope := afterOn[:scopeEnd]
+	database, table := splitGrantScope(scope)
+	withGrant := strings.HasSuffix(strings.ToUpper(afterOn), "WITH GRANT OPTION")
+
+	grants = make([]Grant, 0, len(privileges))
+	for _, priv := range privileges {
+		grant := Grant{
+			Scope:           scope,
+			Database:        database,
+			Table:           table,
+			WithGrantOption: withGrant,
+		}
+		if open := strings.Index(priv, "("); open >= 0 && strings.HasSuffix(priv, ")") {
+			for _, col := range strings.Split(priv[open+1:len(priv)-1], ",") {
+				grant.Columns = append(grant.Columns, strings.Trim(strings.TrimSpace(col), "`"))
+			}
+			priv = priv[:open]
+		}
+		grant.Privilege = strings.ToUpper(strings.TrimSpace(priv))
+		grants = append(grants, grant)
+	}
+	return grants, "", true
+}
+
+// indexOutsideQuotes finds sep in s, ignoring occurrences inside backtick, single or double quotes.
+func indexOutsideQuotes(s, sep string) int {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '`' || c == '\'' || c == '"':
+			quote = c
+		case strings.HasPrefix(s[i:], sep):
+			return i
+		}
+	}
+	return -1
+}
+
+// splitOutsideParens splits a privilege list on commas that are not inside a column list.
+func splitOutsideParens(s string) []string {
+	parts := make([]string, 0)
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, strings.TrimSpace(s[start:]))
+}
+
+// splitGrantScope splits a scope such as `shop`.`orders` into its unquoted database and table parts.
+func splitGrantScope(scope string) (database, table string) {
+	dot := indexOutsideQuotes(scope, ".")
+	if dot < 0 {
+		return "*", unquoteIdentifier(scope)
+	}
+	return unquoteIdentifier(scope[:dot]), unquoteIdentifier(scope[dot+1:])
+}
+
+// unquoteIdentifier removes surrounding backticks and unescapes doubled backticks.
+func unquoteIdentifier(name string) string {
+	if len(name) >= 2 && name[0] == '`' && name[len(name)-1] == '`' {
+		return strings.ReplaceAll(name[1:len(name)-1], "``", "`")
+	}
+	return name
+}
+
+// isPermissionError reports whether err is a privilege check failure.
+func isPermissionError(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return false
+	}
+	switch mysqlErr.Number {
+	case 1044, 1045, 1142, 1143, 1227, 8121: // 8121 is TiDB's generic privilege check failure
+		return true
+	}
+	return false
+}
+
+// CanPerform probes whether the connected user can run an operation (select, insert, update or delete)
+// on a table. Reads run "SELECT 1 ... LIMIT 0"; writes run a statement matching no rows inside a
+// transaction that is always rolled back, so nothing is ever changed.
+func (s *DatabaseService) CanPerform(ctx context.Context, details ConnectionDetails, operation string, dbName string, tableName string) (bool, error) {
+	targetDB := dbName
+	if targetDB == "" {
+		targetDB = details.DBName
+	}
+	if targetDB == "" {
+		return false, fmt.Errorf("database name is required either explicitly or in connection details")
+	}
+	if tableName == "" {
+		return false, fmt.Errorf("table name is required")
+	}
+	table := quoteIdentifier(targetDB) + "." + quoteIdentifier(tableName)
+
+	var probe string
+	isRead := false
+	switch strings.ToLower(operation) {
+	case "select", "read":
+		probe = fmt.Sprintf("SELECT 1 FROM %s LIMIT 0", table)
+		isRead = true
+	case "insert":
+		probe = fmt.Sprintf("INSERT INTO %s SELECT * FROM %s WHERE FALSE", table, table)
+	case "update":
+		schema, err := s.GetTableSchema(ctx, details, targetDB, tableName)
+		if err != nil {
+			return false, err
+		}
+		if len(schema.Columns) == 0 {
+			return false, fmt.Errorf("table '%s.%s' not found", targetDB, tableName)
+		}
+		col := quoteIdentifier(schema.Columns[0].ColumnName)
+		probe = fmt.Sprintf("UPDATE %s SET %s = %s WHERE FALSE", table, col, col)
+	case "delete":
+		probe = fmt.Sprintf("DELETE FROM %s WHERE FALSE", table)
+	default:
+		return false, fmt.Errorf("unsupported operation '%s' (expected select, insert, update or delete)", operation)
+	}
+
+	db, err := s.getDB(details)
+	if err != nil {
+		return false, fmt.Errorf("connection setup failed for CanPerform: %w", err)
+	}
+
+	if isRead {
+		rows, err := db.QueryContext(ctx, probe)
+		if err != nil {
+			if isPermissionError(err) {
+				return false, nil
+			}
+			return false, fmt.Errorf("failed to probe %s on '%s.%s': %w", operation, targetDB, tableName, err)
+		}
+		rows.Close()
+		return true, nil
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin probe transaction: %w", err)
+	}
+	// The probe matches no rows, but never commit it regardless
+	defer func() {
+		if err := tx.Rollback(); err != nil {
+			LogWarning("Failed to roll back probe transaction: %v", err)
+		}
+	}()
+
+	if _, err := tx.ExecContext(ctx, probe); err != nil {
+		if isPermissionError(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to probe %s on '%s.%s': %w", operation, targetDB, tableName, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,293 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// DefaultImportBatchSize is the number of rows per INSERT when ImportOptions.BatchSize is 0
+	DefaultImportBatchSize = 500
+	// maxPlaceholders is the server's limit on parameters in a single prepared statement
+	maxPlaceholders = 65535
+	// maxImportRowErrors caps the row errors kept in an ImportResult; further failures are only counted
+	maxImportRowErrors = 1000
+)
+
+// Import error modes
+const (
+	ImportOnErrorAbort = "abort" // Roll back the whole import on the first failing row
+	ImportOnErrorSkip  = "skip"  // Skip failing rows and import the rest
+)
+
+// ImportOptions controls ImportCSV
+type ImportOptions struct {
+	Delimiter string `json:"delimiter,omitempty"` // Single character, defaults to a comma
+	HasHeader bool   `json:"hasHeader"`           // First record names the table columns; otherwise records follow table column order
+	BatchSize int    `json:"batchSize,omitempty"` // Rows per INSERT, defaults to DefaultImportBatchSize
+	OnError   string `json:"onError,omitempty"`   // ImportOnErrorAbort (default) or ImportOnErrorSkip
+	// OnProgress, if set, is called after each batch with the number of records read so far
+	OnProgress func(processed int64, result ImportResult) `json:"-"`
+}
+
+// RowError is a CSV record that couldn't be imported
+type RowError struct {
+	Row   int64  `json:"row"`           // 1-based record number in the file, counting the header
+	Line  int    `json:"line"`          // 1-based line in the file where the record starts
+	Raw   string `json:"raw,omitempty"` // The record re-encoded as CSV, empty if it couldn't be parsed
+	Error string `json:"error"`
+}
+
+// ImportResult summarizes an import
+type ImportResult struct {
+	Inserted int64      `json:"inserted"`
+	Skipped  int64      `json:"skipped"`
+	Errors   []RowError `json:"errors"`
+}
+
+// addError records a failed row, keeping at most maxImportRowErrors of them.
+func (r *ImportResult) addError(row importRow, err error) {
+	r.Skipped++
+	if len(r.Errors) < maxImportRowErrors {
+		r.Errors = append(r.Errors, RowError{Row: row.record, Line: row.line, Raw: row.raw(), Error: err.Error()})
+	}
+}
+
+// importRow is a parsed CSV record waiting to be inserted
+type importRow struct {
+	record int64
+	line   int
+	fields []string
+	delim  rune
+}
+
+// raw re-encodes the record as a CSV line.
+func (r importRow) raw() string {
+	if r.fields == nil {
+		return ""
+	}
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	w.Comma = r.delim
+	w.Write(r.fields)
+	w.Flush()
+	return strings.TrimRight(b.String(), "\r\n")
+}
+
+// values returns the record's fields as statement arguments.
+func (r importRow) values() []any {
+	values := make([]any, len(r.fields))
+	for i, field := range r.fields {
+		values[i] = field
+	}
+	return values
+}
+
+// ImportCSV loads CSV records into an existing table using batched, parameterized INSERT statements.
+// The file is read one record at a time, so its size isn't limited by memory. Header columns are
+// matched to the table's columns case-insensitively. In abort mode the import runs in a single
+// transaction and nothing is kept if any row fails; in skip mode each batch commits on its own and
+// failing records are reported in the result with their line and content.
+func (s *DatabaseService) ImportCSV(ctx context.Context, details ConnectionDetails, dbName string, tableName string, reader io.Reader, opts ImportOptions) (*ImportResult, error) {
+	targetDB := dbName
+	if targetDB == "" {
+		targetDB = details.DBName
+	}
+	if targetDB == "" {
+		return nil, fmt.Errorf("database name is required either explicitly or in connection details")
+	}
+	if tableName == "" {
+		return nil, fmt.Errorf("table name is required")
+	}
+	switch opts.OnError {
+	case "":
+		opts.OnError = ImportOnErrorAbort
+	case ImportOnErrorAbort, ImportOnErrorSkip:
+	default:
+		return nil, fmt.Errorf("unsupported error mode '%s' (expected abort or skip)", opts.OnError)
+	}
+
+	in := csv.NewReader(reader)
+	in.FieldsPerRecord = -1 // Checked per record so a bad row can be skipped
+	if opts.Delimiter != "" {
+		delimiter, size := utf8.DecodeRuneInString(opts.Delimiter)
+		if size != len(opts.Delimiter) {
+			return nil, fmt.Errorf("CSV delimiter must be a single character, got %q", opts.Delimiter)
+		}
+		in.Comma = delimiter
+	}
+
+	schema, err := s.GetTableSchema(ctx, details, targetDB, tableName)
+	if err != nil {
+		return nil, err
+	}
+	if len(schema.Columns) == 0 {
+		return nil, fmt.Errorf("table '%s.%s' not found", targetDB, tableName)
+	}
+	tableColumns := make(map[string]string, len(schema.Columns))
+	columns := make([]string, 0, len(schema.Columns))
+	for _, col := range schema.Columns {
+		tableColumns[strings.ToLower(col.ColumnName)] = col.ColumnName
+		columns = append(columns, col.ColumnName)
+	}
+
+	var record int64
+	if opts.HasHeader {
+		header, err := in.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("CSV file is empty")
+			}
+			return nil, fmt.Errorf("failed to read CSV header: %w", err)
+		}
+		record++
+		columns = make([]string, 0, len(header))
+		seen := make(map[string]bool, len(header))
+		for _, name := range header {
+			column, ok := tableColumns[strings.ToLower(strings.TrimSpace(name))]
+			if !ok {
+				return nil, fmt.Errorf("CSV column '%s' does not exist in table '%s.%s'", name, targetDB, tableName)
+			}
+			if seen[column] {
+				return nil, fmt.Errorf("CSV column '%s' appears more than once", name)
+			}
+			seen[column] = true
+			columns = append(columns, column)
+		}
+	}
+
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultImportBatchSize
+	}
+	batchSize = min(batchSize, maxPlaceholders/len(columns))
+
+	db, err := s.getDB(details)
+	if err != nil {
+		return nil, fmt.Errorf("connection setup failed for ImportCSV: %w", err)
+	}
+	var runner sqlRunner = db
+	var tx *sql.Tx
+	abort := opts.OnError == ImportOnErrorAbort
+	if abort {
+		tx, err = db.BeginTx(ctx, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to begin import transaction: %w", err)
+		}
+		defer tx.Rollback() // No-op after a successful commit
+		runner = tx
+	}
+
+	quotedColumns := make([]string, len(columns))
+	for i, col := range columns {
+		quotedColumns[i] = quoteIdentifier(col)
+	}
+	insertPrefix := fmt.Sprintf("INSERT INTO %s.%s (%s) VALUES ",
+		quoteIdentifier(targetDB), quoteIdentifier(tableName), strings.Join(quotedColumns, ", "))
+	rowPlaceholders := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ") + ")"
+
+	insert := func(rows []importRow) error {
+		placeholders := make([]string, len(rows))
+		args := make([]any, 0, len(rows)*len(columns))
+		for i, row := range rows {
+			placeholders[i] = rowPlaceholders
+			args = append(args, row.values()...)
+		}
+		_, err := runner.ExecContext(ctx, insertPrefix+strings.Join(placeholders, ", "), args...)
+		return err
+	}
+
+	result := &ImportResult{Errors: make([]RowError, 0)}
+	// flush inserts a batch. In skip mode a failed batch is retried row by row to find the bad rows.
+	flush := func(batch []importRow) error {
+		if len(batch) == 0 {
+			return nil
+		}
+		err := insert(batch)
+		if err == nil {
+			result.Inserted += int64(len(batch))
+			return nil
+		}
+		if abort {
+			return fmt.Errorf("failed to import records %d-%d: %w", batch[0].record, batch[len(batch)-1].record, err)
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		for _, row := range batch {
+			if err := insert([]importRow{row}); err != nil {
+				result.addError(row, err)
+				continue
+			}
+			result.Inserted++
+		}
+		return nil
+	}
+
+	var processed int64
+	progress := func() {
+		if opts.OnProgress != nil {
+			opts.OnProgress(processed, *result)
+		}
+	}
+
+	batch := make([]importRow, 0, batchSize)
+	for {
+		fields, err := in.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		record++
+		processed++
+		row := importRow{record: record, fields: fields, delim: in.Comma}
+		row.line, _ = in.FieldPos(0)
+
+		var recordErr error
+		var parseErr *csv.ParseError
+		if err != nil {
+			if !errors.As(err, &parseErr) {
+				return nil, fmt.Errorf("failed to read CSV: %w", err)
+			}
+			row.line = parseErr.StartLine
+			row.fields = nil
+			recordErr = err
+		} else if len(fields) != len(columns) {
+			recordErr = fmt.Errorf("expected %d fields, got %d", len(columns), len(fields))
+		}
+		if recordErr != nil {
+			if abort {
+				return nil, fmt.Errorf("invalid CSV record %d (line %d): %w", record, row.line, recordErr)
+			}
+			result.addError(row, recordErr)
+			continue
+		}
+
+		batch = append(batch, row)
+		if len(batch) == batchSize {
+			if err := flush(batch); err != nil {
+				return nil, err
+			}
+			batch = batch[:0]
+			progress()
+		}
+	}
+	if err := flush(batch); err != nil {
+		return nil, err
+	}
+
+	if tx != nil {
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("failed to commit import: %w", err)
+		}
+	}
+
+	progress()
+	LogInfo("Imported %d rows into %s.%s (%d skipped)", result.Inserted, targetDB, tableName, result.Skipped)
+	return result, nil
+}
@@ -0,0 +1,243 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+)
+
+const (
+	// indexEntryOverheadBytes approximates the per-entry key prefix and row handle of an index entry
+	indexEntryOverheadBytes = 32
+	// largeTableRows and largeTableBytes mark tables where building an index takes noticeable time
+	largeTableRows  int64 = 10_000_000
+	largeTableBytes int64 = 10 << 30
+	// lowSelectivityRatio is the distinct/rows ratio below which a leading index column is flagged
+	lowSelectivityRatio = 0.01
+)
+
+// IndexColumnEstimate holds the statistics used to size one column of a proposed index
+type IndexColumnEstimate struct {
+	Name          string   `json:"name"`
+	DataType      string   `json:"dataType"`
+	AvgWidthBytes float64  `json:"avgWidthBytes"`
+	FromStats     bool     `json:"fromStats"`               // False when the width is guessed from the type
+	DistinctCount *int64   `json:"distinctCount,omitempty"` // nil without statistics
+	Selectivity   *float64 `json:"selectivity,omitempty"`   // Distinct values per row, nil without statistics
+}
+
+// IndexImpact is an advisory estimate of the cost of adding an index to a table
+type IndexImpact struct {
+	TableRows               int64                 `json:"tableRows"`
+	DataSizeBytes           int64                 `json:"dataSizeBytes"`
+	IndexSizeBytes          int64                 `json:"indexSizeBytes"` // Size of the existing indexes
+	ExistingIndexes         int                   `json:"existingIndexes"`
+	Columns                 []IndexColumnEstimate `json:"columns"`
+	EntryWidthBytes         float64               `json:"entryWidthBytes"`
+	EstimatedIndexSizeBytes int64                 `json:"estimatedIndexSizeBytes"`
+	WritesPerRowBefore      int                   `json:"writesPerRowBefore"` // Row plus index entries written per inserted row
+	WritesPerRowAfter       int                   `json:"writesPerRowAfter"`
+	ModifyCount             *int64                `json:"modifyCount,omitempty"` // Rows modified since statistics were last collected
+	Warnings                []string              `json:"warnings"`
+}
+
+// EstimateIndexImpact estimates the size and write amplification of an index on columns of a table,
+// from information_schema and, when available, TiDB statistics. Nothing is changed on the server.
+func (s *DatabaseService) EstimateIndexImpact(ctx context.Context, details ConnectionDetails, dbName string, tableName string, columns []string) (*IndexImpact, error) {
+	targetDB := dbName
+	if targetDB == "" {
+		targetDB = details.DBName
+	}
+	if targetDB == "" {
+		return nil, fmt.Errorf("database name is required either explicitly or in connection details")
+	}
+	if tableName == "" {
+		return nil, fmt.Errorf("table name is required")
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("at least one index column is required")
+	}
+
+	tableResult, err := s.ExecuteSQL(ctx, details, `
+		SELECT TABLE_ROWS, DATA_LENGTH, INDEX_LENGTH
+		FROM information_schema.TABLES
+		WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?`, targetDB, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get table statistics: %w", err)
+	}
+	if len(tableResult.Rows) == 0 {
+		return nil, fmt.Errorf("table '%s.%s' not found", targetDB, tableName)
+	}
+
+	impact := &IndexImpact{
+		Columns:  make([]IndexColumnEstimate, 0, len(columns)),
+		Warnings: make([]string, 0),
+	}
+	if v, ok := valueFloat(tableResult.Rows[0]["TABLE_ROWS"]); ok {
+		impact.TableRows = int64(v)
+	}
+	if v, ok := valueFloat(tableResult.Rows[0]["DATA_LENGTH"]); ok {
+		impact.DataSizeBytes = int64(v)
+	}
+	if v, ok := valueFloat(tableResult.Rows[0]["INDEX_LENGTH"]); ok {
+		impact.IndexSizeBytes = int64(v)
+	}
+
+	indexResult, err := s.ExecuteSQL(ctx, details, `
+		SELECT COUNT(DISTINCT INDEX_NAME) AS index_count
+		FROM information_schema.STATISTICS
+		WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? AND INDEX_NAME <> 'PRIMARY'`, targetDB, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count existing indexes: %w", err)
+	}
+	if len(indexResult.Rows) > 0 {
+		if v, ok := valueFloat(indexResult.Rows[0]["index_count"]); ok {
+			impact.ExistingIndexes = int(v)
+		}
+	}
+
+	columnResult, err := s.ExecuteSQL(ctx, details, `
+		SELECT COLUMN_NAME, DATA_TYPE, CHARACTER_OCTET_LENGTH, NUMERIC_PRECISION
+		FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?`, targetDB, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get column types: %w", err)
+	}
+	columnTypes := make(map[string]map[string]any, len(columnResult.Rows))
+	for _, row := range columnResult.Rows {
+		columnTypes[strings.ToLower(valueString(row["COLUMN_NAME"]))] = row
+	}
+
+	histograms := s.columnHistograms(ctx, details, targetDB, tableName)
+	impact.ModifyCount = s.tableModifyCount(ctx, details, targetDB, tableName)
+
+	for _, name := range columns {
+		row, ok := columnTypes[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("column '%s' not found in table '%s.%s'", name, targetDB, tableName)
+		}
+		estimate := IndexColumnEstimate{
+			Name:          valueString(row["COLUMN_NAME"]),
+			DataType:      strings.ToLower(valueString(row["DATA_TYPE"])),
+			AvgWidthBytes: typeWidthBytes(row),
+		}
+		if hist, ok := histograms[strings.ToLower(name)]; ok {
+			if hist.avgColSize > 0 {
+				estimate.AvgWidthBytes = hist.avgColSize
+				estimate.FromStats = true
+			}
+			distinct := hist.distinctCount
+			estimate.DistinctCount = &distinct
+			if impact.TableRows > 0 {
+				selectivity := float64(distinct) / float64(impact.TableRows)
+				estimate.Selectivity = &selectivity
+			}
+		}
+		impact.EntryWidthBytes += estimate.AvgWidthBytes
+		impact.Columns = append(impact.Columns, estimate)
+	}
+
+	impact.EntryWidthBytes += indexEntryOverheadBytes
+	impact.EstimatedIndexSizeBytes = int64(math.Ceil(impact.EntryWidthBytes * float64(impact.TableRows)))
+	impact.WritesPerRowBefore = 1 + impact.ExistingIndexes
+	impact.WritesPerRowAfter = impact.WritesPerRowBefore + 1
+
+	if impact.TableRows >= largeTableRows || impact.DataSizeBytes >= largeTableBytes {
+		impact.Warnings = append(impact.Warnings, fmt.Sprintf(
+			"Table is large (~%d rows, %d bytes); building the index will take a while and consume cluster resources",
+			impact.TableRows, impact.DataSizeBytes))
+	}
+	if impact.ModifyCount != nil && impact.TableRows > 0 && *impact.ModifyCount > impact.TableRows/2 {
+		impact.Warnings = append(impact.Warnings, fmt.Sprintf(
+			"Table is write-heavy (%d rows modified since statistics were collected); every write will also update the new index",
+			*impact.ModifyCount))
+	}
+	if impact.ExistingIndexes >= 5 {
+		impact.Warnings = append(impact.Warnings, fmt.Sprintf(
+			"Table already has %d secondary indexes; each write will touch %d index entries after this change",
+			impact.ExistingIndexes, impact.ExistingIndexes+1))
+	}
+	if lead := impact.Columns[0]; lead.Selectivity != nil && *lead.Selectivity < lowSelectivityRatio {
+		impact.Warnings = append(impact.Warnings, fmt.Sprintf(
+			"Leading column '%s' has low selectivity (%d distinct values); the index may rarely be used",
+			lead.Name, *lead.DistinctCount))
+	}
+
+	return impact, nil
+}
+
+// columnHistogram is the subset of TiDB column statistics used for estimates
+type columnHistogram struct {
+	distinctCount int64
+	avgColSize    float64
+}
+
+// columnHistograms reads TiDB's column statistics of a table. The result is empty on servers without
+// them or for tables that were never analyzed.
+func (s *DatabaseService) columnHistograms(ctx context.Context, details ConnectionDetails, dbName, tableName string) map[string]columnHistogram {
+	histograms := make(map[string]columnHistogram)
+	query := fmt.Sprintf("SHOW STATS_HISTOGRAMS WHERE Db_name = %s AND Table_name = %s AND Is_index = 0",
+		sqlQuoteValue(dbName), sqlQuoteValue(tableName))
+	result, err := s.ExecuteSQL(ctx, details, query)
+	if err != nil {
+		LogDebug("Column statistics unavailable for %s.%s: %v", dbName, tableName, err)
+		return histograms
+	}
+	for _, row := range result.Rows {
+		var hist columnHistogram
+		if v, ok := valueFloat(row["Distinct_count"]); ok {
+			hist.distinctCount = int64(v)
+		}
+		if v, ok := valueFloat(row["Avg_col_size"]); ok {
+			hist.avgColSize = v
+		}
+		histograms[strings.ToLower(valueString(row["Column_name"]))] = hist
+	}
+	return histograms
+}
+
+// tableModifyCount returns how many rows TiDB counted as modified since the table was last analyzed,
+// or nil if unknown.
+func (s *DatabaseService) tableModifyCount(ctx context.Context, details ConnectionDetails, dbName, tableName string) *int64 {
+	query := fmt.Sprintf("SHOW STATS_META WHERE Db_name = %s AND Table_name = %s",
+		sqlQuoteValue(dbName), sqlQuoteValue(tableName))
+	result, err := s.ExecuteSQL(ctx, details, query)
+	if err != nil || len(result.Rows) == 0 {
+		return nil
+	}
+	v, ok := valueFloat(result.Rows[0]["Modify_count"])
+	if !ok {
+		return nil
+	}
+	count := int64(v)
+	return &count
+}
+
+// typeWidthBytes guesses the average stored width of a column from its type.
+func typeWidthBytes(column map[string]any) float64 {
+	switch strings.ToLower(valueString(column["DATA_TYPE"])) {
+	case "tinyint", "bool", "boolean", "year":
+		return 1
+	case "smallint":
+		return 2
+	case "mediumint", "date", "time":
+		return 3
+	case "int", "integer", "float":
+		return 4
+	case "bigint", "double", "datetime", "timestamp", "bit":
+		return 8
+	case "decimal", "numeric":
+		if precision, ok := valueFloat(column["NUMERIC_PRECISION"]); ok {
+			return math.Ceil(precision/2) + 1
+		}
+		return 8
+	case "enum", "set":
+		return 2
+	}
+	// Variable-length types: assume values use about half their declared maximum, capped like a prefix index
+	if octets, ok := valueFloat(column["CHARACTER_OCTET_LENGTH"]); ok && octets > 0 {
+		return math.Min(octets/2, 256)
+	}
+	return 256
+}
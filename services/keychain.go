@@ -0,0 +1,158 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/zalando/go-keyring"
+)
+
+// Where connection passwords are saved, see ConfigData.PasswordStorage
+const (
+	PasswordStorageFile     = "file"     // In config.json
+	PasswordStorageKeychain = "keychain" // In the OS keychain, with config.json holding no passwords
+)
+
+// keychainService is the service name passwords are stored under in the OS keychain
+const keychainService = "tidb-desktop"
+
+// keychainAccounts returns the keychain accounts of a connection's database and SSH tunnel passwords.
+func keychainAccounts(connectionID string) (password, sshPassword string) {
+	return connectionID, connectionID + "/ssh"
+}
+
+// storeKeychainSecret saves a secret in the keychain, or removes the entry when secret is empty.
+func storeKeychainSecret(account, secret string) error {
+	if secret == "" {
+		return deleteKeychainSecret(account)
+	}
+	return keyring.Set(keychainService, account, secret)
+}
+
+// loadKeychainSecret returns a secret from the keychain, or an empty string if there is none.
+func loadKeychainSecret(account string) (string, error) {
+	secret, err := keyring.Get(keychainService, account)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", nil
+	}
+	return secret, err
+}
+
+// deleteKeychainSecret removes a secret from the keychain, ignoring entries that don't exist.
+func deleteKeychainSecret(account string) error {
+	if err := keyring.Delete(keychainService, account); err != nil && !errors.Is(err, keyring.ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// storeConnectionSecrets moves a connection's passwords into the keychain and records that they are there,
+// so saveConfig leaves them out of the file. On failure the passwords stay in the file.
+// Callers must hold s.mu for writing.
+func (s *ConfigService) storeConnectionSecrets(connectionID string, details ConnectionDetails) {
+	passwordAccount, sshAccount := keychainAccounts(connectionID)
+	sshPassword := ""
+	if details.SSHTunnel != nil {
+		sshPassword = details.SSHTunnel.Password
+	}
+	if err := storeKeychainSecret(passwordAccount, details.Password); err != nil {
+		LogWarning("Keychain unavailable, keeping password of connection %s in the config file: %v", connectionID, err)
+		delete(s.inKeychain, connectionID)
+		return
+	}
+	if err := storeKeychainSecret(sshAccount, sshPassword); err != nil {
+		LogWarning("Keychain unavailable, keeping password of connection %s in the config file: %v", connectionID, err)
+		delete(s.inKeychain, connectionID)
+		return
+	}
+	s.inKeychain[connectionID] = true
+}
+
+// loadConnectionSecrets fills in the passwords of a connection saved in the keychain.
+// Callers must hold s.mu for writing.
+func (s *ConfigService) loadConnectionSecrets(connectionID string, details *ConnectionDetails) {
+	passwordAccount, sshAccount := keychainAccounts(connectionID)
+	password, err := loadKeychainSecret(passwordAccount)
+	if err != nil {
+		LogWarning("Failed to read password of connection %s from the keychain: %v", connectionID, err)
+		return
+	}
+	if password != "" && details.Password == "" {
+		details.Password = password
+	}
+	if details.SSHTunnel != nil && details.SSHTunnel.Password == "" {
+		sshPassword, err := loadKeychainSecret(sshAccount)
+		if err != nil {
+			LogWarning("Failed to read SSH password of connection %s from the keychain: %v", connectionID, err)
+			return
+		}
+		tunnel := *details.SSHTunnel
+		tunnel.Password = sshPassword
+		details.SSHTunnel = &tunnel
+	}
+	s.inKeychain[connectionID] = true
+}
+
+// deleteConnectionSecrets removes a connection's passwords from the keychain.
+// Callers must hold s.mu for writing.
+func (s *ConfigService) deleteConnectionSecrets(connectionID string) {
+	if !s.inKeychain[connectionID] {
+		return
+	}
+	passwordAccount, sshAccount := keychainAccounts(connectionID)
+	for _, account := range []string{passwordAccount, sshAccount} {
+		if err := deleteKeychainSecret(account); err != nil {
+			LogWarning("Failed to remove password of connection %s from the keychain: %v", connectionID, err)
+		}
+	}
+	delete(s.inKeychain, connectionID)
+}
+
+// usesKeychain reports whether passwords are configured to be kept in the OS keychain.
+func (s *ConfigService) usesKeychain() bool {
+	return s.config.PasswordStorage == PasswordStorageKeychain
+}
+
+// GetPasswordStorage returns where connection passwords are saved, PasswordStorageFile or PasswordStorageKeychain.
+func (s *ConfigService) GetPasswordStorage() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.usesKeychain() {
+		return PasswordStorageKeychain
+	}
+	return PasswordStorageFile
+}
+
+// SetPasswordStorage moves all saved connection passwords to the keychain or back into the config file.
+// Connections whose password can't be stored in the keychain keep it in the file.
+func (s *ConfigService) SetPasswordStorage(storage string) error {
+	if storage != PasswordStorageFile && storage != PasswordStorageKeychain {
+		return errors.New("password storage must be \"file\" or \"keychain\"")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.config.PasswordStorage = storage
+	if storage == PasswordStorageKeychain {
+		for id, details := range s.config.Connections {
+			s.storeConnectionSecrets(id, details)
+		}
+		return s.saveConfig()
+	}
+
+	// Write the passwords to the file before removing them from the keychain
+	keychainIDs := make([]string, 0, len(s.inKeychain))
+	for id := range s.inKeychain {
+		keychainIDs = append(keychainIDs, id)
+	}
+	clear(s.inKeychain)
+	if err := s.saveConfig(); err != nil {
+		return err
+	}
+	for _, id := range keychainIDs {
+		s.inKeychain[id] = true
+		s.deleteConnectionSecrets(id)
+	}
+	return nil
+}
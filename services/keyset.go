@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// KeysetPageResponse holds one page of a keyset-paginated table scan
+type KeysetPageResponse struct {
+	Columns    []TableColumn    `json:"columns"`
+	Rows       []map[string]any `json:"rows"`
+	PKColumn   string           `json:"pkColumn"`
+	NextCursor any              `json:"nextCursor,omitempty"` // Pass as afterValue to fetch the next page
+	HasMore    bool             `json:"hasMore"`
+}
+
+// GetTableDataKeyset returns up to limit rows ordered by a single-column primary key, starting after
+// afterValue (from the first row when nil). Unlike OFFSET paging, each page costs the same regardless of
+// how deep into the table it is. If pkColumn is empty the table's primary key is used, which must consist
+// of exactly one column.
+func (s *DatabaseService) GetTableDataKeyset(ctx context.Context, details ConnectionDetails, dbName string, tableName string, pkColumn string, afterValue any, limit int) (*KeysetPageResponse, error) {
+	targetDB := dbName
+	if targetDB == "" {
+		targetDB = details.DBName
+	}
+	if targetDB == "" {
+		return nil, fmt.Errorf("database name is required either explicitly or in connection details")
+	}
+	if tableName == "" {
+		return nil, fmt.Errorf("table name is required")
+	}
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	if limit > MaxPageSize {
+		limit = MaxPageSize
+	}
+
+	schema, err := s.GetTableSchema(ctx, details, targetDB, tableName)
+	if err != nil {
+		return nil, err
+	}
+	if len(schema.Columns) == 0 {
+		return nil, fmt.Errorf("table '%s.%s' not found", targetDB, tableName)
+	}
+
+	if pkColumn == "" {
+		rowKey, err := s.ResolveRowKey(ctx, details, targetDB, tableName)
+		if err != nil {
+			return nil, err
+		}
+		if len(rowKey.Columns) != 1 {
+			return nil, fmt.Errorf("table '%s.%s' has no single-column row key (found %d key columns), specify a key column",
+				targetDB, tableName, len(rowKey.Columns))
+		}
+		pkColumn = rowKey.Columns[0]
+	}
+
+	columns := make([]TableColumn, 0, len(schema.Columns))
+	found := false
+	for i, col := range schema.Columns {
+		columns = append(columns, TableColumn{
+			Name:      col.ColumnName,
+			Type:      col.ColumnType,
+			Ordinal:   i + 1,
+			Charset:   col.CharacterSetName.String,
+			Collation: col.CollationName.String,
+			Comment:   col.ColumnComment,
+		})
+		if strings.EqualFold(col.ColumnName, pkColumn) {
+			pkColumn = col.ColumnName
+			found = true
+		}
+	}
+	selectList := "*"
+	if !found {
+		if !strings.EqualFold(pkColumn, TiDBRowIDColumn) {
+			return nil, fmt.Errorf("column '%s' not found in table '%s.%s'", pkColumn, targetDB, tableName)
+		}
+		// The hidden row ID isn't part of *, but rows need it for the cursor
+		pkColumn = TiDBRowIDColumn
+		selectList = "*, " + quoteIdentifier(TiDBRowIDColumn)
+	}
+
+	pk := quoteIdentifier(pkColumn)
+	query := fmt.Sprintf("SELECT %s FROM %s.%s", selectList, quoteIdentifier(targetDB), quoteIdentifier(tableName))
+	var args []any
+	if afterValue != nil {
+		query += fmt.Sprintf(" WHERE %s > ?", pk)
+		args = append(args, afterValue)
+	}
+	// Fetch one extra row to know whether another page follows
+	query += fmt.Sprintf(" ORDER BY %s LIMIT %d", pk, limit+1)
+
+	result, err := s.ExecuteSQL(ctx, details, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch data for table '%s.%s': %w", targetDB, tableName, err)
+	}
+
+	resp := &KeysetPageResponse{
+		Columns:  columns,
+		Rows:     result.Rows,
+		PKColumn: pkColumn,
+	}
+	if resp.Rows == nil {
+		resp.Rows = []map[string]any{}
+	}
+	if len(resp.Rows) > limit {
+		resp.Rows = resp.Rows[:limit]
+		resp.HasMore = true
+	}
+	if len(resp.Rows) > 0 {
+		resp.NextCursor = resp.Rows[len(resp.Rows)-1][pkColumn]
+	}
+
+	LogInfo("Retrieved %d rows from %s.%s after %v (keyset on %s)", len(resp.Rows), targetDB, tableName, afterValue, pkColumn)
+	return resp, nil
+}
@@ -6,6 +6,8 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 
 	"github.com/wailsapp/wails/v2/pkg/logger"
 )
@@ -18,19 +20,63 @@ type appLogger struct {
 // Global logger instance
 var GlobalLogger logger.Logger
 
+// LogFileName is the name of the log file in the config directory
+const LogFileName = "tidb-desktop.log"
+
+// secretPatterns match secrets that can show up in log messages. The secret itself is replaced while
+// the capture groups around it are kept.
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)(IDENTIFIED\s+(?:WITH\s+\S+\s+)?(?:BY|AS)\s+)(?:'(?:[^'\\]|\\.|'')*'|"(?:[^"\\]|\\.)*")`),
+	regexp.MustCompile(`(?i)(SET\s+PASSWORD\b[^=\n]*=\s*(?:PASSWORD\s*\(\s*)?)'(?:[^'\\]|\\.|'')*'`),
+	regexp.MustCompile(`(?i)(PASSWORD\s*\(\s*)'(?:[^'\\]|\\.|'')*'`),
+	regexp.MustCompile(`(?i)("(?:password|apiKey|api_key|token|secret)"\s*:\s*)"(?:[^"\\]|\\.)*"`),
+	regexp.MustCompile(`([\w.%+-]+:)[^@\s/]+(@(?:tcp|unix)\()`), // user:password@tcp(host) DSNs
+	regexp.MustCompile(`()sk-[A-Za-z0-9_-]{16,}`),               // OpenAI, Anthropic and OpenRouter keys
+}
+
+// redacted replaces scrubbed secrets
+const redacted = "[REDACTED]"
+
+// ScrubSecrets redacts passwords, API keys and DSN credentials from text, along with every literal
+// occurrence of the given secrets (e.g. the saved connection passwords).
+func ScrubSecrets(text string, secrets ...string) string {
+	for _, secret := range secrets {
+		if len(secret) >= 3 { // Very short secrets would redact unrelated text
+			text = strings.ReplaceAll(text, secret, redacted)
+		}
+	}
+	for _, pattern := range secretPatterns {
+		text = pattern.ReplaceAllStringFunc(text, func(match string) string {
+			groups := pattern.FindStringSubmatch(match)
+			if len(groups) > 2 {
+				return groups[1] + redacted + groups[2]
+			}
+			return groups[1] + redacted
+		})
+	}
+	return text
+}
+
+// LogFilePath returns the path of the application log file.
+func LogFilePath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %v", err)
+	}
+	return filepath.Join(homeDir, ConfigDirName, LogFileName), nil
+}
+
 // InitLogger initializes the application logger.
 func InitLogger() error {
-	homeDir, err := os.UserHomeDir()
+	logFile, err := LogFilePath()
 	if err != nil {
-		return fmt.Errorf("failed to get user home directory: %v", err)
+		return err
 	}
 
-	logDir := filepath.Join(homeDir, ConfigDirName)
-	if err := os.MkdirAll(logDir, 0750); err != nil {
+	if err := os.MkdirAll(filepath.Dir(logFile), 0750); err != nil {
 		return fmt.Errorf("failed to create log directory: %v", err)
 	}
 
-	logFile := filepath.Join(logDir, "tidb-desktop.log")
 	f, err := os.OpenFile(logFile, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
 	if err != nil {
 		return fmt.Errorf("failed to open log file: %v", err)
@@ -43,31 +89,31 @@ func InitLogger() error {
 }
 
 func (l *appLogger) Print(message string) {
-	l.logger.Print(message)
+	l.logger.Print(ScrubSecrets(message))
 }
 
 func (l *appLogger) Trace(message string) {
-	l.logger.Printf("TRACE: %s", message)
+	l.logger.Printf("TRACE: %s", ScrubSecrets(message))
 }
 
 func (l *appLogger) Debug(message string) {
-	l.logger.Printf("DEBUG: %s", message)
+	l.logger.Printf("DEBUG: %s", ScrubSecrets(message))
 }
 
 func (l *appLogger) Info(message string) {
-	l.logger.Printf("INFO: %s", message)
+	l.logger.Printf("INFO: %s", ScrubSecrets(message))
 }
 
 func (l *appLogger) Warning(message string) {
-	l.logger.Printf("WARNING: %s", message)
+	l.logger.Printf("WARNING: %s", ScrubSecrets(message))
 }
 
 func (l *appLogger) Error(message string) {
-	l.logger.Printf("ERROR: %s", message)
+	l.logger.Printf("ERROR: %s", ScrubSecrets(message))
 }
 
 func (l *appLogger) Fatal(message string) {
-	l.logger.Fatalf("FATAL: %s", message)
+	l.logger.Fatalf("FATAL: %s", ScrubSecrets(message))
 }
 
 func LogInfo(format string, v ...interface{}) {
@@ -1,16 +1,25 @@
 package services
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"maps"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// StaleMetadataThreshold is the default age after which cached metadata is considered stale,
+// see ConnectionDetails.MetadataStaleMinutes
+const StaleMetadataThreshold = 24 * time.Hour
+
 // Column represents a database column's metadata
 type Column struct {
 	Name          string `json:"name"`
@@ -19,6 +28,7 @@ type Column struct {
 	DefaultValue  any    `json:"defaultValue,omitempty"`
 	IsPrimaryKey  bool   `json:"isPrimaryKey"`
 	AutoIncrement bool   `json:"autoIncrement"`
+	Collation     string `json:"collation,omitempty"`     // Column collation, empty for non-string types
 	DBComment     string `json:"dbComment,omitempty"`     // Comment from database
 	AIDescription string `json:"aiDescription,omitempty"` // Description from AI
 }
@@ -44,17 +54,30 @@ type Table struct {
 	Columns       []Column     `json:"columns"`
 	ForeignKeys   []ForeignKey `json:"foreignKeys,omitempty"`
 	Indexes       []Index      `json:"indexes,omitempty"`
+	Collation     string       `json:"collation,omitempty"`     // Default collation of the table
+	CreateTime    *time.Time   `json:"createTime,omitempty"`    // nil when the server doesn't report it
+	UpdateTime    *time.Time   `json:"updateTime,omitempty"`    // nil when the server doesn't report it
 	DBComment     string       `json:"dbComment,omitempty"`     // Comment from database
 	AIDescription string       `json:"aiDescription,omitempty"` // Description from AI
+	// IsView marks views, which are listed alongside base tables but have no keys or indexes of their own
+	IsView         bool   `json:"isView,omitempty"`
+	ViewDefinition string `json:"viewDefinition,omitempty"` // SELECT the view is defined as
+	// Physical stats from information_schema.TABLES, estimates on TiDB
+	Engine        string `json:"engine,omitempty"`
+	EstimatedRows int64  `json:"estimatedRows,omitempty"`
+	DataLength    int64  `json:"dataLength,omitempty"`  // Bytes
+	IndexLength   int64  `json:"indexLength,omitempty"` // Bytes
 }
 
 // DatabaseMetadata represents the metadata for a single database
 type DatabaseMetadata struct {
-	Name          string            `json:"name"`
-	Tables        []Table           `json:"tables"`
-	Graph         map[string][]Edge `json:"graph,omitempty"`         // Adjacency list representation
-	DBComment     string            `json:"dbComment,omitempty"`     // Comment from database
-	AIDescription string            `json:"aiDescription,omitempty"` // Description from AI
+	Name             string            `json:"name"`
+	Tables           []Table           `json:"tables"`
+	Sequences        []Sequence        `json:"sequences,omitempty"`        // TiDB sequences, not part of Tables or Graph
+	Graph            map[string][]Edge `json:"graph,omitempty"`            // Adjacency list representation
+	DefaultCollation string            `json:"defaultCollation,omitempty"` // Default collation of the database
+	DBComment        string            `json:"dbComment,omitempty"`        // Comment from database
+	AIDescription    string            `json:"aiDescription,omitempty"`    // Description from AI
 }
 
 // ConnectionMetadata represents the complete metadata for a connection
@@ -63,14 +86,26 @@ type ConnectionMetadata struct {
 	ConnectionName string                      `json:"connectionName"` // Display name
 	LastExtracted  time.Time                   `json:"lastExtracted"`
 	Version        string                      `json:"version,omitempty"` // Database version
+	Stale          bool                        `json:"stale,omitempty"`   // Set when served from an outdated cache
 	Databases      map[string]DatabaseMetadata `json:"databases"`
 }
 
+// isStaleAt reports whether the metadata is older than threshold at now. A zero threshold never goes stale.
+func (m *ConnectionMetadata) isStaleAt(threshold time.Duration, now time.Time) bool {
+	if threshold <= 0 {
+		return false
+	}
+	return m.LastExtracted.IsZero() || now.Sub(m.LastExtracted) > threshold
+}
+
 // Edge represents a relationship between tables in the graph
 type Edge struct {
 	ToTable    string `json:"toTable"`
-	FromColumn string `json:"fromColumn"`
-	ToColumn   string `json:"toColumn"`
+	FromColumn string `json:"fromColumn"` // First of FromColumns, kept for older readers
+	ToColumn   string `json:"toColumn"`   // First of ToColumns, kept for older readers
+	// Every column pair of the foreign key, in order, so composite keys are complete
+	FromColumns []string `json:"fromColumns,omitempty"`
+	ToColumns   []string `json:"toColumns,omitempty"`
 }
 
 // MetadataService handles database metadata operations
@@ -81,6 +116,27 @@ type MetadataService struct {
 	// Simple in-memory storage per connection
 	metadata map[string]*ConnectionMetadata
 	mu       sync.RWMutex
+	// In-flight loads and extractions, so concurrent requests for the same work share one run
+	inflight   map[string]*metadataCall
+	inflightMu sync.Mutex
+	now        func() time.Time // Clock for staleness checks
+	// OnExtractionProgress is called as each table finishes extracting, possibly from several goroutines at once
+	OnExtractionProgress func(progress ExtractionProgress)
+}
+
+// ExtractionProgress reports how far extraction of one database has got
+type ExtractionProgress struct {
+	ConnectionID string `json:"connectionId"`
+	Database     string `json:"database"`
+	TablesDone   int    `json:"tablesDone"`
+	TablesTotal  int    `json:"tablesTotal"`
+}
+
+// metadataCall is a load or extraction in progress, shared by every caller requesting the same work
+type metadataCall struct {
+	done     chan struct{}
+	metadata *ConnectionMetadata
+	err      error
 }
 
 // DescriptionTarget for updating AI descriptions
@@ -107,9 +163,65 @@ func NewMetadataService(configService *ConfigService, dbService *DatabaseService
 		dbService:     dbService,
 		metadataDir:   metadataDir,
 		metadata:      make(map[string]*ConnectionMetadata),
+		inflight:      make(map[string]*metadataCall),
+		now:           time.Now,
 	}, nil
 }
 
+// StaleThreshold returns the age after which a connection's metadata is stale, 0 if it never is.
+func (s *MetadataService) StaleThreshold(connectionID string) time.Duration {
+	connDetails, exists, err := s.configService.GetConnection(connectionID)
+	if err != nil || !exists || connDetails.MetadataStaleMinutes == nil {
+		return StaleMetadataThreshold
+	}
+	return time.Duration(max(*connDetails.MetadataStaleMinutes, 0)) * time.Minute
+}
+
+// IsStale reports whether metadata should be re-extracted under its connection's staleness setting.
+func (s *MetadataService) IsStale(metadata *ConnectionMetadata) bool {
+	threshold := s.StaleThreshold(metadata.ConnectionID)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return metadata.isStaleAt(threshold, s.now())
+}
+
+// AutoMetadata returns a connection's metadata for a refresh the user didn't ask for, such as on connect.
+// It extracts when force is set or the cached metadata is stale, and otherwise serves the cache. A
+// connection whose staleness threshold is 0 is never extracted here, only by an explicit ExtractMetadata.
+func (s *MetadataService) AutoMetadata(ctx context.Context, connectionID string, dbName string, force bool) (*ConnectionMetadata, error) {
+	if force && s.StaleThreshold(connectionID) == 0 {
+		LogInfo("Automatic extraction is off for connection %s, serving cached metadata", connectionID)
+		force = false
+	}
+	if !force {
+		metadata, err := s.GetMetadata(ctx, connectionID)
+		if err != nil || !s.IsStale(metadata) {
+			return metadata, err
+		}
+		LogInfo("Cached metadata for connection %s is stale, re-extracting", connectionID)
+		dbName = ""
+	}
+	if dbName != "" {
+		return s.ExtractMetadata(ctx, connectionID, dbName)
+	}
+	return s.ExtractMetadata(ctx, connectionID)
+}
+
+// Snapshot returns a copy of cached metadata, taken under the lock, with Stale set as IsStale reports it.
+// Callers hand the copy out instead of the cached metadata, which extraction keeps changing.
+func (s *MetadataService) Snapshot(metadata *ConnectionMetadata) *ConnectionMetadata {
+	threshold := s.StaleThreshold(metadata.ConnectionID)
+
+	s.mu.RLock()
+	snapshot := *metadata
+	snapshot.Databases = maps.Clone(metadata.Databases)
+	s.mu.RUnlock()
+
+	snapshot.Stale = snapshot.isStaleAt(threshold, s.now())
+	return &snapshot
+}
+
 // LoadMetadata loads metadata from file into memory for a connection
 func (s *MetadataService) LoadMetadata(ctx context.Context, connectionID string) (*ConnectionMetadata, error) {
 	s.mu.Lock()
@@ -124,8 +236,7 @@ func (s *MetadataService) LoadMetadata(ctx context.Context, connectionID string)
 		return nil, fmt.Errorf("connection not found: %s", connectionID)
 	}
 
-	filePath := s.getMetadataFilePath(connectionID)
-	data, err := os.ReadFile(filePath)
+	data, err := s.readMetadataFile(connectionID)
 	if err != nil {
 		if os.IsNotExist(err) {
 			// File doesn't exist, create empty structure - extraction will be triggered by frontend events
@@ -167,12 +278,43 @@ func (s *MetadataService) GetMetadata(ctx context.Context, connectionID string)
 	s.mu.RUnlock()
 
 	if !exists {
-		return s.LoadMetadata(ctx, connectionID)
+		return s.coalesce(ctx, "load\x00"+connectionID, func() (*ConnectionMetadata, error) {
+			return s.LoadMetadata(ctx, connectionID)
+		})
 	}
 
 	return metadata, nil
 }
 
+// coalesce runs fn unless a call with the same key is already in flight, in which case it waits for
+// and returns that call's result instead.
+func (s *MetadataService) coalesce(ctx context.Context, key string, fn func() (*ConnectionMetadata, error)) (*ConnectionMetadata, error) {
+	s.inflightMu.Lock()
+	if call, ok := s.inflight[key]; ok {
+		s.inflightMu.Unlock()
+		LogDebug("Joining in-flight metadata request: %q", key)
+		select {
+		case <-call.done:
+			return call.metadata, call.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	call := &metadataCall{done: make(chan struct{})}
+	s.inflight[key] = call
+	s.inflightMu.Unlock()
+
+	defer func() {
+		s.inflightMu.Lock()
+		delete(s.inflight, key)
+		s.inflightMu.Unlock()
+		close(call.done)
+	}()
+
+	call.metadata, call.err = fn()
+	return call.metadata, call.err
+}
+
 // SaveMetadata saves the in-memory metadata to file
 func (s *MetadataService) SaveMetadata(connectionID string) error {
 	s.mu.RLock()
@@ -183,13 +325,12 @@ func (s *MetadataService) SaveMetadata(connectionID string) error {
 		return fmt.Errorf("metadata not found in memory for connection: %s", connectionID)
 	}
 
-	filePath := s.getMetadataFilePath(connectionID)
 	data, err := json.MarshalIndent(metadata, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal metadata: %w", err)
 	}
 
-	if err := os.WriteFile(filePath, data, 0600); err != nil {
+	if err := s.writeMetadataFile(connectionID, data); err != nil {
 		return fmt.Errorf("failed to write metadata file: %w", err)
 	}
 
@@ -197,8 +338,20 @@ func (s *MetadataService) SaveMetadata(connectionID string) error {
 	return nil
 }
 
-// ExtractMetadata performs fresh extraction from database and updates memory
+// ExtractMetadata performs fresh extraction from database and updates memory.
+// Concurrent requests for the same connection and database share a single extraction.
 func (s *MetadataService) ExtractMetadata(ctx context.Context, connectionID string, optionalDbName ...string) (*ConnectionMetadata, error) {
+	dbName := ""
+	if len(optionalDbName) > 0 {
+		dbName = optionalDbName[0]
+	}
+	return s.coalesce(ctx, "extract\x00"+connectionID+"\x00"+dbName, func() (*ConnectionMetadata, error) {
+		return s.extractMetadata(ctx, connectionID, dbName)
+	})
+}
+
+// extractMetadata extracts metadata for one database, or all user databases if dbName is empty.
+func (s *MetadataService) extractMetadata(ctx context.Context, connectionID string, dbName string) (*ConnectionMetadata, error) {
 	connDetails, exists, err := s.configService.GetConnection(connectionID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get connection details: %w", err)
@@ -223,10 +376,10 @@ func (s *MetadataService) ExtractMetadata(ctx context.Context, connectionID stri
 
 	// Determine which databases to extract
 	var databasesToExtract []string
-	if len(optionalDbName) > 0 && optionalDbName[0] != "" {
+	if dbName != "" {
 		// Partial extraction for specific database
-		databasesToExtract = []string{optionalDbName[0]}
-		LogInfo("Extracting metadata for database: %s", optionalDbName[0])
+		databasesToExtract = []string{dbName}
+		LogInfo("Extracting metadata for database: %s", dbName)
 	} else {
 		// Full extraction - get all user databases
 		allDatabases, err := s.dbService.ListDatabases(ctx, connDetails)
@@ -242,13 +395,78 @@ func (s *MetadataService) ExtractMetadata(ctx context.Context, connectionID stri
 		LogInfo("Extracting metadata for %d databases", len(databasesToExtract))
 	}
 
-	// Extract metadata for each database
-	for _, dbName := range databasesToExtract {
-		dbMetadata, err := s.extractDatabaseMetadata(ctx, connDetails, dbName)
-		if err != nil {
-			return nil, fmt.Errorf("failed to extract metadata for database %s: %w", dbName, err)
+	// Extract metadata for each database, a bounded number at a time
+	databaseWorkers, tableWorkers := s.configService.GetMetadataConcurrency()
+	parentCtx := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// Table fetches share one limit across databases so the total stays bounded
+	tableSem := make(chan struct{}, tableWorkers)
+	dbSem := make(chan struct{}, databaseWorkers)
+	results := make([]*DatabaseMetadata, len(databasesToExtract))
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	for i, dbName := range databasesToExtract {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case dbSem <- struct{}{}:
+				defer func() { <-dbSem }()
+			case <-ctx.Done():
+				return
+			}
+			progress := func(done, total int) {
+				if s.OnExtractionProgress != nil {
+					s.OnExtractionProgress(ExtractionProgress{
+						ConnectionID: connectionID,
+						Database:     dbName,
+						TablesDone:   done,
+						TablesTotal:  total,
+					})
+				}
+			}
+			dbMetadata, err := s.extractDatabaseMetadata(ctx, connDetails, dbName, tableSem, progress)
+			if err != nil {
+				errOnce.Do(func() {
+					firstErr = fmt.Errorf("failed to extract metadata for database %s: %w", dbName, err)
+					cancel()
+				})
+				return
+			}
+			results[i] = dbMetadata
+		}()
+	}
+	wg.Wait()
+	// A cancelled extraction reports the cancellation itself rather than whichever query noticed it first
+	if err := parentCtx.Err(); err != nil {
+		LogInfo("Extraction cancelled for connection: %s", connectionID)
+		return nil, err
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	for i, dbName := range databasesToExtract {
+		if previous, ok := metadata.Databases[dbName]; ok {
+			logDroppedTables(dbName, previous, results[i])
+		}
+		metadata.Databases[dbName] = *results[i]
+	}
+	// A full extraction also forgets databases that no longer exist
+	if dbName == "" {
+		extracted := make(map[string]bool, len(databasesToExtract))
+		for _, name := range databasesToExtract {
+			extracted[name] = true
+		}
+		for name := range metadata.Databases {
+			if !extracted[name] {
+				LogInfo("Database %s no longer exists, removing it from metadata", name)
+				delete(metadata.Databases, name)
+			}
 		}
-		metadata.Databases[dbName] = *dbMetadata
 	}
 
 	metadata.LastExtracted = time.Now()
@@ -256,6 +474,19 @@ func (s *MetadataService) ExtractMetadata(ctx context.Context, connectionID stri
 	return metadata, nil
 }
 
+// logDroppedTables logs tables present in the previous metadata of a database but missing from the new one.
+func logDroppedTables(dbName string, previous DatabaseMetadata, current *DatabaseMetadata) {
+	remaining := make(map[string]bool, len(current.Tables))
+	for _, table := range current.Tables {
+		remaining[table.Name] = true
+	}
+	for _, table := range previous.Tables {
+		if !remaining[table.Name] {
+			LogInfo("Table %s.%s no longer exists, removing it from metadata", dbName, table.Name)
+		}
+	}
+}
+
 // UpdateAIDescription updates AI description in memory
 func (s *MetadataService) UpdateAIDescription(ctx context.Context, connectionID, dbName string, target DescriptionTarget, description string) error {
 	s.mu.Lock()
@@ -345,6 +576,48 @@ func (s *MetadataService) getMetadataFilePath(connectionID string) string {
 	return filepath.Join(s.metadataDir, fileName)
 }
 
+// metadataCompressThreshold is the JSON size above which metadata files are stored gzipped
+const metadataCompressThreshold = 256 << 10
+
+// readMetadataFile returns the JSON of a connection's metadata file, decompressing it if it was gzipped.
+// Errors from reading the file are returned as is, so callers can check os.IsNotExist.
+func (s *MetadataService) readMetadataFile(connectionID string) ([]byte, error) {
+	data, err := os.ReadFile(s.getMetadataFilePath(connectionID))
+	if err != nil {
+		return nil, err
+	}
+	// Files keep the .json extension either way, so compression is detected by the gzip magic bytes
+	if len(data) < 2 || data[0] != 0x1f || data[1] != 0x8b {
+		return data, nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress metadata file: %w", err)
+	}
+	defer zr.Close()
+	data, err = io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress metadata file: %w", err)
+	}
+	return data, nil
+}
+
+// writeMetadataFile stores a connection's metadata JSON, gzipped when larger than metadataCompressThreshold.
+func (s *MetadataService) writeMetadataFile(connectionID string, data []byte) error {
+	if len(data) > metadataCompressThreshold {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(data); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+		data = buf.Bytes()
+	}
+	return writeFileAtomic(s.getMetadataFilePath(connectionID), data, 0600)
+}
+
 func isSystemDatabase(dbName string) bool {
 	systemDBs := map[string]bool{
 		"information_schema":  true,
@@ -357,7 +630,12 @@ func isSystemDatabase(dbName string) bool {
 	return systemDBs[strings.ToLower(dbName)]
 }
 
-func (s *MetadataService) extractDatabaseMetadata(ctx context.Context, connDetails ConnectionDetails, dbName string) (*DatabaseMetadata, error) {
+// extractDatabaseMetadata extracts one database's tables, fetching at most cap(tableSem) tables at once.
+// progress is called with the number of tables done so far, once up front and then after each table.
+func (s *MetadataService) extractDatabaseMetadata(ctx context.Context, connDetails ConnectionDetails, dbName string, tableSem chan struct{}, progress func(done, total int)) (*DatabaseMetadata, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	connDetailsCopy := connDetails
 	connDetailsCopy.DBName = dbName
 
@@ -374,7 +652,7 @@ func (s *MetadataService) extractDatabaseMetadata(ctx context.Context, connDetai
 
 	// Get database comment
 	dbCommentQuery := fmt.Sprintf(`
-		SELECT SCHEMA_COMMENT
+		SELECT SCHEMA_COMMENT, DEFAULT_COLLATION_NAME
 		FROM information_schema.SCHEMATA
 		WHERE SCHEMA_NAME = '%s'`, dbName)
 
@@ -382,23 +660,76 @@ func (s *MetadataService) extractDatabaseMetadata(ctx context.Context, connDetai
 		if comment, ok := result.Rows[0]["SCHEMA_COMMENT"].(string); ok && comment != "" {
 			dbMetadata.DBComment = comment
 		}
+		if collation, ok := result.Rows[0]["DEFAULT_COLLATION_NAME"].(string); ok {
+			dbMetadata.DefaultCollation = collation
+		}
+	}
+
+	sequences, err := s.extractSequences(ctx, connDetailsCopy, dbName)
+	if err != nil {
+		return nil, err
 	}
+	dbMetadata.Sequences = sequences
+	// information_schema.TABLES lists sequences too
+	sequenceNames := make(map[string]bool, len(sequences))
+	for _, seq := range sequences {
+		sequenceNames[seq.Name] = true
+	}
+
+	// Comments for every table are fetched up front rather than per table
+	details := s.fetchTableDetails(ctx, connDetailsCopy, dbName)
 
-	// Extract table metadata
+	// Extract table metadata concurrently, keeping results in ListTables order
+	var pending []string
 	for _, tableName := range tables {
-		table, err := s.extractTableMetadata(ctx, connDetailsCopy, dbName, tableName)
-		if err != nil {
-			return nil, fmt.Errorf("failed to extract table %s: %w", tableName, err)
+		if !sequenceNames[tableName] {
+			pending = append(pending, tableName)
+		}
+	}
+	tableResults := make([]*Table, len(pending))
+	tableErrs := make([]error, len(pending))
+	var wg sync.WaitGroup
+	var tablesDone atomic.Int64
+	progress(0, len(pending))
+	for i, tableName := range pending {
+		select {
+		case tableSem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return nil, ctx.Err()
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-tableSem }()
+			tableResults[i], tableErrs[i] = s.extractTableMetadata(ctx, connDetailsCopy, dbName, tableName, details)
+			if tableErrs[i] == nil {
+				progress(int(tablesDone.Add(1)), len(pending))
+			}
+		}()
+	}
+	wg.Wait()
+	// Most per-table lookups are best effort, so a cancelled one could otherwise pass as an empty result
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	for i, tableName := range pending {
+		if tableErrs[i] != nil {
+			return nil, fmt.Errorf("failed to extract table %s: %w", tableName, tableErrs[i])
 		}
+		table := tableResults[i]
 		dbMetadata.Tables = append(dbMetadata.Tables, *table)
 
 		// Build graph edges from foreign keys
 		for _, fk := range table.ForeignKeys {
 			if len(fk.ColumnNames) > 0 && len(fk.RefColumnNames) > 0 {
 				dbMetadata.Graph[table.Name] = append(dbMetadata.Graph[table.Name], Edge{
-					ToTable:    fk.RefTableName,
-					FromColumn: fk.ColumnNames[0],
-					ToColumn:   fk.RefColumnNames[0],
+					ToTable:     fk.RefTableName,
+					FromColumn:  fk.ColumnNames[0],
+					ToColumn:    fk.RefColumnNames[0],
+					FromColumns: fk.ColumnNames,
+					ToColumns:   fk.RefColumnNames,
 				})
 			}
 		}
@@ -407,7 +738,93 @@ func (s *MetadataService) extractDatabaseMetadata(ctx context.Context, connDetai
 	return dbMetadata, nil
 }
 
-func (s *MetadataService) extractTableMetadata(ctx context.Context, connDetails ConnectionDetails, dbName, tableName string) (*Table, error) {
+// tableDetails holds what information_schema reports about one table, see fetchTableDetails
+type tableDetails struct {
+	isView         bool
+	viewDefinition string
+	comment        string
+	collation      string
+	engine         string
+	estimatedRows  int64
+	dataLength     int64
+	indexLength    int64
+	createTime     *time.Time
+	updateTime     *time.Time
+	columnComments map[string]string
+}
+
+// fetchTableDetails loads table types, sizes, view definitions and table and column comments for a whole database,
+// keyed by table name. Failures are logged and leave the details empty, as they are not essential to the metadata.
+func (s *MetadataService) fetchTableDetails(ctx context.Context, connDetails ConnectionDetails, dbName string) map[string]tableDetails {
+	details := make(map[string]tableDetails)
+
+	tablesQuery := `
+		SELECT TABLE_NAME, TABLE_TYPE, TABLE_COMMENT, TABLE_COLLATION, CREATE_TIME, UPDATE_TIME,
+			ENGINE, TABLE_ROWS, DATA_LENGTH, INDEX_LENGTH
+		FROM information_schema.TABLES
+		WHERE TABLE_SCHEMA = ?`
+	if result, err := s.dbService.ExecuteSQL(ctx, connDetails, tablesQuery, dbName); err == nil {
+		for _, row := range result.Rows {
+			tableName, _ := row["TABLE_NAME"].(string)
+			tableType, _ := row["TABLE_TYPE"].(string)
+			d := tableDetails{isView: tableType == "VIEW", columnComments: make(map[string]string)}
+			d.comment, _ = row["TABLE_COMMENT"].(string)
+			d.collation, _ = row["TABLE_COLLATION"].(string)
+			d.createTime = timeValue(row["CREATE_TIME"])
+			d.updateTime = timeValue(row["UPDATE_TIME"])
+			d.engine, _ = row["ENGINE"].(string)
+			d.estimatedRows, _ = valueInt64(row["TABLE_ROWS"])
+			d.dataLength, _ = valueInt64(row["DATA_LENGTH"])
+			d.indexLength, _ = valueInt64(row["INDEX_LENGTH"])
+			details[tableName] = d
+		}
+	} else {
+		LogWarning("Failed to fetch table comments for %s: %v", dbName, err)
+	}
+
+	columnsQuery := `
+		SELECT TABLE_NAME, COLUMN_NAME, COLUMN_COMMENT
+		FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = ? AND COLUMN_COMMENT != ''`
+	if result, err := s.dbService.ExecuteSQL(ctx, connDetails, columnsQuery, dbName); err == nil {
+		for _, row := range result.Rows {
+			tableName, _ := row["TABLE_NAME"].(string)
+			colName, _ := row["COLUMN_NAME"].(string)
+			comment, _ := row["COLUMN_COMMENT"].(string)
+			d, ok := details[tableName]
+			if !ok {
+				d = tableDetails{columnComments: make(map[string]string)}
+				details[tableName] = d
+			}
+			d.columnComments[colName] = comment
+		}
+	} else {
+		LogWarning("Failed to fetch column comments for %s: %v", dbName, err)
+	}
+
+	viewsQuery := `
+		SELECT TABLE_NAME, VIEW_DEFINITION
+		FROM information_schema.VIEWS
+		WHERE TABLE_SCHEMA = ?`
+	if result, err := s.dbService.ExecuteSQL(ctx, connDetails, viewsQuery, dbName); err == nil {
+		for _, row := range result.Rows {
+			tableName, _ := row["TABLE_NAME"].(string)
+			if d, ok := details[tableName]; ok {
+				d.viewDefinition, _ = row["VIEW_DEFINITION"].(string)
+				details[tableName] = d
+			}
+		}
+	} else {
+		LogWarning("Failed to fetch view definitions for %s: %v", dbName, err)
+	}
+
+	return details
+}
+
+func (s *MetadataService) extractTableMetadata(ctx context.Context, connDetails ConnectionDetails, dbName, tableName string, details map[string]tableDetails) (*Table, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	table := &Table{
 		Name:        tableName,
 		Columns:     make([]Column, 0),
@@ -421,31 +838,26 @@ func (s *MetadataService) extractTableMetadata(ctx context.Context, connDetails
 		return nil, fmt.Errorf("failed to get table schema: %w", err)
 	}
 
-	// Get table comment
-	tableCommentQuery := fmt.Sprintf(`
-		SELECT TABLE_COMMENT
-		FROM information_schema.TABLES
-		WHERE TABLE_SCHEMA = '%s' AND TABLE_NAME = '%s'`, dbName, tableName)
-
-	if result, err := s.dbService.ExecuteSQL(ctx, connDetails, tableCommentQuery); err == nil && len(result.Rows) > 0 {
-		if comment, ok := result.Rows[0]["TABLE_COMMENT"].(string); ok && comment != "" {
-			table.DBComment = comment
-		}
-	}
-
-	// Get column comments
-	columnCommentsQuery := fmt.Sprintf(`
-		SELECT COLUMN_NAME, COLUMN_COMMENT
-		FROM information_schema.COLUMNS
-		WHERE TABLE_SCHEMA = '%s' AND TABLE_NAME = '%s'`, dbName, tableName)
-
-	columnComments := make(map[string]string)
-	if result, err := s.dbService.ExecuteSQL(ctx, connDetails, columnCommentsQuery); err == nil {
-		for _, row := range result.Rows {
-			if colName, ok := row["COLUMN_NAME"].(string); ok {
-				if comment, okComment := row["COLUMN_COMMENT"].(string); okComment && comment != "" {
-					columnComments[colName] = comment
-				}
+	// Table and column comments come from the per-database batch
+	tableInfo := details[tableName]
+	table.DBComment = tableInfo.comment
+	table.Collation = tableInfo.collation
+	table.CreateTime = tableInfo.createTime
+	table.UpdateTime = tableInfo.updateTime
+	table.IsView = tableInfo.isView
+	table.ViewDefinition = tableInfo.viewDefinition
+	table.Engine = tableInfo.engine
+	table.EstimatedRows = tableInfo.estimatedRows
+	table.DataLength = tableInfo.dataLength
+	table.IndexLength = tableInfo.indexLength
+	columnComments := tableInfo.columnComments
+
+	// Get primary key columns, including every column of a composite key
+	primaryKey := make(map[string]bool)
+	if !table.IsView {
+		if pkColumns, err := s.dbService.getPrimaryKeyColumns(ctx, connDetails, dbName, tableName); err == nil {
+			for _, name := range pkColumns {
+				primaryKey[strings.ToLower(name)] = true
 			}
 		}
 	}
@@ -455,16 +867,25 @@ func (s *MetadataService) extractTableMetadata(ctx context.Context, connDetails
 		column := Column{
 			Name:          col.ColumnName,
 			DataType:      col.ColumnType,
+			IsPrimaryKey:  primaryKey[strings.ToLower(col.ColumnName)],
 			IsNullable:    col.IsNullable == "YES",
 			AutoIncrement: col.Extra == "auto_increment",
 			DBComment:     columnComments[col.ColumnName],
 		}
+		if col.CollationName.Valid {
+			column.Collation = col.CollationName.String
+		}
 		if col.ColumnDefault.Valid {
 			column.DefaultValue = col.ColumnDefault.String
 		}
 		table.Columns = append(table.Columns, column)
 	}
 
+	// Views have no keys or indexes to look up
+	if table.IsView {
+		return table, nil
+	}
+
 	// Get foreign keys
 	fkQuery := fmt.Sprintf(`
 		SELECT CONSTRAINT_NAME, COLUMN_NAME, REFERENCED_TABLE_NAME, REFERENCED_COLUMN_NAME
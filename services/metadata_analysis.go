@@ -0,0 +1,269 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CollationIssue describes a column or table whose collation differs from its expected default
+type CollationIssue struct {
+	TableName         string `json:"tableName"`
+	ColumnName        string `json:"columnName,omitempty"` // Empty when the table itself mismatches the database default
+	Collation         string `json:"collation"`
+	ExpectedCollation string `json:"expectedCollation"`
+}
+
+// getCachedDatabase returns the cached metadata for a database, loading it from file if needed
+func (s *MetadataService) getCachedDatabase(connectionID, dbName string) (DatabaseMetadata, error) {
+	metadata, err := s.GetMetadata(context.Background(), connectionID)
+	if err != nil {
+		return DatabaseMetadata{}, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	dbMeta, exists := metadata.Databases[dbName]
+	if !exists {
+		return DatabaseMetadata{}, fmt.Errorf("database %s not found in metadata", dbName)
+	}
+	return dbMeta, nil
+}
+
+// FindCollationIssues scans cached metadata for tables whose collation differs from the database
+// default and columns whose collation differs from their table default
+func (s *MetadataService) FindCollationIssues(connectionID, dbName string) ([]CollationIssue, error) {
+	dbMeta, err := s.getCachedDatabase(connectionID, dbName)
+	if err != nil {
+		return nil, err
+	}
+
+	issues := make([]CollationIssue, 0)
+	for _, table := range dbMeta.Tables {
+		if dbMeta.DefaultCollation != "" && table.Collation != "" && table.Collation != dbMeta.DefaultCollation {
+			issues = append(issues, CollationIssue{
+				TableName:         table.Name,
+				Collation:         table.Collation,
+				ExpectedCollation: dbMeta.DefaultCollation,
+			})
+		}
+
+		if table.Collation == "" {
+			continue
+		}
+		for _, col := range table.Columns {
+			if col.Collation != "" && col.Collation != table.Collation {
+				issues = append(issues, CollationIssue{
+					TableName:         table.Name,
+					ColumnName:        col.Name,
+					Collation:         col.Collation,
+					ExpectedCollation: table.Collation,
+				})
+			}
+		}
+	}
+
+	return issues, nil
+}
+
+// FindCircularDependencies detects cycles in a database's foreign key graph using cached metadata.
+// Each cycle is returned as an ordered list of tables starting from its alphabetically first table.
+// Self-referencing tables are reported first, as single-table cycles.
+func (s *MetadataService) FindCircularDependencies(connectionID, dbName string) ([][]string, error) {
+	dbMeta, err := s.getCachedDatabase(connectionID, dbName)
+	if err != nil {
+		return nil, err
+	}
+
+	graph := make(map[string][]string)
+	var tableNames []string
+	for _, table := range dbMeta.Tables {
+		tableNames = append(tableNames, table.Name)
+		seen := make(map[string]bool)
+		for _, fk := range table.ForeignKeys {
+			if !seen[fk.RefTableName] {
+				seen[fk.RefTableName] = true
+				graph[table.Name] = append(graph[table.Name], fk.RefTableName)
+			}
+		}
+		sort.Strings(graph[table.Name])
+	}
+	sort.Strings(tableNames)
+
+	selfRefs := make([][]string, 0)
+	cycles := make([][]string, 0)
+	found := make(map[string]bool)
+
+	const (
+		unvisited = iota
+		inProgress
+		done
+	)
+	state := make(map[string]int)
+	var stack []string
+
+	var visit func(table string)
+	visit = func(table string) {
+		state[table] = inProgress
+		stack = append(stack, table)
+
+		for _, ref := range graph[table] {
+			switch {
+			case ref == table:
+				selfRefs = append(selfRefs, []string{table})
+			case state[ref] == inProgress:
+				// Back edge: the cycle is the stack suffix starting at ref
+				start := len(stack) - 1
+				for stack[start] != ref {
+					start--
+				}
+				cycle := canonicalCycle(stack[start:])
+				key := strings.Join(cycle, "\x00")
+				if !found[key] {
+					found[key] = true
+					cycles = append(cycles, cycle)
+				}
+			case state[ref] == unvisited:
+				visit(ref)
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		state[table] = done
+	}
+
+	for _, table := range tableNames {
+		if state[table] == unvisited {
+			visit(table)
+		}
+	}
+
+	return append(selfRefs, cycles...), nil
+}
+
+// canonicalCycle rotates a cycle so it starts at its alphabetically first table.
+func canonicalCycle(cycle []string) []string {
+	minIdx := 0
+	for i, table := range cycle {
+		if table < cycle[minIdx] {
+			minIdx = i
+		}
+	}
+	rotated := make([]string, 0, len(cycle))
+	rotated = append(rotated, cycle[minIdx:]...)
+	return append(rotated, cycle[:minIdx]...)
+}
+
+// CachedTableNames returns the table names of each database in the cached metadata of a connection.
+func (s *MetadataService) CachedTableNames(connectionID string) (map[string][]string, error) {
+	metadata, err := s.GetMetadata(context.Background(), connectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tables := make(map[string][]string, len(metadata.Databases))
+	for dbName, dbMeta := range metadata.Databases {
+		names := make([]string, 0, len(dbMeta.Tables))
+		for _, table := range dbMeta.Tables {
+			names = append(names, table.Name)
+		}
+		sort.Strings(names)
+		tables[dbName] = names
+	}
+	return tables, nil
+}
+
+// RedundantIndex is an index made unnecessary by another index of the same table
+type RedundantIndex struct {
+	TableName        string   `json:"tableName"`
+	IndexName        string   `json:"indexName"`
+	Columns          []string `json:"columns"`
+	IsUnique         bool     `json:"isUnique"`
+	CoveredBy        string   `json:"coveredBy"`
+	CoveredByColumns []string `json:"coveredByColumns"`
+	Reason           string   `json:"reason"` // "duplicate" or "prefix"
+}
+
+// FindRedundantIndexes reports indexes that duplicate another index or are a leading prefix of one,
+// using cached metadata. The primary key is never reported. A unique index is only reported when another
+// unique index (or the primary key) has exactly the same columns, since a unique prefix still enforces a
+// constraint the longer index doesn't.
+func (s *MetadataService) FindRedundantIndexes(connectionID, dbName string) ([]RedundantIndex, error) {
+	dbMeta, err := s.getCachedDatabase(connectionID, dbName)
+	if err != nil {
+		return nil, err
+	}
+
+	redundant := make([]RedundantIndex, 0)
+	for _, table := range dbMeta.Tables {
+		indexes := make([]Index, len(table.Indexes))
+		copy(indexes, table.Indexes)
+		// PRIMARY first, then by name, so the preferred covering index is found first
+		sort.Slice(indexes, func(i, j int) bool {
+			if (indexes[i].Name == "PRIMARY") != (indexes[j].Name == "PRIMARY") {
+				return indexes[i].Name == "PRIMARY"
+			}
+			return indexes[i].Name < indexes[j].Name
+		})
+
+		for _, idx := range indexes {
+			if idx.Name == "PRIMARY" {
+				continue
+			}
+			var cover *Index
+			reason := ""
+			for i := range indexes {
+				other := &indexes[i]
+				if other.Name == idx.Name || !isColumnPrefix(idx.ColumnNames, other.ColumnNames) {
+					continue
+				}
+				duplicate := len(idx.ColumnNames) == len(other.ColumnNames)
+				if idx.IsUnique && (!duplicate || !other.IsUnique) {
+					continue
+				}
+				if duplicate && other.Name != "PRIMARY" && other.IsUnique == idx.IsUnique && other.Name > idx.Name {
+					continue // Of two equivalent duplicates, only the later one is reported
+				}
+				// Prefer an exact duplicate over a longer index, and a unique duplicate over a non-unique one
+				if cover == nil || (duplicate && (reason != "duplicate" || (other.IsUnique && !cover.IsUnique))) {
+					cover = other
+					reason = "prefix"
+					if duplicate {
+						reason = "duplicate"
+					}
+				}
+			}
+			if cover != nil {
+				redundant = append(redundant, RedundantIndex{
+					TableName:        table.Name,
+					IndexName:        idx.Name,
+					Columns:          idx.ColumnNames,
+					IsUnique:         idx.IsUnique,
+					CoveredBy:        cover.Name,
+					CoveredByColumns: cover.ColumnNames,
+					Reason:           reason,
+				})
+			}
+		}
+	}
+
+	return redundant, nil
+}
+
+// isColumnPrefix reports whether prefix is a leading prefix of (or equal to) columns.
+func isColumnPrefix(prefix, columns []string) bool {
+	if len(prefix) > len(columns) {
+		return false
+	}
+	for i, col := range prefix {
+		if !strings.EqualFold(col, columns[i]) {
+			return false
+		}
+	}
+	return true
+}
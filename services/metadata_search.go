@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+// maxSearchHits caps how many results SearchMetadata returns
+const maxSearchHits = 100
+
+// SearchHit is a database, table or column of cached metadata matching a search
+type SearchHit struct {
+	Kind     string `json:"kind"` // "database", "table" or "column"
+	Database string `json:"database"`
+	Table    string `json:"table,omitempty"`
+	Column   string `json:"column,omitempty"`
+	Path     string `json:"path"`   // e.g. "shop.orders.user_id"
+	Reason   string `json:"reason"` // What matched, e.g. "name prefix" or "comment"
+	Score    int    `json:"score"`
+}
+
+// SearchMetadata matches query against the names, comments and AI descriptions of every database, table and
+// column in a connection's cached metadata, without querying the database. Exact name matches rank first,
+// then prefixes, substrings and fuzzy (in-order characters) matches, then comment and description matches.
+func (s *MetadataService) SearchMetadata(connectionID, query string) ([]SearchHit, error) {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return []SearchHit{}, nil
+	}
+
+	metadata, err := s.GetMetadata(context.Background(), connectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	hits := make([]SearchHit, 0)
+	add := func(hit SearchHit, name, comment, aiDescription string) {
+		score, reason := scoreSearchMatch(query, name, comment, aiDescription)
+		if score == 0 {
+			return
+		}
+		hit.Score, hit.Reason = score, reason
+		hits = append(hits, hit)
+	}
+
+	for dbName, dbMeta := range metadata.Databases {
+		add(SearchHit{Kind: "database", Database: dbName, Path: dbName}, dbName, dbMeta.DBComment, dbMeta.AIDescription)
+		for _, table := range dbMeta.Tables {
+			tablePath := dbName + "." + table.Name
+			add(SearchHit{Kind: "table", Database: dbName, Table: table.Name, Path: tablePath},
+				table.Name, table.DBComment, table.AIDescription)
+			for _, col := range table.Columns {
+				add(SearchHit{Kind: "column", Database: dbName, Table: table.Name, Column: col.Name, Path: tablePath + "." + col.Name},
+					col.Name, col.DBComment, col.AIDescription)
+			}
+		}
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Path < hits[j].Path
+	})
+	if len(hits) > maxSearchHits {
+		hits = hits[:maxSearchHits]
+	}
+	return hits, nil
+}
+
+// scoreSearchMatch rates how well a lowercased query matches a name or its descriptions, 0 for no match.
+func scoreSearchMatch(query, name, comment, aiDescription string) (int, string) {
+	name = strings.ToLower(name)
+	switch {
+	case name == query:
+		return 100, "exact name"
+	case strings.HasPrefix(name, query):
+		return 80, "name prefix"
+	case strings.Contains(name, query):
+		return 60, "name contains"
+	case isSubsequence(query, name):
+		return 40, "fuzzy name"
+	case strings.Contains(strings.ToLower(comment), query):
+		return 20, "comment"
+	case strings.Contains(strings.ToLower(aiDescription), query):
+		return 10, "AI description"
+	}
+	return 0, ""
+}
+
+// isSubsequence reports whether every character of query appears in s in order, e.g. "uid" in "user_id".
+func isSubsequence(query, s string) bool {
+	rest := []rune(query)
+	for _, r := range s {
+		if len(rest) == 0 {
+			break
+		}
+		if r == rest[0] {
+			rest = rest[1:]
+		}
+	}
+	return len(rest) == 0
+}
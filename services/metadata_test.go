@@ -0,0 +1,770 @@
+package services
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"reflect"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestMetadataService returns a MetadataService that keeps its files in a temporary directory.
+func newTestMetadataService(t *testing.T, configService *ConfigService) *MetadataService {
+	t.Helper()
+	return &MetadataService{
+		configService: configService,
+		dbService:     NewDatabaseService(),
+		metadataDir:   t.TempDir(),
+		metadata:      make(map[string]*ConnectionMetadata),
+		inflight:      make(map[string]*metadataCall),
+		now:           time.Now,
+	}
+}
+
+// fakeSchemaTable is a table of a fakeCluster. Its columns are all NOT NULL INTs.
+type fakeSchemaTable struct {
+	columns []string
+	pk      []string
+	fks     [][4]string // Constraint, column, referenced table, referenced column
+	indexes [][3]string // Index, column, NON_UNIQUE
+	view    string      // The definition of a view, empty for base tables
+}
+
+// fakeCluster answers the information_schema queries of metadata extraction for its databases, which
+// tests may change between extractions.
+type fakeCluster struct {
+	mu        sync.Mutex
+	databases map[string]map[string]fakeSchemaTable
+}
+
+var (
+	// quotedSchemaName and quotedTableName find the names in metadata queries that inline them
+	quotedSchemaName = regexp.MustCompile(`(?:TABLE_SCHEMA|SCHEMA_NAME) = '([^']*)'`)
+	quotedTableName  = regexp.MustCompile(`TABLE_NAME = '([^']*)'`)
+)
+
+// use makes s run the statements of details against the cluster, whichever database they default to.
+func (c *fakeCluster) use(t *testing.T, s *DatabaseService, details ConnectionDetails) {
+	t.Helper()
+	db, _ := newFakeDB(t, c.respond)
+	useFakeDB(s, details, db)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for name := range c.databases {
+		details.DBName = name
+		useFakeDB(s, details, db)
+	}
+}
+
+// dropTable removes a table, as DROP TABLE would.
+func (c *fakeCluster) dropTable(dbName, tableName string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.databases[dbName], tableName)
+}
+
+// dropDatabase removes a database, as DROP DATABASE would.
+func (c *fakeCluster) dropDatabase(dbName string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.databases, dbName)
+}
+
+func (c *fakeCluster) respond(_ context.Context, query string, args []any) fakeResponse {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var dbName, tableName string
+	if len(args) > 0 {
+		dbName, _ = args[0].(string)
+	} else if m := quotedSchemaName.FindStringSubmatch(query); m != nil {
+		dbName = m[1]
+	}
+	if len(args) > 1 {
+		tableName, _ = args[1].(string)
+	} else if m := quotedTableName.FindStringSubmatch(query); m != nil {
+		tableName = m[1]
+	}
+	tables := c.databases[dbName]
+	table := tables[tableName]
+
+	var response fakeResponse
+	switch {
+	case strings.Contains(query, "SELECT SCHEMA_NAME FROM information_schema.SCHEMATA"):
+		response.columns = []string{"SCHEMA_NAME"}
+		for _, name := range slices.Sorted(maps.Keys(c.databases)) {
+			response.rows = append(response.rows, []driver.Value{name})
+		}
+	case strings.Contains(query, "SELECT TABLE_NAME FROM information_schema.TABLES"):
+		response.columns = []string{"TABLE_NAME"}
+		for _, name := range slices.Sorted(maps.Keys(tables)) {
+			response.rows = append(response.rows, []driver.Value{name})
+		}
+	case strings.Contains(query, "TABLE_TYPE"):
+		response.columns = []string{"TABLE_NAME", "TABLE_TYPE"}
+		for _, name := range slices.Sorted(maps.Keys(tables)) {
+			tableType := "BASE TABLE"
+			if tables[name].view != "" {
+				tableType = "VIEW"
+			}
+			response.rows = append(response.rows, []driver.Value{name, tableType})
+		}
+	case strings.Contains(query, "information_schema.VIEWS"):
+		response.columns = []string{"TABLE_NAME", "VIEW_DEFINITION"}
+		for _, name := range slices.Sorted(maps.Keys(tables)) {
+			if tables[name].view != "" {
+				response.rows = append(response.rows, []driver.Value{name, tables[name].view})
+			}
+		}
+	case strings.Contains(query, "information_schema.COLUMNS") && !strings.Contains(query, "COLUMN_COMMENT !="):
+		response.columns = []string{"COLUMN_NAME", "COLUMN_TYPE", "CHARACTER_SET_NAME", "COLLATION_NAME",
+			"IS_NULLABLE", "COLUMN_DEFAULT", "EXTRA", "COLUMN_COMMENT"}
+		for _, col := range table.columns {
+			response.rows = append(response.rows, []driver.Value{col, "int", nil, nil, "NO", nil, "", ""})
+		}
+	case strings.Contains(query, "CONSTRAINT_NAME = 'PRIMARY'"):
+		response.columns = []string{"COLUMN_NAME"}
+		for _, col := range table.pk {
+			response.rows = append(response.rows, []driver.Value{col})
+		}
+	case strings.Contains(query, "REFERENCED_TABLE_NAME IS NOT NULL"):
+		response.columns = []string{"CONSTRAINT_NAME", "COLUMN_NAME", "REFERENCED_TABLE_NAME", "REFERENCED_COLUMN_NAME"}
+		for _, fk := range table.fks {
+			response.rows = append(response.rows, []driver.Value{fk[0], fk[1], fk[2], fk[3]})
+		}
+	case strings.Contains(query, "information_schema.STATISTICS"):
+		response.columns = []string{"INDEX_NAME", "COLUMN_NAME", "NON_UNIQUE"}
+		for _, idx := range table.indexes {
+			response.rows = append(response.rows, []driver.Value{idx[0], idx[1], idx[2]})
+		}
+	default:
+		// Comments, sequences and the like: none
+		response.columns = []string{"x"}
+	}
+	return response
+}
+
+func TestExtractTableMetadataCompositePrimaryKey(t *testing.T) {
+	s := newTestMetadataService(t, newTestConfigService(t))
+	cluster := &fakeCluster{databases: map[string]map[string]fakeSchemaTable{"shop": {
+		"order_items": {columns: []string{"order_id", "product_id", "quantity"}, pk: []string{"Order_ID", "product_id"}},
+	}}}
+	details := ConnectionDetails{ID: "conn", Host: "127.0.0.1"}
+	cluster.use(t, s.dbService, details)
+
+	table, err := s.extractTableMetadata(context.Background(), details, "shop", "order_items", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var primaryKey []string
+	for _, col := range table.Columns {
+		if col.IsPrimaryKey {
+			primaryKey = append(primaryKey, col.Name)
+		}
+	}
+	if want := []string{"order_id", "product_id"}; !slices.Equal(primaryKey, want) {
+		t.Errorf("primary key columns = %v, want %v", primaryKey, want)
+	}
+}
+
+func TestExtractTableMetadataViewHasNoPrimaryKey(t *testing.T) {
+	s := newTestMetadataService(t, newTestConfigService(t))
+	cluster := &fakeCluster{databases: map[string]map[string]fakeSchemaTable{"shop": {
+		"big_orders": {columns: []string{"id"}, pk: []string{"id"}},
+	}}}
+	details := ConnectionDetails{ID: "conn", Host: "127.0.0.1"}
+	db, fake := newFakeDB(t, cluster.respond)
+	useFakeDB(s.dbService, details, db)
+
+	table, err := s.extractTableMetadata(context.Background(), details, "shop", "big_orders",
+		map[string]tableDetails{"big_orders": {isView: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.Columns[0].IsPrimaryKey {
+		t.Error("view column marked as primary key")
+	}
+	for _, stmt := range fake.Statements() {
+		if strings.Contains(stmt.query, "KEY_COLUMN_USAGE") {
+			t.Errorf("keys looked up for a view: %s", stmt.query)
+		}
+	}
+}
+
+func TestCoalesceSharesInFlightCall(t *testing.T) {
+	s := newTestMetadataService(t, newTestConfigService(t))
+
+	var runs atomic.Int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	want := &ConnectionMetadata{ConnectionID: "conn"}
+	extract := func() (*ConnectionMetadata, error) {
+		if runs.Add(1) == 1 {
+			close(started)
+		}
+		<-release
+		return want, nil
+	}
+
+	const callers = 20
+	results := make(chan *ConnectionMetadata, callers)
+	go func() {
+		metadata, _ := s.coalesce(context.Background(), "extract\x00conn\x00", extract)
+		results <- metadata
+	}()
+	<-started
+
+	var joined sync.WaitGroup
+	for range callers - 1 {
+		joined.Add(1)
+		go func() {
+			joined.Done()
+			metadata, _ := s.coalesce(context.Background(), "extract\x00conn\x00", extract)
+			results <- metadata
+		}()
+	}
+	joined.Wait()
+	time.Sleep(20 * time.Millisecond) // Let the callers reach coalesce before the extraction finishes
+	close(release)
+
+	for range callers {
+		if got := <-results; got != want {
+			t.Fatalf("caller got %p, want the shared result %p", got, want)
+		}
+	}
+	if n := runs.Load(); n != 1 {
+		t.Fatalf("extraction ran %d times, want 1", n)
+	}
+	if len(s.inflight) != 0 {
+		t.Fatalf("%d calls left in flight", len(s.inflight))
+	}
+
+	// Once finished, the next request runs again
+	release = make(chan struct{})
+	close(release)
+	if _, err := s.coalesce(context.Background(), "extract\x00conn\x00", extract); err != nil {
+		t.Fatal(err)
+	}
+	if n := runs.Load(); n != 2 {
+		t.Fatalf("extraction ran %d times after the first finished, want 2", n)
+	}
+}
+
+func TestCoalesceWaiterHonoursContext(t *testing.T) {
+	s := newTestMetadataService(t, newTestConfigService(t))
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	go s.coalesce(context.Background(), "load\x00conn", func() (*ConnectionMetadata, error) {
+		close(started)
+		<-release
+		return nil, nil
+	})
+	<-started
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := s.coalesce(ctx, "load\x00conn", func() (*ConnectionMetadata, error) {
+		t.Error("joined call ran its own load")
+		return nil, nil
+	}); err != context.Canceled {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+}
+
+func TestGetMetadataConcurrentCallersShareOneLoad(t *testing.T) {
+	configService := newTestConfigService(t, ConnectionDetails{ID: "conn", Name: "local", Host: "127.0.0.1"})
+	s := newTestMetadataService(t, configService)
+
+	var wg sync.WaitGroup
+	results := make([]*ConnectionMetadata, 50)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			metadata, err := s.GetMetadata(context.Background(), "conn")
+			if err != nil {
+				t.Error(err)
+			}
+			results[i] = metadata
+		}()
+	}
+	wg.Wait()
+
+	for _, metadata := range results {
+		if metadata != results[0] {
+			t.Fatal("concurrent GetMetadata calls returned different metadata, so the connection was loaded more than once")
+		}
+	}
+}
+
+func TestExtractMetadataCancelledMidFlight(t *testing.T) {
+	configService := newTestConfigService(t, ConnectionDetails{ID: "conn", Name: "local", Host: "127.0.0.1"})
+	s := newTestMetadataService(t, configService)
+
+	var schemaQueries atomic.Int32
+	started := make(chan struct{})
+	db, _ := newFakeDB(t, func(ctx context.Context, query string, _ []any) fakeResponse {
+		switch {
+		case strings.Contains(query, "SELECT SCHEMA_NAME FROM information_schema.SCHEMATA"):
+			return fakeResponse{columns: []string{"SCHEMA_NAME"}, rows: [][]driver.Value{{"shop"}}}
+		case strings.Contains(query, "SELECT TABLE_NAME FROM information_schema.TABLES"):
+			response := fakeResponse{columns: []string{"TABLE_NAME"}}
+			for i := range 50 {
+				response.rows = append(response.rows, []driver.Value{fmt.Sprintf("t%02d", i)})
+			}
+			return response
+		case strings.Contains(query, "information_schema.COLUMNS"):
+			// Table lookups hang until the extraction is cancelled
+			if schemaQueries.Add(1) == 1 {
+				close(started)
+			}
+			<-ctx.Done()
+			return fakeResponse{err: ctx.Err()}
+		}
+		return fakeResponse{columns: []string{"x"}}
+	})
+	connDetails, _, _ := configService.GetConnection("conn")
+	useFakeDB(s.dbService, connDetails, db)
+	connDetails.DBName = "shop" // Databases are read with it as the default database
+	useFakeDB(s.dbService, connDetails, db)
+	baseline := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.ExtractMetadata(ctx, "conn")
+		done <- err
+	}()
+	select {
+	case <-started:
+	case err := <-done:
+		t.Fatalf("extraction finished before it was cancelled: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("extraction never reached the table lookups")
+	}
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("err = %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("extraction didn't stop after being cancelled")
+	}
+	if n := schemaQueries.Load(); n >= 50 {
+		t.Errorf("%d tables looked up, want the extraction to stop before the rest", n)
+	}
+	if databases := s.metadata["conn"].Databases; len(databases) != 0 {
+		t.Errorf("cancelled extraction stored %d databases", len(databases))
+	}
+
+	// Every worker and query goroutine of the extraction exits
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > baseline && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > baseline {
+		buf := make([]byte, 1<<16)
+		t.Errorf("%d goroutines left running, %d before the extraction:\n%s", n, baseline, buf[:runtime.Stack(buf, true)])
+	}
+}
+
+// shopCluster returns a cluster with a shop database of two related tables and a view over one of them.
+func shopCluster() *fakeCluster {
+	return &fakeCluster{databases: map[string]map[string]fakeSchemaTable{"shop": {
+		"customers": {columns: []string{"id", "name"}, pk: []string{"id"}},
+		"orders": {
+			columns: []string{"id", "customer_id"},
+			pk:      []string{"id"},
+			fks:     [][4]string{{"fk_customer", "customer_id", "customers", "id"}},
+			indexes: [][3]string{{"PRIMARY", "id", "0"}, {"fk_customer", "customer_id", "1"}},
+		},
+		"big_orders": {columns: []string{"id", "customer_id"}, view: "select `id`, `customer_id` from `shop`.`orders`"},
+	}}}
+}
+
+// newTestExtraction returns a MetadataService whose connection "conn" reads from cluster.
+func newTestExtraction(t *testing.T, cluster *fakeCluster) *MetadataService {
+	t.Helper()
+	details := ConnectionDetails{ID: "conn", Name: "local", Host: "127.0.0.1"}
+	s := newTestMetadataService(t, newTestConfigService(t, details))
+	cluster.use(t, s.dbService, details)
+	return s
+}
+
+// tableNames returns the names of tables.
+func tableNames(tables []Table) []string {
+	names := make([]string, len(tables))
+	for i, table := range tables {
+		names[i] = table.Name
+	}
+	return names
+}
+
+func TestExtractMetadataViews(t *testing.T) {
+	s := newTestExtraction(t, shopCluster())
+	metadata, err := s.ExtractMetadata(context.Background(), "conn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	shop := metadata.Databases["shop"]
+	if want := []string{"big_orders", "customers", "orders"}; !slices.Equal(tableNames(shop.Tables), want) {
+		t.Fatalf("tables = %v, want %v", tableNames(shop.Tables), want)
+	}
+
+	view, orders := shop.Tables[0], shop.Tables[2]
+	if !view.IsView || view.ViewDefinition != "select `id`, `customer_id` from `shop`.`orders`" {
+		t.Errorf("view = %+v, want it marked as a view with its definition", view)
+	}
+	if len(view.Columns) != 2 || len(view.ForeignKeys) != 0 || len(view.Indexes) != 0 {
+		t.Errorf("view has %d columns, %d foreign keys and %d indexes, want only its 2 columns",
+			len(view.Columns), len(view.ForeignKeys), len(view.Indexes))
+	}
+	if orders.IsView || orders.ViewDefinition != "" || len(orders.ForeignKeys) != 1 || len(orders.Indexes) != 2 {
+		t.Errorf("base table = %+v, want its keys and no view definition", orders)
+	}
+	if _, ok := shop.Graph["big_orders"]; ok {
+		t.Error("view has foreign key edges")
+	}
+
+	withoutViews, err := s.ExportSchemaHTML("conn", "shop", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	withViews, err := s.ExportSchemaHTML("conn", "shop", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(withoutViews, "big_orders") || !strings.Contains(withoutViews, "orders") {
+		t.Error("schema diagram includes the view without includeViews")
+	}
+	if !strings.Contains(withViews, "big_orders (view)") {
+		t.Error("schema diagram leaves the view out with includeViews")
+	}
+}
+
+func TestReextractionPrunesDroppedTables(t *testing.T) {
+	cluster := shopCluster()
+	cluster.databases["archive"] = map[string]fakeSchemaTable{"old_orders": {columns: []string{"id"}, pk: []string{"id"}}}
+	s := newTestExtraction(t, cluster)
+	ctx := context.Background()
+	if _, err := s.ExtractMetadata(ctx, "conn"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A partial refresh replaces the database's tables
+	cluster.dropTable("shop", "big_orders")
+	if _, err := s.ExtractMetadata(ctx, "conn", "shop"); err != nil {
+		t.Fatal(err)
+	}
+	metadata, err := s.GetMetadata(ctx, "conn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"customers", "orders"}; !slices.Equal(tableNames(metadata.Databases["shop"].Tables), want) {
+		t.Errorf("tables after dropping big_orders = %v, want %v", tableNames(metadata.Databases["shop"].Tables), want)
+	}
+	if _, ok := metadata.Databases["archive"]; !ok {
+		t.Error("partial refresh of shop removed the archive database")
+	}
+
+	// A full refresh also forgets dropped databases
+	cluster.dropDatabase("archive")
+	cluster.dropTable("shop", "customers")
+	if _, err := s.ExtractMetadata(ctx, "conn"); err != nil {
+		t.Fatal(err)
+	}
+	metadata, err = s.GetMetadata(ctx, "conn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := metadata.Databases["archive"]; ok {
+		t.Error("dropped database still in metadata after a full refresh")
+	}
+	if want := []string{"orders"}; !slices.Equal(tableNames(metadata.Databases["shop"].Tables), want) {
+		t.Errorf("tables after dropping customers = %v, want %v", tableNames(metadata.Databases["shop"].Tables), want)
+	}
+}
+
+func TestMetadataStaleness(t *testing.T) {
+	never, hour, negative := 0, 60, -5
+	extracted := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name          string
+		staleMinutes  *int
+		lastExtracted time.Time
+		age           time.Duration
+		wantThreshold time.Duration
+		wantStale     bool
+	}{
+		{"default fresh", nil, extracted, 23 * time.Hour, StaleMetadataThreshold, false},
+		{"default stale", nil, extracted, 25 * time.Hour, StaleMetadataThreshold, true},
+		{"default never extracted", nil, time.Time{}, 0, StaleMetadataThreshold, true},
+		{"custom fresh", &hour, extracted, 59 * time.Minute, time.Hour, false},
+		{"custom stale", &hour, extracted, 61 * time.Minute, time.Hour, true},
+		{"never stale", &never, extracted, 365 * 24 * time.Hour, 0, false},
+		{"never stale never extracted", &never, time.Time{}, 0, 0, false},
+		{"negative never stale", &negative, extracted, 365 * 24 * time.Hour, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configService := newTestConfigService(t, ConnectionDetails{ID: "conn", Name: "local", MetadataStaleMinutes: tt.staleMinutes})
+			s := newTestMetadataService(t, configService)
+			s.now = func() time.Time { return extracted.Add(tt.age) }
+
+			if got := s.StaleThreshold("conn"); got != tt.wantThreshold {
+				t.Errorf("StaleThreshold = %v, want %v", got, tt.wantThreshold)
+			}
+			metadata := &ConnectionMetadata{ConnectionID: "conn", LastExtracted: tt.lastExtracted}
+			if got := s.IsStale(metadata); got != tt.wantStale {
+				t.Errorf("IsStale = %v, want %v", got, tt.wantStale)
+			}
+		})
+	}
+
+	t.Run("unknown connection uses the default", func(t *testing.T) {
+		s := newTestMetadataService(t, newTestConfigService(t))
+		s.now = func() time.Time { return extracted.Add(25 * time.Hour) }
+		if got := s.StaleThreshold("gone"); got != StaleMetadataThreshold {
+			t.Errorf("StaleThreshold = %v, want %v", got, StaleMetadataThreshold)
+		}
+		if !s.IsStale(&ConnectionMetadata{ConnectionID: "gone", LastExtracted: extracted}) {
+			t.Error("metadata older than the default threshold isn't stale")
+		}
+	})
+}
+
+// bigMetadata returns metadata for connection "conn" whose JSON is larger than metadataCompressThreshold.
+func bigMetadata() *ConnectionMetadata {
+	tables := make([]Table, 2000)
+	for i := range tables {
+		tables[i] = Table{
+			Name:    fmt.Sprintf("table_%04d", i),
+			Columns: []Column{{Name: "id", DataType: "bigint", IsPrimaryKey: true}, {Name: "payload", DataType: "json", IsNullable: true}},
+		}
+	}
+	return &ConnectionMetadata{
+		ConnectionID:   "conn",
+		ConnectionName: "local",
+		LastExtracted:  time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC),
+		Databases:      map[string]DatabaseMetadata{"shop": {Name: "shop", Tables: tables}},
+	}
+}
+
+// roundTripMetadata saves metadata for connection "conn", loads it into a fresh service sharing the
+// metadata directory, and returns the stored file and the loaded metadata.
+func roundTripMetadata(t *testing.T, metadata *ConnectionMetadata) ([]byte, *ConnectionMetadata) {
+	t.Helper()
+	configService := newTestConfigService(t, ConnectionDetails{ID: "conn", Name: "local"})
+	s := newTestMetadataService(t, configService)
+	s.metadata["conn"] = metadata
+	if err := s.SaveMetadata("conn"); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := os.ReadFile(s.getMetadataFilePath("conn"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded := newTestMetadataService(t, configService)
+	reloaded.metadataDir = s.metadataDir
+	loaded, err := reloaded.LoadMetadata(context.Background(), "conn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stored, loaded
+}
+
+// isGzip reports whether data starts with the gzip magic bytes.
+func isGzip(data []byte) bool {
+	return len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b
+}
+
+func TestMetadataFileSmallStaysPlainJSON(t *testing.T) {
+	metadata := &ConnectionMetadata{
+		ConnectionID:   "conn",
+		ConnectionName: "local",
+		LastExtracted:  time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC),
+		Databases:      map[string]DatabaseMetadata{"shop": {Name: "shop", Tables: []Table{{Name: "orders"}}}},
+	}
+	stored, loaded := roundTripMetadata(t, metadata)
+	if isGzip(stored) || !json.Valid(stored) {
+		t.Errorf("stored %d bytes that aren't plain JSON", len(stored))
+	}
+	if !reflect.DeepEqual(loaded, metadata) {
+		t.Errorf("loaded %+v, want %+v", loaded, metadata)
+	}
+}
+
+func TestMetadataFileLargeIsGzipped(t *testing.T) {
+	metadata := bigMetadata()
+	plain, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plain) <= metadataCompressThreshold {
+		t.Fatalf("test metadata is %d bytes of JSON, want more than %d", len(plain), metadataCompressThreshold)
+	}
+
+	stored, loaded := roundTripMetadata(t, metadata)
+	if !isGzip(stored) || len(stored) >= len(plain) {
+		t.Errorf("stored %d bytes, want gzipped JSON smaller than its %d bytes", len(stored), len(plain))
+	}
+	if !reflect.DeepEqual(loaded, metadata) {
+		t.Error("metadata loaded from the gzipped file differs from what was saved")
+	}
+}
+
+func TestMetadataFileLegacyPlainJSON(t *testing.T) {
+	s := newTestMetadataService(t, newTestConfigService(t, ConnectionDetails{ID: "conn", Name: "local"}))
+	// Written before compression existed: compact, plain and without the connection fields
+	legacy := `{"lastExtracted":"2024-06-01T08:00:00Z","databases":{"shop":{"name":"shop","tables":[{"name":"orders"}]}}}`
+	if err := os.WriteFile(s.getMetadataFilePath("conn"), []byte(legacy), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := s.LoadMetadata(context.Background(), "conn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.ConnectionID != "conn" || loaded.ConnectionName != "local" {
+		t.Errorf("connection = %q %q, want it filled in from the config", loaded.ConnectionID, loaded.ConnectionName)
+	}
+	if tables := loaded.Databases["shop"].Tables; len(tables) != 1 || tables[0].Name != "orders" {
+		t.Errorf("tables = %+v, want orders", tables)
+	}
+}
+
+func TestMetadataFileCorruptGzip(t *testing.T) {
+	s := newTestMetadataService(t, newTestConfigService(t, ConnectionDetails{ID: "conn", Name: "local"}))
+	if err := os.WriteFile(s.getMetadataFilePath("conn"), []byte{0x1f, 0x8b, 'n', 'o', 'p', 'e'}, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.LoadMetadata(context.Background(), "conn"); err == nil || !strings.Contains(err.Error(), "decompress") {
+		t.Errorf("err = %v, want a decompression error", err)
+	}
+}
+
+// Run with -race: snapshots are taken while extraction rewrites the cached metadata.
+func TestSnapshotDuringExtraction(t *testing.T) {
+	s := newTestExtraction(t, shopCluster())
+	ctx := context.Background()
+	cached, err := s.ExtractMetadata(ctx, "conn")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for range 20 {
+			if _, err := s.ExtractMetadata(ctx, "conn"); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range 200 {
+			snapshot := s.Snapshot(cached)
+			if _, err := json.Marshal(snapshot); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	wg.Wait()
+
+	s.now = func() time.Time { return cached.LastExtracted.Add(StaleMetadataThreshold + time.Minute) }
+	snapshot := s.Snapshot(cached)
+	if snapshot == cached || !snapshot.Stale {
+		t.Errorf("snapshot is the cached metadata or not stale: %p, %p, stale %v", snapshot, cached, snapshot.Stale)
+	}
+	if cached.Stale {
+		t.Error("taking a snapshot marked the cached metadata stale")
+	}
+}
+
+func TestAutoMetadata(t *testing.T) {
+	never, hour := 0, 60
+	ctx := context.Background()
+
+	// setup returns a service for a shop cluster whose metadata was extracted once, with a products table
+	// added since, the connection's staleness setting set to staleMinutes, and the extraction time.
+	setup := func(t *testing.T, staleMinutes *int) (*MetadataService, time.Time) {
+		cluster := shopCluster()
+		s := newTestExtraction(t, cluster)
+		details := s.configService.config.Connections["conn"]
+		details.MetadataStaleMinutes = staleMinutes
+		s.configService.config.Connections["conn"] = details
+		metadata, err := s.ExtractMetadata(ctx, "conn")
+		if err != nil {
+			t.Fatal(err)
+		}
+		cluster.mu.Lock()
+		cluster.databases["shop"]["products"] = fakeSchemaTable{columns: []string{"id"}, pk: []string{"id"}}
+		cluster.mu.Unlock()
+		return s, metadata.LastExtracted
+	}
+	// extracted reports whether the products table added after the first extraction is known
+	extracted := func(metadata *ConnectionMetadata) bool {
+		return slices.Contains(tableNames(metadata.Databases["shop"].Tables), "products")
+	}
+
+	tests := []struct {
+		name          string
+		staleMinutes  *int
+		age           time.Duration
+		force         bool
+		wantExtracted bool
+	}{
+		{"fresh is served from cache", nil, time.Hour, false, false},
+		{"stale is re-extracted", nil, StaleMetadataThreshold + time.Minute, false, true},
+		{"forced", nil, time.Hour, true, true},
+		{"custom threshold fresh", &hour, 59 * time.Minute, false, false},
+		{"custom threshold stale", &hour, 61 * time.Minute, false, true},
+		{"never stale", &never, 365 * 24 * time.Hour, false, false},
+		{"never stale ignores force", &never, time.Hour, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, lastExtracted := setup(t, tt.staleMinutes)
+			s.now = func() time.Time { return lastExtracted.Add(tt.age) }
+
+			metadata, err := s.AutoMetadata(ctx, "conn", "", tt.force)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := extracted(metadata); got != tt.wantExtracted {
+				t.Errorf("extracted = %v, want %v", got, tt.wantExtracted)
+			}
+		})
+	}
+
+	t.Run("explicit extraction with automatic extraction off", func(t *testing.T) {
+		s, _ := setup(t, &never)
+		metadata, err := s.ExtractMetadata(ctx, "conn")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !extracted(metadata) {
+			t.Error("explicit refresh didn't extract")
+		}
+	})
+}
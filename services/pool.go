@@ -0,0 +1,40 @@
+package services
+
+import (
+	"fmt"
+)
+
+// PoolStats reports the state of a connection's shared pool
+type PoolStats struct {
+	MaxOpenConnections int   `json:"maxOpenConnections"`
+	OpenConnections    int   `json:"openConnections"` // In use plus idle
+	InUse              int   `json:"inUse"`
+	Idle               int   `json:"idle"`
+	WaitCount          int64 `json:"waitCount"`      // Total times a caller waited for a free connection
+	WaitDurationMs     int64 `json:"waitDurationMs"` // Total time spent waiting
+	MaxIdleClosed      int64 `json:"maxIdleClosed"`
+	MaxLifetimeClosed  int64 `json:"maxLifetimeClosed"`
+}
+
+// PoolStats returns statistics of the shared pool for the connection details. Pools are created by the
+// first query, so a connection that hasn't run one yet has no pool and an error is returned.
+func (s *DatabaseService) PoolStats(details ConnectionDetails) (*PoolStats, error) {
+	s.poolsMu.Lock()
+	db, ok := s.pools[poolKey(details)]
+	s.poolsMu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("no connection pool exists for '%s'", details.Name)
+	}
+
+	stats := db.Stats()
+	return &PoolStats{
+		MaxOpenConnections: stats.MaxOpenConnections,
+		OpenConnections:    stats.OpenConnections,
+		InUse:              stats.InUse,
+		Idle:               stats.Idle,
+		WaitCount:          stats.WaitCount,
+		WaitDurationMs:     stats.WaitDuration.Milliseconds(),
+		MaxIdleClosed:      stats.MaxIdleClosed,
+		MaxLifetimeClosed:  stats.MaxLifetimeClosed,
+	}, nil
+}
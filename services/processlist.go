@@ -0,0 +1,55 @@
+package services
+
+import (
+	"context"
+	"fmt"
+)
+
+// ProcessInfo is a session from the server's processlist
+type ProcessInfo struct {
+	ID      int64  `json:"id"`
+	User    string `json:"user"`
+	Host    string `json:"host"`
+	DB      string `json:"db,omitempty"`
+	Command string `json:"command"`
+	Time    int64  `json:"time"` // Seconds in the current state
+	State   string `json:"state,omitempty"`
+	Info    string `json:"info,omitempty"` // The running statement
+}
+
+// GetLongRunningQueries returns the sessions that have been executing a statement for at least
+// minSeconds, longest first. Idle sessions, including this app's pooled connections, and the session
+// running the lookup are left out. On TiDB only sessions of the connected instance are listed.
+func (s *DatabaseService) GetLongRunningQueries(ctx context.Context, details ConnectionDetails, minSeconds int) ([]ProcessInfo, error) {
+	if minSeconds < 0 {
+		return nil, fmt.Errorf("minimum duration must not be negative")
+	}
+
+	result, err := s.ExecuteSQL(ctx, details, `
+		SELECT ID, USER, HOST, DB, COMMAND, TIME, STATE, INFO
+		FROM information_schema.PROCESSLIST
+		WHERE COMMAND NOT IN ('Sleep', 'Daemon', 'Binlog Dump')
+			AND ID <> CONNECTION_ID()
+			AND TIME >= ?
+		ORDER BY TIME DESC, ID`, minSeconds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read processlist: %w", err)
+	}
+
+	processes := make([]ProcessInfo, 0, len(result.Rows))
+	for _, row := range result.Rows {
+		id, _ := valueInt64(row["ID"])
+		seconds, _ := valueInt64(row["TIME"])
+		processes = append(processes, ProcessInfo{
+			ID:      id,
+			User:    valueString(row["USER"]),
+			Host:    valueString(row["HOST"]),
+			DB:      valueString(row["DB"]),
+			Command: valueString(row["COMMAND"]),
+			Time:    seconds,
+			State:   valueString(row["STATE"]),
+			Info:    valueString(row["INFO"]),
+		})
+	}
+	return processes, nil
+}
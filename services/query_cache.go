@@ -0,0 +1,326 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// maxQueryCacheEntries bounds the result cache; the oldest entry is evicted first
+const maxQueryCacheEntries = 100
+
+// maxCachedRows keeps large results out of the cache
+const maxCachedRows = 10000
+
+// queryCacheEntry is a cached SELECT result
+type queryCacheEntry struct {
+	result   *SQLResult
+	tables   []string // Lower-case names of the tables the query reads, best effort
+	storedAt time.Time
+}
+
+// QueryCache keeps results of repeated SELECTs for a short time. Entries are keyed by connection and
+// normalized query text, and dropped when a write to one of their tables goes through Invalidate.
+type QueryCache struct {
+	mu      sync.Mutex
+	entries map[string]map[string]*queryCacheEntry // Connection key to query key
+}
+
+// NewQueryCache creates an empty QueryCache.
+func NewQueryCache() *QueryCache {
+	return &QueryCache{entries: make(map[string]map[string]*queryCacheEntry)}
+}
+
+// queryCacheKey returns the cache key for a query and its args.
+func queryCacheKey(query string, args []any) string {
+	var b strings.Builder
+	b.WriteString(normalizeQuery(query))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "\x00%T:%v", arg, arg)
+	}
+	return b.String()
+}
+
+// Get returns a copy of the cached result of a query if it is younger than ttl.
+func (c *QueryCache) Get(details ConnectionDetails, query string, args []any, ttl time.Duration) (*SQLResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	conn := c.entries[poolKey(details)]
+	key := queryCacheKey(query, args)
+	entry, ok := conn[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Since(entry.storedAt) > ttl {
+		delete(conn, key)
+		return nil, false
+	}
+	return copyResult(entry.result), true
+}
+
+// Put caches the result of a plain SELECT. Other statements and large results are ignored.
+func (c *QueryCache) Put(details ConnectionDetails, query string, args []any, result *SQLResult) {
+	if !isCacheableQuery(query) || len(result.Rows) > maxCachedRows {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	connKey := poolKey(details)
+	conn, ok := c.entries[connKey]
+	if !ok {
+		conn = make(map[string]*queryCacheEntry)
+		c.entries[connKey] = conn
+	}
+	if len(conn) >= maxQueryCacheEntries {
+		var oldestKey string
+		var oldest time.Time
+		for key, entry := range conn {
+			if oldestKey == "" || entry.storedAt.Before(oldest) {
+				oldestKey, oldest = key, entry.storedAt
+			}
+		}
+		delete(conn, oldestKey)
+	}
+	conn[queryCacheKey(query, args)] = &queryCacheEntry{
+		result:   copyResult(result),
+		tables:   referencedTables(query),
+		storedAt: time.Now(),
+	}
+}
+
+// Invalidate drops the cached results of a connection that read any of the given tables, along with
+// those whose tables couldn't be determined. Without tables, the connection's whole cache is dropped.
+func (c *QueryCache) Invalidate(details ConnectionDetails, tables ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	connKey := poolKey(details)
+	if len(tables) == 0 {
+		delete(c.entries, connKey)
+		return
+	}
+	written := make(map[string]bool, len(tables))
+	for _, table := range tables {
+		written[strings.ToLower(table)] = true
+	}
+	for key, entry := range c.entries[connKey] {
+		drop := len(entry.tables) == 0
+		for _, table := range entry.tables {
+			if written[table] {
+				drop = true
+				break
+			}
+		}
+		if drop {
+			delete(c.entries[connKey], key)
+		}
+	}
+}
+
+// InvalidateForStatement drops the cached results a successfully executed statement may have made
+// stale. Plain SELECTs and SHOW/DESCRIBE leave the cache alone.
+func (c *QueryCache) InvalidateForStatement(details ConnectionDetails, query string) {
+	switch leadingKeyword(query) {
+	case "SHOW", "DESC", "DESCRIBE":
+		return
+	}
+	if isCacheableQuery(query) {
+		return
+	}
+	c.Invalidate(details, referencedTables(query)...)
+}
+
+// isCacheableQuery reports whether a statement is a SELECT that only reads: no data-modifying CTEs,
+// locking reads or INTO targets.
+func isCacheableQuery(query string) bool {
+	if !isSelectStatement(query) {
+		return false
+	}
+	for _, tok := range sqlTokens(query) {
+		if !tok.ident || tok.quoted {
+			continue
+		}
+		switch strings.ToUpper(tok.text) {
+		case "UPDATE", "DELETE", "INSERT", "REPLACE", "INTO", "FOR", "LOCK":
+			return false
+		}
+	}
+	return true
+}
+
+// Clear drops every cached result.
+func (c *QueryCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[string]map[string]*queryCacheEntry)
+}
+
+// copyResult returns a copy of a result whose rows can be modified without affecting the original.
+func copyResult(result *SQLResult) *SQLResult {
+	copied := *result
+	if result.Rows != nil {
+		copied.Rows = make([]map[string]any, len(result.Rows))
+		for i, row := range result.Rows {
+			copiedRow := make(map[string]any, len(row))
+			for col, v := range row {
+				copiedRow[col] = v
+			}
+			copied.Rows[i] = copiedRow
+		}
+	}
+	return &copied
+}
+
+// normalizeQuery collapses whitespace outside quoted literals and identifiers and drops trailing
+// semicolons, so trivially reformatted queries share a cache entry.
+func normalizeQuery(query string) string {
+	var b strings.Builder
+	space := false
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end := skipQuoted(query, i)
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			space = false
+			b.WriteString(query[i:end])
+			i = end
+		case unicode.IsSpace(rune(c)):
+			space = true
+			i++
+		default:
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			space = false
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return strings.TrimRight(b.String(), "; ")
+}
+
+// tableListStopWords end the table list following FROM or UPDATE
+var tableListStopWords = map[string]bool{
+	"WHERE": true, "GROUP": true, "ORDER": true, "LIMIT": true, "HAVING": true, "WINDOW": true,
+	"JOIN": true, "LEFT": true, "RIGHT": true, "INNER": true, "OUTER": true, "CROSS": true, "NATURAL": true,
+	"STRAIGHT_JOIN": true, "ON": true, "USING": true, "SET": true, "UNION": true, "EXCEPT": true,
+	"INTERSECT": true, "FOR": true, "LOCK": true, "INTO": true, "PARTITION": true, "USE": true,
+	"IGNORE": true, "FORCE": true, "AS": true, "SELECT": true, "VALUES": true,
+}
+
+// referencedTables returns the lower-case names, without database, of the tables a statement names
+// after FROM, JOIN, UPDATE, INTO and TABLE, including comma-separated table lists. It is a best
+// effort that may return extra names such as aliases, which only makes invalidation broader.
+func referencedTables(query string) []string {
+	tokens := sqlTokens(query)
+	seen := make(map[string]bool)
+	tables := make([]string, 0)
+
+	// readName reads a possibly qualified name at i, returning it and the index after it.
+	readName := func(i int) (string, int) {
+		if i >= len(tokens) || !tokens[i].ident {
+			return "", i
+		}
+		name := tokens[i].text
+		i++
+		for i+1 < len(tokens) && tokens[i].text == "." && tokens[i+1].ident {
+			name = tokens[i+1].text
+			i += 2
+		}
+		return strings.ToLower(name), i
+	}
+	add := func(name string) {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			tables = append(tables, name)
+		}
+	}
+
+	for i, tok := range tokens {
+		if !tok.ident || tok.quoted {
+			continue
+		}
+		switch strings.ToUpper(tok.text) {
+		case "JOIN", "INTO", "TABLE":
+			name, _ := readName(i + 1)
+			add(name)
+		case "FROM", "UPDATE":
+			name, j := readName(i + 1)
+			add(name)
+			for name != "" && j < len(tokens) {
+				// Skip an alias, then continue with the next table of a comma-separated list
+				if tokens[j].ident && !tokens[j].quoted && strings.EqualFold(tokens[j].text, "AS") {
+					j++
+				}
+				if j < len(tokens) && tokens[j].ident && (tokens[j].quoted || !tableListStopWords[strings.ToUpper(tokens[j].text)]) {
+					j++
+				}
+				if j >= len(tokens) || tokens[j].text != "," {
+					break
+				}
+				name, j = readName(j + 1)
+				add(name)
+			}
+		}
+	}
+	return tables
+}
+
+// sqlToken is a word, quoted identifier or punctuation character of a statement
+type sqlToken struct {
+	text   string
+	ident  bool // Bare word or backtick-quoted identifier
+	quoted bool // Backtick-quoted, so never a keyword
+}
+
+// sqlTokens splits a statement into tokens, skipping comments and string literals.
+func sqlTokens(query string) []sqlToken {
+	tokens := make([]sqlToken, 0)
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == '#' || (c == '-' && strings.HasPrefix(query[i:], "-- ")):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				return tokens
+			}
+			i += end
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				return tokens
+			}
+			i += end + 4
+		case c == '`':
+			end := skipQuoted(query, i)
+			name := strings.ReplaceAll(strings.TrimSuffix(query[i+1:end], "`"), "``", "`")
+			tokens = append(tokens, sqlToken{text: name, ident: true, quoted: true})
+			i = end
+		case c == '\'' || c == '"':
+			i = skipQuoted(query, i)
+			tokens = append(tokens, sqlToken{text: "'"})
+		case isNameChar(c) || c == '$':
+			end := i
+			for end < len(query) && (isNameChar(query[end]) || query[end] == '$') {
+				end++
+			}
+			tokens = append(tokens, sqlToken{text: query[i:end], ident: true})
+			i = end
+		case unicode.IsSpace(rune(c)):
+			i++
+		default:
+			tokens = append(tokens, sqlToken{text: string(c)})
+			i++
+		}
+	}
+	return tokens
+}
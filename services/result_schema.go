@@ -0,0 +1,45 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// GetQueryResultSchema returns the columns a SELECT would return, with their types, without reading any
+// rows: the query runs with LIMIT 0, or as a derived table limited to 0 rows when it already has a
+// LIMIT. Unqualified table names resolve in dbName when given. Statements other than SELECT are
+// rejected, since there is no way to learn their result columns without running them.
+func (s *DatabaseService) GetQueryResultSchema(ctx context.Context, details ConnectionDetails, dbName string, query string) ([]ColumnTypeInfo, error) {
+	if !isSelectStatement(query) {
+		return nil, fmt.Errorf("result schema is only available for SELECT statements")
+	}
+	if dbName != "" {
+		details.DBName = dbName
+	}
+
+	probe, ok := appendLimit(query, 0)
+	if !ok {
+		trimmed := strings.TrimRight(strings.TrimSpace(query), "; \t\r\n")
+		probe = fmt.Sprintf("SELECT * FROM (\n%s\n) AS %s LIMIT 0", trimmed, quoteIdentifier("result_schema"))
+	}
+
+	db, err := s.getDB(details)
+	if err != nil {
+		return nil, fmt.Errorf("connection setup failed for GetQueryResultSchema: %w", err)
+	}
+	rows, err := db.QueryContext(ctx, probe)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe query result: %w", err)
+	}
+	defer rows.Close()
+
+	columnTypes, err := columnTypeInfos(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(columnTypes) == 0 {
+		return nil, fmt.Errorf("query returns no columns")
+	}
+	return columnTypes, nil
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	mysql "github.com/go-sql-driver/mysql"
+)
+
+// Region unavailable retries. TiKV regions usually recover within seconds (leader election, splits,
+// a restarting store), so the waits are longer than for a plain network hiccup.
+const regionRetryAttempts = 4
+
+// regionRetryBaseDelay is the wait before the first retry, doubling for each further one
+var regionRetryBaseDelay = 500 * time.Millisecond
+
+// ErrRegionUnavailable is returned, wrapped, when a read keeps failing because TiKV regions are unavailable
+var ErrRegionUnavailable = errors.New("TiKV region is unavailable")
+
+// isRegionUnavailableError reports whether err is TiDB's transient "Region is unavailable" error.
+func isRegionUnavailableError(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == 9005 {
+		return true
+	}
+	return err != nil && strings.Contains(strings.ToLower(err.Error()), "region is unavailable")
+}
+
+// retryRegionUnavailable runs fn and, for read-only statements failing with a region unavailable
+// error, retries it with exponential backoff. When retries run out, the error wraps ErrRegionUnavailable.
+func retryRegionUnavailable[T any](ctx context.Context, query string, fn func() (T, error)) (T, error) {
+	result, err := fn()
+	if !isRegionUnavailableError(err) || !IsReadOnlyStatement(query) {
+		return result, err
+	}
+
+	delay := regionRetryBaseDelay
+	for attempt := 2; attempt <= regionRetryAttempts; attempt++ {
+		LogWarning("Region unavailable (attempt %d/%d), retrying in %v: %v", attempt-1, regionRetryAttempts, delay, err)
+		select {
+		case <-ctx.Done():
+			return result, ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+
+		result, err = fn()
+		if !isRegionUnavailableError(err) {
+			return result, err
+		}
+	}
+	return result, fmt.Errorf("%w after %d attempts: %v. The cluster may be rebalancing or a TiKV node may be down; wait a moment and retry, or check the cluster status",
+		ErrRegionUnavailable, regionRetryAttempts, err)
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	mysql "github.com/go-sql-driver/mysql"
+)
+
+// failingFn returns a function that fails with err the first failures times it's called, then succeeds.
+func failingFn(failures int, err error) (func() (string, error), *int) {
+	calls := 0
+	return func() (string, error) {
+		calls++
+		if calls <= failures {
+			return "", err
+		}
+		return "ok", nil
+	}, &calls
+}
+
+func TestRetryRegionUnavailable(t *testing.T) {
+	defer func(delay time.Duration) { regionRetryBaseDelay = delay }(regionRetryBaseDelay)
+	regionRetryBaseDelay = time.Millisecond
+
+	regionErr := &mysql.MySQLError{Number: 9005, Message: "Region is unavailable"}
+	tests := []struct {
+		name      string
+		query     string
+		failures  int
+		err       error
+		wantCalls int
+		wantErr   bool
+	}{
+		{name: "retried until it succeeds", query: "SELECT * FROM t", failures: 2, err: regionErr, wantCalls: 3},
+		{name: "message only", query: "SELECT 1", failures: 1, err: errors.New("other error: Region is unavailable"), wantCalls: 2},
+		{name: "gives up", query: "SELECT * FROM t", failures: 10, err: regionErr, wantCalls: regionRetryAttempts, wantErr: true},
+		{name: "not retryable", query: "SELECT * FROM t", failures: 1, err: &mysql.MySQLError{Number: 1146, Message: "Table doesn't exist"}, wantCalls: 1, wantErr: true},
+		{name: "writes aren't retried", query: "UPDATE t SET a = 1", failures: 1, err: regionErr, wantCalls: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn, calls := failingFn(tt.failures, tt.err)
+			result, err := retryRegionUnavailable(context.Background(), tt.query, fn)
+			if *calls != tt.wantCalls {
+				t.Errorf("%d calls, want %d", *calls, tt.wantCalls)
+			}
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("no error")
+				}
+				return
+			}
+			if err != nil || result != "ok" {
+				t.Errorf("result = %q, %v, want ok", result, err)
+			}
+		})
+	}
+}
+
+func TestRetryRegionUnavailableGivesUpWithErrRegionUnavailable(t *testing.T) {
+	defer func(delay time.Duration) { regionRetryBaseDelay = delay }(regionRetryBaseDelay)
+	regionRetryBaseDelay = time.Millisecond
+
+	fn, _ := failingFn(10, &mysql.MySQLError{Number: 9005, Message: "Region is unavailable"})
+	_, err := retryRegionUnavailable(context.Background(), "SELECT 1", fn)
+	if !errors.Is(err, ErrRegionUnavailable) {
+		t.Errorf("err = %v, want ErrRegionUnavailable", err)
+	}
+
+	fn, _ = failingFn(1, &mysql.MySQLError{Number: 1146})
+	if _, err := retryRegionUnavailable(context.Background(), "SELECT 1", fn); errors.Is(err, ErrRegionUnavailable) {
+		t.Errorf("err = %v, a non-retryable error must be returned as is", err)
+	}
+}
+
+func TestRetryRegionUnavailableStopsWhenCanceled(t *testing.T) {
+	defer func(delay time.Duration) { regionRetryBaseDelay = delay }(regionRetryBaseDelay)
+	regionRetryBaseDelay = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	fn, calls := failingFn(10, &mysql.MySQLError{Number: 9005, Message: "Region is unavailable"})
+	time.AfterFunc(10*time.Millisecond, cancel)
+	_, err := retryRegionUnavailable(ctx, "SELECT 1", fn)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if *calls != 1 {
+		t.Errorf("%d calls, want 1", *calls)
+	}
+}
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// RowStatement is a parameterized statement generated for a row edit
+type RowStatement struct {
+	SQL  string `json:"sql"`
+	Args []any  `json:"args"`
+}
+
+// RowEditResult holds the statement for a row edit and, unless it was a dry run, its execution result
+type RowEditResult struct {
+	Statement RowStatement `json:"statement"`
+	Executed  bool         `json:"executed"`
+	Result    *SQLResult   `json:"result,omitempty"`
+}
+
+// sortedKeys returns the keys of a column/value map in a stable order.
+func sortedKeys(values map[string]any) []string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// buildKeyCondition renders a WHERE condition matching every key column, using IS NULL for nil values.
+func buildKeyCondition(key map[string]any) (string, []any) {
+	conditions := make([]string, 0, len(key))
+	args := make([]any, 0, len(key))
+	for _, col := range sortedKeys(key) {
+		if key[col] == nil {
+			conditions = append(conditions, quoteIdentifier(col)+" IS NULL")
+			continue
+		}
+		conditions = append(conditions, quoteIdentifier(col)+" = ?")
+		args = append(args, key[col])
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+// BuildInsertSQL generates the INSERT statement for a new row. Values that are NULL sentinels
+// ({"$null": true}) are inserted as NULL.
+func BuildInsertSQL(dbName, tableName string, values map[string]any) (*RowStatement, error) {
+	if dbName == "" || tableName == "" {
+		return nil, fmt.Errorf("database and table name are required")
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("at least one column value is required")
+	}
+	values = resolveNullSentinels(values)
+
+	columns := sortedKeys(values)
+	quoted := make([]string, len(columns))
+	placeholders := make([]string, len(columns))
+	args := make([]any, len(columns))
+	for i, col := range columns {
+		quoted[i] = quoteIdentifier(col)
+		placeholders[i] = "?"
+		args[i] = values[col]
+	}
+
+	return &RowStatement{
+		SQL: fmt.Sprintf("INSERT INTO %s.%s (%s) VALUES (%s)",
+			quoteIdentifier(dbName), quoteIdentifier(tableName), strings.Join(quoted, ", "), strings.Join(placeholders, ", ")),
+		Args: args,
+	}, nil
+}
+
+// BuildUpdateSQL generates the UPDATE statement changing values of the row identified by key.
+// The statement is limited to a single row. NULL sentinels ({"$null": true}) in values set columns to
+// NULL, while empty strings are written as such.
+func BuildUpdateSQL(dbName, tableName string, key map[string]any, values map[string]any) (*RowStatement, error) {
+	if dbName == "" || tableName == "" {
+		return nil, fmt.Errorf("database and table name are required")
+	}
+	if len(key) == 0 {
+		return nil, fmt.Errorf("a row key is required to update a row")
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("at least one column value is required")
+	}
+	key, values = resolveNullSentinels(key), resolveNullSentinels(values)
+
+	columns := sortedKeys(values)
+	assignments := make([]string, len(columns))
+	args := make([]any, 0, len(columns)+len(key))
+	for i, col := range columns {
+		assignments[i] = quoteIdentifier(col) + " = ?"
+		args = append(args, values[col])
+	}
+	where, keyArgs := buildKeyCondition(key)
+
+	return &RowStatement{
+		SQL: fmt.Sprintf("UPDATE %s.%s SET %s WHERE %s LIMIT 1",
+			quoteIdentifier(dbName), quoteIdentifier(tableName), strings.Join(assignments, ", "), where),
+		Args: append(args, keyArgs...),
+	}, nil
+}
+
+// BuildDeleteSQL generates the DELETE statement for the row identified by key.
+// The statement is limited to a single row.
+func BuildDeleteSQL(dbName, tableName string, key map[string]any) (*RowStatement, error) {
+	if dbName == "" || tableName == "" {
+		return nil, fmt.Errorf("database and table name are required")
+	}
+	if len(key) == 0 {
+		return nil, fmt.Errorf("a row key is required to delete a row")
+	}
+	key = resolveNullSentinels(key)
+
+	where, args := buildKeyCondition(key)
+	return &RowStatement{
+		SQL:  fmt.Sprintf("DELETE FROM %s.%s WHERE %s LIMIT 1", quoteIdentifier(dbName), quoteIdentifier(tableName), where),
+		Args: args,
+	}, nil
+}
+
+// executeRowStatement runs the statement unless dryRun is set.
+func (s *DatabaseService) executeRowStatement(ctx context.Context, details ConnectionDetails, stmt *RowStatement, dryRun bool) (*RowEditResult, error) {
+	result := &RowEditResult{Statement: *stmt}
+	if dryRun {
+		return result, nil
+	}
+
+	sqlResult, err := s.ExecuteSQL(ctx, details, stmt.SQL, stmt.Args...)
+	if err != nil {
+		return nil, err
+	}
+	result.Executed = true
+	result.Result = sqlResult
+	return result, nil
+}
+
+// InsertRow inserts a row into a table. With dryRun, the statement is only generated.
+func (s *DatabaseService) InsertRow(ctx context.Context, details ConnectionDetails, dbName string, tableName string, values map[string]any, dryRun bool) (*RowEditResult, error) {
+	if dbName == "" {
+		dbName = details.DBName
+	}
+	stmt, err := BuildInsertSQL(dbName, tableName, values)
+	if err != nil {
+		return nil, err
+	}
+	return s.executeRowStatement(ctx, details, stmt, dryRun)
+}
+
+// UpdateRow updates the row identified by key, which must consist of the table's row key columns (see
+// ResolveRowKey). With dryRun, the statement is only generated.
+func (s *DatabaseService) UpdateRow(ctx context.Context, details ConnectionDetails, dbName string, tableName string, key map[string]any, values map[string]any, dryRun bool) (*RowEditResult, error) {
+	if dbName == "" {
+		dbName = details.DBName
+	}
+	if err := s.checkRowKey(ctx, details, dbName, tableName, key); err != nil {
+		return nil, err
+	}
+	stmt, err := BuildUpdateSQL(dbName, tableName, key, values)
+	if err != nil {
+		return nil, err
+	}
+	return s.executeRowStatement(ctx, details, stmt, dryRun)
+}
+
+// DeleteRow deletes the row identified by key, which must consist of the table's row key columns (see
+// ResolveRowKey). With dryRun, the statement is only generated.
+func (s *DatabaseService) DeleteRow(ctx context.Context, details ConnectionDetails, dbName string, tableName string, key map[string]any, dryRun bool) (*RowEditResult, error) {
+	if dbName == "" {
+		dbName = details.DBName
+	}
+	if err := s.checkRowKey(ctx, details, dbName, tableName, key); err != nil {
+		return nil, err
+	}
+	stmt, err := BuildDeleteSQL(dbName, tableName, key)
+	if err != nil {
+		return nil, err
+	}
+	return s.executeRowStatement(ctx, details, stmt, dryRun)
+}
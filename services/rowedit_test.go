@@ -0,0 +1,58 @@
+package services
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// decodeValues decodes a column/value map the way values sent by the frontend arrive.
+func decodeValues(t *testing.T, data string) map[string]any {
+	t.Helper()
+	var values map[string]any
+	if err := json.Unmarshal([]byte(data), &values); err != nil {
+		t.Fatal(err)
+	}
+	return values
+}
+
+func TestBuildInsertSQLNullAndEmpty(t *testing.T) {
+	stmt, err := BuildInsertSQL("shop", "people", decodeValues(t, `{"name":"","nickname":{"$null":true},"age":7}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "INSERT INTO `shop`.`people` (`age`, `name`, `nickname`) VALUES (?, ?, ?)"; stmt.SQL != want {
+		t.Errorf("SQL = %s, want %s", stmt.SQL, want)
+	}
+	if want := []any{float64(7), "", nil}; !reflect.DeepEqual(stmt.Args, want) {
+		t.Errorf("args = %#v, want %#v", stmt.Args, want)
+	}
+}
+
+func TestBuildUpdateSQLNullAndEmpty(t *testing.T) {
+	key := decodeValues(t, `{"id":1,"tenant":{"$null":true}}`)
+	stmt, err := BuildUpdateSQL("shop", "people", key, decodeValues(t, `{"name":"","nickname":{"$null":true}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "UPDATE `shop`.`people` SET `name` = ?, `nickname` = ? WHERE `id` = ? AND `tenant` IS NULL LIMIT 1"
+	if stmt.SQL != want {
+		t.Errorf("SQL = %s, want %s", stmt.SQL, want)
+	}
+	if want := []any{"", nil, float64(1)}; !reflect.DeepEqual(stmt.Args, want) {
+		t.Errorf("args = %#v, want %#v", stmt.Args, want)
+	}
+}
+
+func TestBuildDeleteSQLNullKey(t *testing.T) {
+	stmt, err := BuildDeleteSQL("shop", "people", map[string]any{"code": "", "region": NullSentinel{Null: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "DELETE FROM `shop`.`people` WHERE `code` = ? AND `region` IS NULL LIMIT 1"; stmt.SQL != want {
+		t.Errorf("SQL = %s, want %s", stmt.SQL, want)
+	}
+	if want := []any{""}; !reflect.DeepEqual(stmt.Args, want) {
+		t.Errorf("args = %#v, want %#v", stmt.Args, want)
+	}
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Kinds of row keys, in order of preference
+const (
+	RowKeyPrimary = "primary" // Primary key
+	RowKeyUnique  = "unique"  // Unique index over NOT NULL columns
+	RowKeyRowID   = "rowid"   // TiDB's hidden _tidb_rowid
+)
+
+// TiDBRowIDColumn is the hidden row handle of TiDB tables without a clustered primary key
+const TiDBRowIDColumn = "_tidb_rowid"
+
+// RowKey identifies the columns that safely address a single row of a table
+type RowKey struct {
+	Columns   []string `json:"columns"`
+	Kind      string   `json:"kind"`                // RowKeyPrimary, RowKeyUnique or RowKeyRowID
+	IndexName string   `json:"indexName,omitempty"` // Name of the unique index for RowKeyUnique
+}
+
+// selectList returns the select list that includes the key columns: the hidden row ID isn't part of *.
+func (k *RowKey) selectList() string {
+	if k.Kind == RowKeyRowID {
+		return "*, " + quoteIdentifier(TiDBRowIDColumn)
+	}
+	return "*"
+}
+
+// matches reports whether the columns of a row-addressing map are exactly the key columns.
+func (k *RowKey) matches(key map[string]any) bool {
+	if len(key) != len(k.Columns) {
+		return false
+	}
+	for _, col := range k.Columns {
+		found := false
+		for name := range key {
+			if strings.EqualFold(name, col) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// ResolveRowKey returns the key used to address single rows of a table: the primary key, else a unique
+// index whose columns are all NOT NULL (the one with the fewest columns), else TiDB's hidden
+// _tidb_rowid. Tables with none of these have no safe row key and an error is returned.
+func (s *DatabaseService) ResolveRowKey(ctx context.Context, details ConnectionDetails, dbName string, tableName string) (*RowKey, error) {
+	targetDB := dbName
+	if targetDB == "" {
+		targetDB = details.DBName
+	}
+	if targetDB == "" {
+		return nil, fmt.Errorf("database name is required either explicitly or in connection details")
+	}
+	if tableName == "" {
+		return nil, fmt.Errorf("table name is required")
+	}
+
+	pkColumns, err := s.getPrimaryKeyColumns(ctx, details, targetDB, tableName)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkColumns) > 0 {
+		return &RowKey{Columns: pkColumns, Kind: RowKeyPrimary}, nil
+	}
+
+	result, err := s.ExecuteSQL(ctx, details, `
+		SELECT s.INDEX_NAME, s.COLUMN_NAME, c.IS_NULLABLE
+		FROM information_schema.STATISTICS s
+		JOIN information_schema.COLUMNS c
+			ON c.TABLE_SCHEMA = s.TABLE_SCHEMA AND c.TABLE_NAME = s.TABLE_NAME AND c.COLUMN_NAME = s.COLUMN_NAME
+		WHERE s.TABLE_SCHEMA = ? AND s.TABLE_NAME = ? AND s.NON_UNIQUE = 0
+		ORDER BY s.INDEX_NAME, s.SEQ_IN_INDEX`, targetDB, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unique indexes for '%s.%s': %w", targetDB, tableName, err)
+	}
+	indexColumns := make(map[string][]string)
+	nullable := make(map[string]bool)
+	for _, row := range result.Rows {
+		name := valueString(row["INDEX_NAME"])
+		// Expression indexes have no column name and can't be used as a key
+		column := valueString(row["COLUMN_NAME"])
+		if column == "" || strings.EqualFold(valueString(row["IS_NULLABLE"]), "YES") {
+			nullable[name] = true
+		}
+		indexColumns[name] = append(indexColumns[name], column)
+	}
+	candidates := make([]string, 0, len(indexColumns))
+	for name := range indexColumns {
+		if !nullable[name] {
+			candidates = append(candidates, name)
+		}
+	}
+	if len(candidates) > 0 {
+		sort.Slice(candidates, func(i, j int) bool {
+			a, b := candidates[i], candidates[j]
+			if len(indexColumns[a]) != len(indexColumns[b]) {
+				return len(indexColumns[a]) < len(indexColumns[b])
+			}
+			return a < b
+		})
+		name := candidates[0]
+		return &RowKey{Columns: indexColumns[name], Kind: RowKeyUnique, IndexName: name}, nil
+	}
+
+	// Only TiDB tables without a clustered primary key have the hidden row ID
+	probe := fmt.Sprintf("SELECT %s FROM %s.%s LIMIT 0", quoteIdentifier(TiDBRowIDColumn), quoteIdentifier(targetDB), quoteIdentifier(tableName))
+	if _, err := s.ExecuteSQL(ctx, details, probe); err == nil {
+		return &RowKey{Columns: []string{TiDBRowIDColumn}, Kind: RowKeyRowID}, nil
+	}
+
+	return nil, fmt.Errorf("table '%s.%s' has no primary key, NOT NULL unique index or row ID to safely identify rows", targetDB, tableName)
+}
+
+// checkRowKey verifies that key addresses rows of a table by its resolved row key, so an edit can never
+// match more or other rows than intended.
+func (s *DatabaseService) checkRowKey(ctx context.Context, details ConnectionDetails, dbName string, tableName string, key map[string]any) error {
+	rowKey, err := s.ResolveRowKey(ctx, details, dbName, tableName)
+	if err != nil {
+		return err
+	}
+	if !rowKey.matches(key) {
+		return fmt.Errorf("row key must consist of exactly the %s key columns (%s) of '%s.%s', got (%s)",
+			rowKey.Kind, strings.Join(rowKey.Columns, ", "), dbName, tableName, strings.Join(sortedKeys(key), ", "))
+	}
+	return nil
+}
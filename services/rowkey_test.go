@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// fakeTableKeys answers the queries ResolveRowKey runs for a table with the given primary key columns,
+// unique index columns (index name, column, IS_NULLABLE) and hidden row ID.
+func fakeTableKeys(pk []string, unique [][3]string, rowID bool) func(context.Context, string, []any) fakeResponse {
+	return func(_ context.Context, query string, _ []any) fakeResponse {
+		switch {
+		case strings.Contains(query, "KEY_COLUMN_USAGE"):
+			response := fakeResponse{columns: []string{"COLUMN_NAME"}}
+			for _, col := range pk {
+				response.rows = append(response.rows, []driver.Value{[]byte(col)})
+			}
+			return response
+		case strings.Contains(query, "STATISTICS"):
+			response := fakeResponse{columns: []string{"INDEX_NAME", "COLUMN_NAME", "IS_NULLABLE"}}
+			for _, row := range unique {
+				response.rows = append(response.rows, []driver.Value{[]byte(row[0]), []byte(row[1]), []byte(row[2])})
+			}
+			return response
+		case strings.Contains(query, TiDBRowIDColumn):
+			if !rowID {
+				return fakeResponse{err: errors.New("Error 1054 (42S22): Unknown column '_tidb_rowid' in 'field list'")}
+			}
+			return fakeResponse{columns: []string{TiDBRowIDColumn}}
+		}
+		return fakeResponse{err: errors.New("unexpected query: " + query)}
+	}
+}
+
+func TestResolveRowKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		pk     []string
+		unique [][3]string
+		rowID  bool
+		want   *RowKey
+	}{
+		{
+			name:   "primary key",
+			pk:     []string{"tenant", "id"},
+			unique: [][3]string{{"uk_email", "email", "NO"}},
+			rowID:  true,
+			want:   &RowKey{Columns: []string{"tenant", "id"}, Kind: RowKeyPrimary},
+		},
+		{
+			name: "narrowest NOT NULL unique index",
+			unique: [][3]string{
+				{"uk_name", "first", "NO"}, {"uk_name", "last", "NO"},
+				{"uk_phone", "phone", "YES"},
+				{"uk_email", "email", "NO"},
+			},
+			rowID: true,
+			want:  &RowKey{Columns: []string{"email"}, Kind: RowKeyUnique, IndexName: "uk_email"},
+		},
+		{
+			name:   "nullable unique index falls back to row ID",
+			unique: [][3]string{{"uk_phone", "phone", "YES"}},
+			rowID:  true,
+			want:   &RowKey{Columns: []string{TiDBRowIDColumn}, Kind: RowKeyRowID},
+		},
+		{
+			name:   "expression index isn't a key",
+			unique: [][3]string{{"uk_lower", "", "NO"}},
+			rowID:  true,
+			want:   &RowKey{Columns: []string{TiDBRowIDColumn}, Kind: RowKeyRowID},
+		},
+		{
+			name: "no key",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, _ := newFakeDB(t, fakeTableKeys(tt.pk, tt.unique, tt.rowID))
+			details := ConnectionDetails{ID: "keys", Host: "127.0.0.1"}
+			s := NewDatabaseService()
+			useFakeDB(s, details, db)
+
+			got, err := s.ResolveRowKey(context.Background(), details, "shop", "orders")
+			if tt.want == nil {
+				if err == nil {
+					t.Fatalf("key = %+v, want an error for a table without a safe key", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got.Columns, tt.want.Columns) || got.Kind != tt.want.Kind || got.IndexName != tt.want.IndexName {
+				t.Errorf("key = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRowKeyMatches(t *testing.T) {
+	key := &RowKey{Columns: []string{"tenant", "id"}, Kind: RowKeyPrimary}
+	tests := []struct {
+		row  map[string]any
+		want bool
+	}{
+		{map[string]any{"tenant": 1, "id": 2}, true},
+		{map[string]any{"TENANT": 1, "Id": 2}, true},
+		{map[string]any{"id": 2}, false},
+		{map[string]any{"tenant": 1, "id": 2, "name": "x"}, false},
+		{map[string]any{"tenant": 1, "name": "x"}, false},
+	}
+	for _, tt := range tests {
+		if got := key.matches(tt.row); got != tt.want {
+			t.Errorf("matches(%v) = %v, want %v", tt.row, got, tt.want)
+		}
+	}
+}
+
+func TestRowKeySelectList(t *testing.T) {
+	if got := (&RowKey{Columns: []string{"id"}, Kind: RowKeyPrimary}).selectList(); got != "*" {
+		t.Errorf("primary key select list = %s, want *", got)
+	}
+	if got := (&RowKey{Columns: []string{TiDBRowIDColumn}, Kind: RowKeyRowID}).selectList(); got != "*, `_tidb_rowid`" {
+		t.Errorf("row ID select list = %s, want the row ID after *", got)
+	}
+}
@@ -0,0 +1,231 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// SchemaDiff lists what changed between two metadata snapshots of a connection
+type SchemaDiff struct {
+	OldExtracted      time.Time      `json:"oldExtracted"`
+	NewExtracted      time.Time      `json:"newExtracted"`
+	AddedDatabases    []string       `json:"addedDatabases"`
+	RemovedDatabases  []string       `json:"removedDatabases"`
+	ModifiedDatabases []DatabaseDiff `json:"modifiedDatabases"`
+}
+
+// DatabaseDiff lists the table changes within a database present in both snapshots
+type DatabaseDiff struct {
+	Name           string      `json:"name"`
+	AddedTables    []string    `json:"addedTables"`
+	RemovedTables  []string    `json:"removedTables"`
+	ModifiedTables []TableDiff `json:"modifiedTables"`
+}
+
+// TableDiff lists the column, index and foreign key changes within a table present in both snapshots.
+// An index or foreign key whose definition changed is reported as removed and added.
+type TableDiff struct {
+	Name               string       `json:"name"`
+	AddedColumns       []string     `json:"addedColumns"`
+	RemovedColumns     []string     `json:"removedColumns"`
+	ModifiedColumns    []ColumnDiff `json:"modifiedColumns"`
+	AddedIndexes       []string     `json:"addedIndexes"`
+	RemovedIndexes     []string     `json:"removedIndexes"`
+	AddedForeignKeys   []string     `json:"addedForeignKeys"`
+	RemovedForeignKeys []string     `json:"removedForeignKeys"`
+}
+
+// ColumnDiff describes how a column present in both snapshots changed
+type ColumnDiff struct {
+	Name        string `json:"name"`
+	OldType     string `json:"oldType"`
+	NewType     string `json:"newType"`
+	OldNullable bool   `json:"oldNullable"`
+	NewNullable bool   `json:"newNullable"`
+}
+
+// IsEmpty reports whether the snapshots describe the same schema.
+func (d *SchemaDiff) IsEmpty() bool {
+	return len(d.AddedDatabases) == 0 && len(d.RemovedDatabases) == 0 && len(d.ModifiedDatabases) == 0
+}
+
+// DiffMetadata compares two metadata snapshots of a connection. Descriptions, comments and physical stats
+// are ignored; only structural changes are reported.
+func (s *MetadataService) DiffMetadata(old, new *ConnectionMetadata) *SchemaDiff {
+	diff := &SchemaDiff{
+		AddedDatabases:    make([]string, 0),
+		RemovedDatabases:  make([]string, 0),
+		ModifiedDatabases: make([]DatabaseDiff, 0),
+	}
+	if old == nil {
+		old = &ConnectionMetadata{}
+	}
+	if new == nil {
+		new = &ConnectionMetadata{}
+	}
+	diff.OldExtracted = old.LastExtracted
+	diff.NewExtracted = new.LastExtracted
+
+	for _, name := range slices.Sorted(maps.Keys(new.Databases)) {
+		oldDB, ok := old.Databases[name]
+		if !ok {
+			diff.AddedDatabases = append(diff.AddedDatabases, name)
+			continue
+		}
+		if dbDiff := diffDatabase(oldDB, new.Databases[name]); dbDiff != nil {
+			diff.ModifiedDatabases = append(diff.ModifiedDatabases, *dbDiff)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(old.Databases)) {
+		if _, ok := new.Databases[name]; !ok {
+			diff.RemovedDatabases = append(diff.RemovedDatabases, name)
+		}
+	}
+	return diff
+}
+
+// DiffAgainstPersisted compares the last saved metadata file of a connection with its in-memory metadata,
+// showing what a fresh extraction changed before it is saved.
+func (s *MetadataService) DiffAgainstPersisted(connectionID string) (*SchemaDiff, error) {
+	var persisted ConnectionMetadata
+	data, err := s.readMetadataFile(connectionID)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read metadata file: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &persisted); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+		}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	current, exists := s.metadata[connectionID]
+	if !exists {
+		return nil, fmt.Errorf("metadata not loaded for connection: %s", connectionID)
+	}
+	return s.DiffMetadata(&persisted, current), nil
+}
+
+func diffDatabase(old, new DatabaseMetadata) *DatabaseDiff {
+	diff := DatabaseDiff{
+		Name:           new.Name,
+		AddedTables:    make([]string, 0),
+		RemovedTables:  make([]string, 0),
+		ModifiedTables: make([]TableDiff, 0),
+	}
+
+	oldTables := make(map[string]Table, len(old.Tables))
+	for _, table := range old.Tables {
+		oldTables[table.Name] = table
+	}
+	newTables := make(map[string]bool, len(new.Tables))
+	for _, table := range new.Tables {
+		newTables[table.Name] = true
+		oldTable, ok := oldTables[table.Name]
+		if !ok {
+			diff.AddedTables = append(diff.AddedTables, table.Name)
+			continue
+		}
+		if tableDiff := diffTable(oldTable, table); tableDiff != nil {
+			diff.ModifiedTables = append(diff.ModifiedTables, *tableDiff)
+		}
+	}
+	for _, table := range old.Tables {
+		if !newTables[table.Name] {
+			diff.RemovedTables = append(diff.RemovedTables, table.Name)
+		}
+	}
+
+	if len(diff.AddedTables) == 0 && len(diff.RemovedTables) == 0 && len(diff.ModifiedTables) == 0 {
+		return nil
+	}
+	return &diff
+}
+
+func diffTable(old, new Table) *TableDiff {
+	diff := TableDiff{
+		Name:            new.Name,
+		AddedColumns:    make([]string, 0),
+		RemovedColumns:  make([]string, 0),
+		ModifiedColumns: make([]ColumnDiff, 0),
+	}
+
+	oldColumns := make(map[string]Column, len(old.Columns))
+	for _, col := range old.Columns {
+		oldColumns[col.Name] = col
+	}
+	newColumns := make(map[string]bool, len(new.Columns))
+	for _, col := range new.Columns {
+		newColumns[col.Name] = true
+		oldCol, ok := oldColumns[col.Name]
+		if !ok {
+			diff.AddedColumns = append(diff.AddedColumns, col.Name)
+			continue
+		}
+		if !strings.EqualFold(oldCol.DataType, col.DataType) || oldCol.IsNullable != col.IsNullable {
+			diff.ModifiedColumns = append(diff.ModifiedColumns, ColumnDiff{
+				Name:        col.Name,
+				OldType:     oldCol.DataType,
+				NewType:     col.DataType,
+				OldNullable: oldCol.IsNullable,
+				NewNullable: col.IsNullable,
+			})
+		}
+	}
+	for _, col := range old.Columns {
+		if !newColumns[col.Name] {
+			diff.RemovedColumns = append(diff.RemovedColumns, col.Name)
+		}
+	}
+
+	diff.AddedIndexes, diff.RemovedIndexes = diffDefinitions(indexDefinitions(old.Indexes), indexDefinitions(new.Indexes))
+	diff.AddedForeignKeys, diff.RemovedForeignKeys = diffDefinitions(foreignKeyDefinitions(old.ForeignKeys), foreignKeyDefinitions(new.ForeignKeys))
+
+	if len(diff.AddedColumns) == 0 && len(diff.RemovedColumns) == 0 && len(diff.ModifiedColumns) == 0 &&
+		len(diff.AddedIndexes) == 0 && len(diff.RemovedIndexes) == 0 &&
+		len(diff.AddedForeignKeys) == 0 && len(diff.RemovedForeignKeys) == 0 {
+		return nil
+	}
+	return &diff
+}
+
+// indexDefinitions maps index names to a comparable rendering of their definition
+func indexDefinitions(indexes []Index) map[string]string {
+	defs := make(map[string]string, len(indexes))
+	for _, idx := range indexes {
+		defs[idx.Name] = fmt.Sprintf("%t(%s)", idx.IsUnique, strings.Join(idx.ColumnNames, ","))
+	}
+	return defs
+}
+
+// foreignKeyDefinitions maps foreign key names to a comparable rendering of their definition
+func foreignKeyDefinitions(fks []ForeignKey) map[string]string {
+	defs := make(map[string]string, len(fks))
+	for _, fk := range fks {
+		defs[fk.Name] = fmt.Sprintf("(%s)->%s(%s)", strings.Join(fk.ColumnNames, ","), fk.RefTableName, strings.Join(fk.RefColumnNames, ","))
+	}
+	return defs
+}
+
+// diffDefinitions returns the sorted names added to or removed from old, counting a changed definition as both.
+func diffDefinitions(old, new map[string]string) (added, removed []string) {
+	added, removed = make([]string, 0), make([]string, 0)
+	for _, name := range slices.Sorted(maps.Keys(new)) {
+		if def, ok := old[name]; !ok || def != new[name] {
+			added = append(added, name)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(old)) {
+		if def, ok := new[name]; !ok || def != old[name] {
+			removed = append(removed, name)
+		}
+	}
+	return added, removed
+}
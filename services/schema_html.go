@@ -0,0 +1,235 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+)
+
+// schemaHTMLNode is a table in the exported diagram
+type schemaHTMLNode struct {
+	ID      string   `json:"id"`
+	Tooltip string   `json:"tooltip"`
+	Columns []string `json:"columns"` // Display lines, e.g. "id bigint PK"
+}
+
+// schemaHTMLLink is a foreign key in the exported diagram
+type schemaHTMLLink struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Label  string `json:"label"`
+}
+
+var schemaHTMLTemplate = template.Must(template.New("schema").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+  html, body { margin: 0; height: 100%; font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", sans-serif; background: #fafafa; }
+  header { padding: 8px 16px; border-bottom: 1px solid #ddd; background: #fff; font-size: 14px; }
+  header span { color: #777; margin-left: 8px; }
+  svg { width: 100%; height: calc(100% - 38px); cursor: grab; }
+  .node rect { fill: #fff; stroke: #4f46e5; stroke-width: 1.5; rx: 4; }
+  .node text { font-size: 11px; fill: #222; pointer-events: none; }
+  .node .title { font-weight: 600; }
+  .link { stroke: #999; stroke-width: 1.2; fill: none; marker-end: url(#arrow); }
+  .link-label { font-size: 9px; fill: #777; }
+</style>
+</head>
+<body>
+<header><strong>{{.Title}}</strong><span>{{.Subtitle}}</span></header>
+<svg id="diagram">
+  <defs><marker id="arrow" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="8" markerHeight="8" orient="auto-start-reverse"><path d="M0,0 L10,5 L0,10 z" fill="#999"/></marker></defs>
+  <g id="viewport"></g>
+</svg>
+<script>
+(function () {
+  const graph = {{.Graph}};
+  const NS = "http://www.w3.org/2000/svg";
+  const svg = document.getElementById("diagram");
+  const viewport = document.getElementById("viewport");
+  const lineHeight = 14, padding = 6, width = 200;
+
+  function el(name, attrs, parent) {
+    const e = document.createElementNS(NS, name);
+    for (const k in attrs) e.setAttribute(k, attrs[k]);
+    if (parent) parent.appendChild(e);
+    return e;
+  }
+
+  // Initial placement on a grid, refined by a short force simulation
+  const cols = Math.max(1, Math.ceil(Math.sqrt(graph.nodes.length)));
+  const byId = {};
+  graph.nodes.forEach((n, i) => {
+    n.h = (n.columns.length + 1) * lineHeight + padding * 2;
+    n.x = (i % cols) * (width + 80) + 40;
+    n.y = Math.floor(i / cols) * 260 + 40;
+    byId[n.id] = n;
+  });
+  const links = graph.links.filter(l => byId[l.source] && byId[l.target]);
+
+  for (let iter = 0; iter < 300; iter++) {
+    const alpha = 1 - iter / 300;
+    for (const a of graph.nodes) for (const b of graph.nodes) {
+      if (a === b) continue;
+      const dx = a.x - b.x, dy = a.y - b.y, d2 = Math.max(dx * dx + dy * dy, 1);
+      const f = 60000 / d2 * alpha;
+      a.x += dx / Math.sqrt(d2) * f; a.y += dy / Math.sqrt(d2) * f;
+    }
+    for (const l of links) {
+      const s = byId[l.source], t = byId[l.target];
+      if (s === t) continue;
+      const dx = t.x - s.x, dy = t.y - s.y, d = Math.max(Math.hypot(dx, dy), 1);
+      const f = (d - 300) * 0.02 * alpha;
+      s.x += dx / d * f; s.y += dy / d * f; t.x -= dx / d * f; t.y -= dy / d * f;
+    }
+  }
+
+  const linkEls = links.map(l => {
+    const path = el("path", { class: "link" }, viewport);
+    el("title", {}, path).textContent = l.label;
+    const label = el("text", { class: "link-label" }, viewport);
+    label.textContent = l.label;
+    return { l, path, label };
+  });
+
+  const nodeEls = graph.nodes.map(n => {
+    const g = el("g", { class: "node" }, viewport);
+    el("title", {}, g).textContent = n.tooltip;
+    el("rect", { width: width, height: n.h }, g);
+    const title = el("text", { class: "title", x: padding, y: padding + 11 }, g);
+    title.textContent = n.id;
+    n.columns.forEach((c, i) => {
+      el("text", { x: padding, y: padding + 11 + (i + 1) * lineHeight }, g).textContent = c;
+    });
+    return { n, g };
+  });
+
+  function render() {
+    nodeEls.forEach(({ n, g }) => g.setAttribute("transform", "translate(" + n.x + "," + n.y + ")"));
+    linkEls.forEach(({ l, path, label }) => {
+      const s = byId[l.source], t = byId[l.target];
+      let d;
+      if (s === t) {
+        const x = s.x + width, y = s.y + 10;
+        d = "M" + x + "," + y + " c 50,-30 50,50 0,20";
+      } else {
+        const x1 = s.x + width / 2, y1 = s.y + s.h / 2, x2 = t.x + width / 2, y2 = t.y + t.h / 2;
+        // Stop at the target's border so the arrow stays visible
+        const dx = x2 - x1, dy = y2 - y1;
+        const k = Math.min(Math.abs((width / 2) / (dx || 1e-9)), Math.abs((t.h / 2) / (dy || 1e-9)), 1);
+        d = "M" + x1 + "," + y1 + " L" + (x2 - dx * k) + "," + (y2 - dy * k);
+        label.setAttribute("x", (x1 + x2) / 2);
+        label.setAttribute("y", (y1 + y2) / 2);
+      }
+      path.setAttribute("d", d);
+    });
+  }
+  render();
+
+  // Pan, zoom and node dragging
+  let view = { x: 0, y: 0, k: 1 }, drag = null;
+  function applyView() { viewport.setAttribute("transform", "translate(" + view.x + "," + view.y + ") scale(" + view.k + ")"); }
+  svg.addEventListener("wheel", e => {
+    e.preventDefault();
+    const k = Math.min(4, Math.max(0.1, view.k * (e.deltaY < 0 ? 1.1 : 0.9)));
+    view.x = e.offsetX - (e.offsetX - view.x) * k / view.k;
+    view.y = e.offsetY - (e.offsetY - view.y) * k / view.k;
+    view.k = k; applyView();
+  }, { passive: false });
+  svg.addEventListener("mousedown", e => {
+    const hit = nodeEls.find(({ g }) => g.contains(e.target));
+    drag = { node: hit ? hit.n : null, x: e.clientX, y: e.clientY };
+  });
+  window.addEventListener("mousemove", e => {
+    if (!drag) return;
+    const dx = e.clientX - drag.x, dy = e.clientY - drag.y;
+    drag.x = e.clientX; drag.y = e.clientY;
+    if (drag.node) { drag.node.x += dx / view.k; drag.node.y += dy / view.k; render(); }
+    else { view.x += dx; view.y += dy; applyView(); }
+  });
+  window.addEventListener("mouseup", () => { drag = null; });
+})();
+</script>
+</body>
+</html>
+`))
+
+// ExportSchemaHTML renders the foreign key graph of a database from cached metadata as a self-contained
+// HTML page with an interactive (pan, zoom, drag) diagram. Node tooltips list each table's columns with
+// their primary and foreign keys. Views are left out unless includeViews is set.
+func (s *MetadataService) ExportSchemaHTML(connectionID, dbName string, includeViews bool) (string, error) {
+	dbMeta, err := s.getCachedDatabase(connectionID, dbName)
+	if err != nil {
+		return "", err
+	}
+
+	graph := struct {
+		Nodes []schemaHTMLNode `json:"nodes"`
+		Links []schemaHTMLLink `json:"links"`
+	}{
+		Nodes: make([]schemaHTMLNode, 0, len(dbMeta.Tables)),
+		Links: make([]schemaHTMLLink, 0),
+	}
+
+	for _, table := range dbMeta.Tables {
+		if table.IsView && !includeViews {
+			continue
+		}
+		fkColumns := make(map[string]string)
+		for _, fk := range table.ForeignKeys {
+			for i, col := range fk.ColumnNames {
+				ref := fk.RefTableName
+				if i < len(fk.RefColumnNames) {
+					ref += "." + fk.RefColumnNames[i]
+				}
+				fkColumns[col] = ref
+			}
+			graph.Links = append(graph.Links, schemaHTMLLink{
+				Source: table.Name,
+				Target: fk.RefTableName,
+				Label:  fmt.Sprintf("%s (%s → %s)", fk.Name, strings.Join(fk.ColumnNames, ", "), strings.Join(fk.RefColumnNames, ", ")),
+			})
+		}
+
+		node := schemaHTMLNode{ID: table.Name, Columns: make([]string, 0, len(table.Columns))}
+		var tooltip strings.Builder
+		tooltip.WriteString(table.Name)
+		if table.IsView {
+			tooltip.WriteString(" (view)")
+		}
+		if table.DBComment != "" {
+			tooltip.WriteString(" - " + table.DBComment)
+		}
+		for _, col := range table.Columns {
+			line := col.Name + " " + col.DataType
+			if col.IsPrimaryKey {
+				line += " PK"
+			}
+			if ref, ok := fkColumns[col.Name]; ok {
+				line += " FK → " + ref
+			}
+			if !col.IsNullable {
+				line += " NOT NULL"
+			}
+			node.Columns = append(node.Columns, line)
+			tooltip.WriteString("\n" + line)
+		}
+		node.Tooltip = tooltip.String()
+		graph.Nodes = append(graph.Nodes, node)
+	}
+
+	var buf bytes.Buffer
+	err = schemaHTMLTemplate.Execute(&buf, map[string]any{
+		"Title":    dbName + " schema",
+		"Subtitle": fmt.Sprintf("%d tables, %d foreign keys, exported %s", len(graph.Nodes), len(graph.Links), time.Now().Format("2006-01-02 15:04")),
+		"Graph":    graph,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to render schema HTML: %w", err)
+	}
+	return buf.String(), nil
+}
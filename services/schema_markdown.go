@@ -0,0 +1,84 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ExportMarkdown renders a data dictionary of a database from cached metadata as Markdown: one section per
+// table, in extraction order, with its columns and foreign keys. Database and table descriptions become
+// prose above their sections.
+func (s *MetadataService) ExportMarkdown(connectionID, dbName string) (string, error) {
+	dbMeta, err := s.getCachedDatabase(connectionID, dbName)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", dbName)
+	fmt.Fprintf(&b, "_%d tables, exported %s_\n\n", len(dbMeta.Tables), time.Now().Format("2006-01-02 15:04"))
+	writeMarkdownProse(&b, dbMeta.DBComment, dbMeta.AIDescription)
+
+	for _, table := range dbMeta.Tables {
+		kind := ""
+		if table.IsView {
+			kind = " (view)"
+		}
+		fmt.Fprintf(&b, "## %s%s\n\n", table.Name, kind)
+		writeMarkdownProse(&b, table.DBComment, table.AIDescription)
+
+		fkColumns := make(map[string]bool)
+		for _, fk := range table.ForeignKeys {
+			for _, col := range fk.ColumnNames {
+				fkColumns[col] = true
+			}
+		}
+
+		b.WriteString("| Column | Type | Nullable | Key | Default | Comment | Description |\n")
+		b.WriteString("| --- | --- | --- | --- | --- | --- | --- |\n")
+		for _, col := range table.Columns {
+			var keys []string
+			if col.IsPrimaryKey {
+				keys = append(keys, "PK")
+			}
+			if fkColumns[col.Name] {
+				keys = append(keys, "FK")
+			}
+			nullable := "NO"
+			if col.IsNullable {
+				nullable = "YES"
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s | %s |\n",
+				markdownCell(col.Name), markdownCell(col.DataType), nullable, strings.Join(keys, ", "),
+				markdownCell(valueString(col.DefaultValue)), markdownCell(col.DBComment), markdownCell(col.AIDescription))
+		}
+		b.WriteString("\n")
+
+		if len(table.ForeignKeys) > 0 {
+			b.WriteString("**Relationships**\n\n")
+			for _, fk := range table.ForeignKeys {
+				fmt.Fprintf(&b, "- `%s`: (%s) → `%s` (%s)\n", fk.Name,
+					strings.Join(fk.ColumnNames, ", "), fk.RefTableName, strings.Join(fk.RefColumnNames, ", "))
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.String(), nil
+}
+
+// writeMarkdownProse writes the database comment and AI description as paragraphs, skipping empty ones.
+func writeMarkdownProse(b *strings.Builder, dbComment, aiDescription string) {
+	for _, text := range []string{dbComment, aiDescription} {
+		if text = strings.TrimSpace(text); text != "" {
+			b.WriteString(text + "\n\n")
+		}
+	}
+}
+
+// markdownCell escapes a value for use inside a Markdown table cell.
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	s = strings.ReplaceAll(s, "\r\n", " ")
+	return strings.ReplaceAll(s, "\n", " ")
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// ScriptStatement is one statement of a script with the line it starts on
+type ScriptStatement struct {
+	SQL  string `json:"sql"`
+	Line int    `json:"line"` // 1-based
+}
+
+// StatementResult is the outcome of one statement of a script
+type StatementResult struct {
+	ScriptStatement
+	Result  *SQLResult `json:"result,omitempty"`
+	Error   string     `json:"error,omitempty"`
+	Skipped bool       `json:"skipped,omitempty"` // Not run because an earlier statement failed
+}
+
+// ScriptOptions controls ExecuteScript
+type ScriptOptions struct {
+	StopOnError bool `json:"stopOnError"` // Skip the remaining statements after the first failure
+}
+
+// SplitStatements splits a script into statements at the delimiter, ";" unless changed by a
+// mysql-client style DELIMITER line. Delimiters inside string literals, quoted identifiers and comments
+// don't split, and statements consisting only of comments are dropped.
+func SplitStatements(script string) []ScriptStatement {
+	statements := make([]ScriptStatement, 0)
+	delimiter := ";"
+	start, line, startLine := 0, 1, 1
+	atLineStart := true
+
+	flush := func(end int) {
+		stmt := strings.TrimSpace(script[start:end])
+		if len(sqlTokens(stmt)) > 0 {
+			statements = append(statements, ScriptStatement{SQL: stmt, Line: startLine})
+		}
+	}
+	// advance moves i to end, counting lines on the way
+	advance := func(i, end int) int {
+		line += strings.Count(script[i:end], "\n")
+		return end
+	}
+
+	for i := 0; i < len(script); {
+		c := script[i]
+
+		if atLineStart {
+			// DELIMITER only counts as the first word of a line outside a statement
+			rest := strings.TrimLeft(script[i:], " \t")
+			if len(rest) > 10 && strings.EqualFold(rest[:10], "DELIMITER ") && strings.TrimSpace(script[start:i]) == "" {
+				end := strings.IndexByte(rest, '\n')
+				if end < 0 {
+					end = len(rest)
+				}
+				if fields := strings.Fields(rest[10:end]); len(fields) > 0 {
+					delimiter = fields[0]
+				}
+				i = advance(i, len(script)-len(rest)+end)
+				start = i
+				continue
+			}
+		}
+		atLineStart = false
+
+		if strings.TrimSpace(script[start:i]) == "" {
+			startLine = line
+		}
+
+		switch {
+		case strings.HasPrefix(script[i:], delimiter):
+			flush(i)
+			i += len(delimiter)
+			start = i
+		case c == '\'' || c == '"' || c == '`':
+			i = advance(i, skipQuoted(script, i))
+		case c == '#' || (c == '-' && strings.HasPrefix(script[i:], "-- ")):
+			end := strings.IndexByte(script[i:], '\n')
+			if end < 0 {
+				end = len(script) - i
+			}
+			i += end
+		case c == '/' && strings.HasPrefix(script[i:], "/*"):
+			end := strings.Index(script[i+2:], "*/")
+			if end < 0 {
+				end = len(script) - i - 2
+			}
+			i = advance(i, i+end+4)
+			i = min(i, len(script))
+		case c == '\n':
+			line++
+			i++
+			atLineStart = true
+		default:
+			i++
+		}
+	}
+	flush(len(script))
+	return statements
+}
+
+// ExecuteScript splits a script into statements and runs them one after another on a single
+// connection, so USE, SET and temporary tables carry over between them. Each statement's result or
+// error is returned in order; with StopOnError the statements after a failure are marked skipped.
+func (s *DatabaseService) ExecuteScript(ctx context.Context, details ConnectionDetails, script string, opts ScriptOptions) ([]StatementResult, error) {
+	statements := SplitStatements(script)
+	if len(statements) == 0 {
+		return nil, fmt.Errorf("script contains no statements")
+	}
+
+	var db *sql.DB
+	var err error
+	sessionChange := false
+	for _, stmt := range statements {
+		sessionChange = sessionChange || changesSessionState(stmt.SQL)
+	}
+	if sessionChange {
+		// Run on a throwaway connection so the session changes can't leak into the shared pool
+		db, err = getDBConnection(details)
+		if err == nil {
+			defer db.Close()
+		}
+	} else {
+		db, err = s.getDB(details)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("connection setup failed for ExecuteScript: %w", err)
+	}
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Close()
+
+	return s.runStatements(ctx, conn, details, statements, opts)
+}
+
+// ExecuteScriptInTx is ExecuteScript inside an open transaction, see BeginTx. Statements that change the
+// session, such as USE or SET, are rejected because they would outlive the transaction on its pooled
+// connection.
+func (s *DatabaseService) ExecuteScriptInTx(ctx context.Context, txID string, script string, opts ScriptOptions) ([]StatementResult, error) {
+	statements := SplitStatements(script)
+	if len(statements) == 0 {
+		return nil, fmt.Errorf("script contains no statements")
+	}
+	for _, stmt := range statements {
+		if changesSessionState(stmt.SQL) {
+			return nil, fmt.Errorf("statement on line %d changes the session and can't run in a transaction: %s", stmt.Line, stmt.SQL)
+		}
+	}
+
+	live, err := s.pauseTx(txID)
+	if err != nil {
+		return nil, err
+	}
+	defer s.resumeTx(txID, live)
+
+	LogInfo("Executing script in transaction %s", txID)
+	return s.runStatements(ctx, live.tx, live.details, statements, opts)
+}
+
+// runStatements runs the statements of a script one after another on runner.
+func (s *DatabaseService) runStatements(ctx context.Context, runner sqlRunner, details ConnectionDetails, statements []ScriptStatement, opts ScriptOptions) ([]StatementResult, error) {
+	results := make([]StatementResult, len(statements))
+	failed := false
+	for i, stmt := range statements {
+		results[i].ScriptStatement = stmt
+		if failed && opts.StopOnError {
+			results[i].Skipped = true
+			continue
+		}
+		result, err := s.runSQL(ctx, runner, details, stmt.SQL)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			results[i].Error = err.Error()
+			failed = true
+			continue
+		}
+		results[i].Result = result
+	}
+
+	LogInfo("Executed script of %d statements", len(statements))
+	return results, nil
+}
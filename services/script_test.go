@@ -0,0 +1,86 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   []ScriptStatement
+	}{
+		{
+			name:   "plain",
+			script: "SELECT 1;\nSELECT 2;",
+			want:   []ScriptStatement{{SQL: "SELECT 1", Line: 1}, {SQL: "SELECT 2", Line: 2}},
+		},
+		{
+			name:   "no trailing delimiter",
+			script: "SELECT 1; SELECT 2",
+			want:   []ScriptStatement{{SQL: "SELECT 1", Line: 1}, {SQL: "SELECT 2", Line: 1}},
+		},
+		{
+			name:   "semicolons in quotes",
+			script: "INSERT INTO t VALUES ('a;b', \"c;d\");\nSELECT `x;y` FROM t;",
+			want: []ScriptStatement{
+				{SQL: "INSERT INTO t VALUES ('a;b', \"c;d\")", Line: 1},
+				{SQL: "SELECT `x;y` FROM t", Line: 2},
+			},
+		},
+		{
+			name:   "escaped quotes",
+			script: "SELECT 'it\\'s;'; SELECT 'it''s;'; SELECT \"say \\\";\"; SELECT `a``;b`;",
+			want: []ScriptStatement{
+				{SQL: "SELECT 'it\\'s;'", Line: 1},
+				{SQL: "SELECT 'it''s;'", Line: 1},
+				{SQL: "SELECT \"say \\\";\"", Line: 1},
+				{SQL: "SELECT `a``;b`", Line: 1},
+			},
+		},
+		{
+			name:   "backslash before backtick",
+			script: "SELECT `a\\`; SELECT 2;",
+			want:   []ScriptStatement{{SQL: "SELECT `a\\`", Line: 1}, {SQL: "SELECT 2", Line: 1}},
+		},
+		{
+			name:   "semicolons in comments",
+			script: "SELECT 1 -- one; two\n;\n# three; four\nSELECT /* five; six */ 2;",
+			want: []ScriptStatement{
+				{SQL: "SELECT 1 -- one; two", Line: 1},
+				{SQL: "# three; four\nSELECT /* five; six */ 2", Line: 3},
+			},
+		},
+		{
+			name:   "comment-only statements are dropped",
+			script: "-- setup\n;\n/* nothing */;\nSELECT 1;\n-- trailing",
+			want:   []ScriptStatement{{SQL: "SELECT 1", Line: 4}},
+		},
+		{
+			name:   "line numbers across multi-line literals",
+			script: "SELECT 'a\nb';\n\nSELECT /*\n*/ 2;",
+			want:   []ScriptStatement{{SQL: "SELECT 'a\nb'", Line: 1}, {SQL: "SELECT /*\n*/ 2", Line: 4}},
+		},
+		{
+			name:   "delimiter",
+			script: "DELIMITER //\nCREATE PROCEDURE p() BEGIN SELECT 1; SELECT 2; END//\nDELIMITER ;\nCALL p();",
+			want: []ScriptStatement{
+				{SQL: "CREATE PROCEDURE p() BEGIN SELECT 1; SELECT 2; END", Line: 2},
+				{SQL: "CALL p()", Line: 4},
+			},
+		},
+		{
+			name:   "empty",
+			script: " ;\n; ",
+			want:   []ScriptStatement{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SplitStatements(tt.script); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitStatements(%q) =\n%#v\nwant\n%#v", tt.script, got, tt.want)
+			}
+		})
+	}
+}
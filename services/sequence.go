@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Sequence represents a TiDB sequence object
+type Sequence struct {
+	Name      string `json:"name"`
+	Start     int64  `json:"start"`
+	Increment int64  `json:"increment"`
+	MinValue  int64  `json:"minValue"`
+	MaxValue  int64  `json:"maxValue"`
+	Cache     int64  `json:"cache"` // 0 when NOCACHE
+	Cycle     bool   `json:"cycle"`
+	// NextValue is the next value to be allocated across the cluster, nil if it couldn't be read
+	NextValue *int64 `json:"nextValue,omitempty"`
+	DBComment string `json:"dbComment,omitempty"`
+}
+
+// extractSequences reads the sequences of a database. Servers without sequence support (anything but
+// TiDB) yield no sequences rather than an error.
+func (s *MetadataService) extractSequences(ctx context.Context, connDetails ConnectionDetails, dbName string) ([]Sequence, error) {
+	result, err := s.dbService.ExecuteSQL(ctx, connDetails, `
+		SELECT SEQUENCE_NAME, START, INCREMENT, MIN_VALUE, MAX_VALUE, CACHE, CACHE_VALUE, CYCLE, COMMENT
+		FROM information_schema.SEQUENCES
+		WHERE SEQUENCE_SCHEMA = ?
+		ORDER BY SEQUENCE_NAME`, dbName)
+	if err != nil {
+		if isMissingTableError(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list sequences: %w", err)
+	}
+
+	sequences := make([]Sequence, 0, len(result.Rows))
+	for _, row := range result.Rows {
+		seq := Sequence{
+			Name:      valueString(row["SEQUENCE_NAME"]),
+			Cycle:     valueString(row["CYCLE"]) == "1",
+			DBComment: valueString(row["COMMENT"]),
+		}
+		for field, target := range map[string]*int64{
+			"START":     &seq.Start,
+			"INCREMENT": &seq.Increment,
+			"MIN_VALUE": &seq.MinValue,
+			"MAX_VALUE": &seq.MaxValue,
+		} {
+			if v, ok := valueInt64(row[field]); ok {
+				*target = v
+			}
+		}
+		if valueString(row["CACHE"]) == "1" {
+			if v, ok := valueInt64(row["CACHE_VALUE"]); ok {
+				seq.Cache = v
+			}
+		}
+		seq.NextValue = s.sequenceNextValue(ctx, connDetails, dbName, seq.Name)
+		sequences = append(sequences, seq)
+	}
+	return sequences, nil
+}
+
+// sequenceNextValue returns the next value TiDB will allocate for a sequence, or nil if unknown.
+func (s *MetadataService) sequenceNextValue(ctx context.Context, connDetails ConnectionDetails, dbName, name string) *int64 {
+	query := fmt.Sprintf("SHOW TABLE %s.%s NEXT_ROW_ID", quoteIdentifier(dbName), quoteIdentifier(name))
+	result, err := s.dbService.ExecuteSQL(ctx, connDetails, query)
+	if err != nil {
+		LogDebug("Next value unavailable for sequence %s.%s: %v", dbName, name, err)
+		return nil
+	}
+	for _, row := range result.Rows {
+		if !strings.EqualFold(valueString(row["ID_TYPE"]), "SEQUENCE") {
+			continue
+		}
+		if next, ok := valueInt64(row["NEXT_GLOBAL_ROW_ID"]); ok {
+			return &next
+		}
+	}
+	return nil
+}
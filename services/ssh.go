@@ -0,0 +1,210 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	mysql "github.com/go-sql-driver/mysql"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// sshDialTimeout bounds establishing the SSH connection to the bastion host
+const sshDialTimeout = 15 * time.Second
+
+// SSHTunnel describes a bastion host the database is reached through
+type SSHTunnel struct {
+	Host           string `json:"host"`
+	Port           string `json:"port,omitempty"` // Defaults to 22
+	User           string `json:"user"`
+	Password       string `json:"password,omitempty"`
+	PrivateKeyPath string `json:"privateKeyPath,omitempty"` // Unencrypted private key; tried before the password
+	// RemoteAddr is the database address as seen from the bastion host, defaults to the connection's host and port
+	RemoteAddr string `json:"remoteAddr,omitempty"`
+}
+
+// sshTunnelClient is a lazily established SSH connection shared by every pooled connection of a tunnel
+type sshTunnelClient struct {
+	tunnel SSHTunnel
+	mu     sync.Mutex
+	client *ssh.Client
+}
+
+var (
+	// sshTunnels holds the tunnels registered as driver networks, keyed by network name
+	sshTunnels   = make(map[string]*sshTunnelClient)
+	sshTunnelsMu sync.Mutex
+)
+
+// sshNetworkName returns the driver network name for a tunnel, derived from its endpoint and user. The
+// name ends up in the DSN, so credentials are left out.
+func sshNetworkName(tunnel SSHTunnel) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{tunnel.Host, tunnel.Port, tunnel.User}, "\x00")))
+	return "ssh-" + hex.EncodeToString(sum[:8])
+}
+
+// registerSSHTunnel registers a driver network that dials through the tunnel, once per distinct tunnel.
+// The SSH connection itself is only established by the first dial. Changed credentials of a registered
+// tunnel replace the old ones and drop its SSH connection.
+func registerSSHTunnel(tunnel SSHTunnel) {
+	// The database address is part of the DSN and dialed per connection, so endpoints with different
+	// remote addresses, such as a connection's read endpoint, share the SSH connection
+	tunnel.RemoteAddr = ""
+	name := sshNetworkName(tunnel)
+
+	sshTunnelsMu.Lock()
+	defer sshTunnelsMu.Unlock()
+
+	if t, ok := sshTunnels[name]; ok {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if t.tunnel != tunnel {
+			t.tunnel = tunnel
+			if t.client != nil {
+				t.client.Close()
+				t.client = nil
+			}
+		}
+		return
+	}
+	t := &sshTunnelClient{tunnel: tunnel}
+	sshTunnels[name] = t
+	mysql.RegisterDialContext(name, t.dial)
+	LogInfo("SSH tunnel %s registered via %s@%s", name, tunnel.User, tunnel.Host)
+}
+
+// dial opens a connection to addr through the SSH connection, (re)establishing it when needed.
+func (t *sshTunnelClient) dial(ctx context.Context, addr string) (net.Conn, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.client != nil {
+		conn, err := t.client.DialContext(ctx, "tcp", addr)
+		if err == nil {
+			return conn, nil
+		}
+		// The bastion may have dropped the connection, reconnect once
+		LogWarning("SSH tunnel to %s failed (%v), reconnecting", t.tunnel.Host, err)
+		t.client.Close()
+		t.client = nil
+	}
+
+	client, err := t.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	t.client = client
+	return client.DialContext(ctx, "tcp", addr)
+}
+
+// connect establishes the SSH connection to the bastion host.
+func (t *sshTunnelClient) connect(ctx context.Context) (*ssh.Client, error) {
+	var auth []ssh.AuthMethod
+	if t.tunnel.PrivateKeyPath != "" {
+		key, err := os.ReadFile(t.tunnel.PrivateKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read SSH private key: %w", err)
+		}
+		signer, err := ssh.ParsePrivateKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse SSH private key: %w", err)
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	if t.tunnel.Password != "" {
+		auth = append(auth, ssh.Password(t.tunnel.Password))
+	}
+	if len(auth) == 0 {
+		return nil, fmt.Errorf("SSH tunnel needs a private key or password")
+	}
+
+	hostKeyCallback, err := sshHostKeyCallback()
+	if err != nil {
+		return nil, err
+	}
+
+	port := t.tunnel.Port
+	if port == "" {
+		port = "22"
+	}
+	addr := net.JoinHostPort(t.tunnel.Host, port)
+	dialer := net.Dialer{Timeout: sshDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach SSH host %s: %w", addr, err)
+	}
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, addr, &ssh.ClientConfig{
+		User:            t.tunnel.User,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         sshDialTimeout,
+	})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("SSH handshake with %s failed: %w", addr, err)
+	}
+	LogInfo("SSH tunnel established to %s", addr)
+	return ssh.NewClient(sshConn, chans, reqs), nil
+}
+
+// sshHostKeyCallback verifies host keys against ~/.ssh/known_hosts, see knownHostsCallback.
+func sshHostKeyCallback() (ssh.HostKeyCallback, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return knownHostsCallback(filepath.Join(home, ".ssh", "known_hosts"))
+}
+
+// knownHostsCallback verifies host keys against the known_hosts file at path. Hosts must already be in
+// the file: without it, or for a host missing from it, the connection is refused rather than trusting
+// whatever key the host presents.
+func knownHostsCallback(path string) (ssh.HostKeyCallback, error) {
+	callback, err := knownhosts.New(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("can't verify the SSH host key: %s doesn't exist. Connect to the SSH host once with ssh, or add its key with ssh-keyscan, then retry", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		err := callback(hostname, remote, key)
+		var keyErr *knownhosts.KeyError
+		if !errors.As(err, &keyErr) {
+			return err
+		}
+		if len(keyErr.Want) == 0 {
+			return fmt.Errorf("SSH host %s (key %s) isn't in %s. Connect to it once with ssh, or add its key with ssh-keyscan, then retry",
+				hostname, ssh.FingerprintSHA256(key), path)
+		}
+		return fmt.Errorf("SSH host key of %s changed: got %s, but %s line %d has a different key. The host may be impersonated; if the key change is expected, update %s",
+			hostname, ssh.FingerprintSHA256(key), path, keyErr.Want[0].Line, path)
+	}, nil
+}
+
+// CloseSSHTunnels closes every established SSH connection. Tunnels stay registered and reconnect on
+// the next dial, so pooled connections recover by redialing.
+func CloseSSHTunnels() {
+	sshTunnelsMu.Lock()
+	defer sshTunnelsMu.Unlock()
+
+	for name, t := range sshTunnels {
+		t.mu.Lock()
+		if t.client != nil {
+			if err := t.client.Close(); err != nil {
+				LogDebug("Failed to close SSH tunnel %s: %v", name, err)
+			}
+			t.client = nil
+		}
+		t.mu.Unlock()
+	}
+}
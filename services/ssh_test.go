@@ -0,0 +1,173 @@
+package services
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// newHostKey returns a fresh SSH host public key.
+func newHostKey(t *testing.T) ssh.PublicKey {
+	t.Helper()
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestKnownHostsCallbackWithoutFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "known_hosts")
+	if _, err := knownHostsCallback(path); err == nil || !strings.Contains(err.Error(), path) {
+		t.Errorf("err = %v, want an error naming the missing %s", err, path)
+	}
+}
+
+func TestKnownHostsCallback(t *testing.T) {
+	known := newHostKey(t)
+	path := filepath.Join(t.TempDir(), "known_hosts")
+	if err := os.WriteFile(path, []byte(knownhosts.Line([]string{"bastion.example.com"}, known)+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	callback, err := knownHostsCallback(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	remote := &net.TCPAddr{IP: net.ParseIP("192.0.2.10"), Port: 22}
+
+	if err := callback("bastion.example.com:22", remote, known); err != nil {
+		t.Errorf("known host key rejected: %v", err)
+	}
+
+	other := newHostKey(t)
+	err = callback("bastion.example.com:22", remote, other)
+	if err == nil || !strings.Contains(err.Error(), "changed") {
+		t.Errorf("err = %v, want a changed host key to be refused", err)
+	}
+
+	err = callback("unknown.example.com:22", remote, other)
+	if err == nil || !strings.Contains(err.Error(), ssh.FingerprintSHA256(other)) {
+		t.Errorf("err = %v, want an unknown host refused with its key fingerprint", err)
+	}
+}
+
+// newLocalSSHClient returns an SSH client connected to an in-process server that accepts anyone.
+func newLocalSSHClient(t *testing.T) *ssh.Client {
+	t.Helper()
+	_, hostKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(hostKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverConfig := &ssh.ServerConfig{NoClientAuth: true}
+	serverConfig.AddHostKey(signer)
+
+	// A real socket: both ends send their version first, which would deadlock on an unbuffered net.Pipe
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		serverSide, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		conn, chans, reqs, err := ssh.NewServerConn(serverSide, serverConfig)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		go ssh.DiscardRequests(reqs)
+		for ch := range chans {
+			ch.Reject(ssh.Prohibited, "no channels")
+		}
+	}()
+
+	clientSide, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, chans, reqs, err := ssh.NewClientConn(clientSide, listener.Addr().String(), &ssh.ClientConfig{
+		User:            "test",
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := ssh.NewClient(conn, chans, reqs)
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// clientClosed reports whether an SSH client's connection has been closed.
+func clientClosed(client *ssh.Client) bool {
+	done := make(chan struct{})
+	go func() {
+		client.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(100 * time.Millisecond):
+		return false
+	}
+}
+
+func TestRegisterSSHTunnelSharedByEndpoints(t *testing.T) {
+	tunnel := SSHTunnel{Host: "bastion.example.com", User: "ops", Password: "secret", RemoteAddr: "10.0.0.5:4000"}
+	name := sshNetworkName(SSHTunnel{Host: tunnel.Host, User: tunnel.User})
+	t.Cleanup(func() {
+		sshTunnelsMu.Lock()
+		delete(sshTunnels, name)
+		sshTunnelsMu.Unlock()
+	})
+
+	registerSSHTunnel(tunnel)
+	sshTunnelsMu.Lock()
+	registered, ok := sshTunnels[name]
+	sshTunnelsMu.Unlock()
+	if !ok {
+		t.Fatalf("tunnel not registered as %s", name)
+	}
+	client := newLocalSSHClient(t)
+	registered.client = client
+
+	// The read endpoint's tunnel only differs in the database address
+	read := tunnel
+	read.RemoteAddr = ""
+	registerSSHTunnel(read)
+	other := tunnel
+	other.RemoteAddr = "10.0.0.6:4000"
+	registerSSHTunnel(other)
+	if registered.client != client || clientClosed(client) {
+		t.Fatal("registering another endpoint of the tunnel dropped its SSH connection")
+	}
+
+	// Changed credentials do replace the connection
+	changed := tunnel
+	changed.Password = "rotated"
+	registerSSHTunnel(changed)
+	if registered.client != nil || !clientClosed(client) {
+		t.Error("changed credentials kept the old SSH connection")
+	}
+	if registered.tunnel.Password != "rotated" {
+		t.Errorf("password = %q, want the rotated one", registered.tunnel.Password)
+	}
+}
@@ -0,0 +1,282 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// ErrUnguardedWrite is returned for UPDATE and DELETE statements without a WHERE clause that weren't confirmed
+var ErrUnguardedWrite = errors.New("statement has no WHERE clause and affects every row")
+
+// leadingKeyword returns the first SQL keyword of a statement in upper case,
+// skipping leading whitespace, comments and opening parentheses.
+func leadingKeyword(query string) string {
+	rest := query
+	for {
+		rest = strings.TrimLeftFunc(rest, func(r rune) bool { return unicode.IsSpace(r) || r == '(' })
+		switch {
+		case strings.HasPrefix(rest, "--"), strings.HasPrefix(rest, "#"):
+			if idx := strings.IndexByte(rest, '\n'); idx >= 0 {
+				rest = rest[idx+1:]
+				continue
+			}
+			return ""
+		case strings.HasPrefix(rest, "/*"):
+			if idx := strings.Index(rest, "*/"); idx >= 0 {
+				rest = rest[idx+2:]
+				continue
+			}
+			return ""
+		}
+		break
+	}
+
+	end := strings.IndexFunc(rest, func(r rune) bool { return !unicode.IsLetter(r) && r != '_' })
+	if end < 0 {
+		end = len(rest)
+	}
+	return strings.ToUpper(rest[:end])
+}
+
+// isSelectStatement reports whether the statement is a SELECT (including WITH ... SELECT and TABLE/VALUES forms).
+func isSelectStatement(query string) bool {
+	switch leadingKeyword(query) {
+	case "SELECT", "WITH", "TABLE", "VALUES":
+		return true
+	}
+	return false
+}
+
+// IsReadOnlyStatement reports whether a statement only reads: SHOW, DESCRIBE, EXPLAIN without ANALYZE
+// (which runs the statement) and plain SELECTs. Such statements can be retried and sent to a replica.
+func IsReadOnlyStatement(query string) bool {
+	switch leadingKeyword(query) {
+	case "SHOW", "DESC", "DESCRIBE":
+		return true
+	case "EXPLAIN":
+		for _, tok := range sqlTokens(query) {
+			if tok.ident && !tok.quoted && strings.EqualFold(tok.text, "ANALYZE") {
+				return false
+			}
+		}
+		return true
+	}
+	return isCacheableQuery(query)
+}
+
+// changesSessionState reports whether a statement changes state that outlives it on its connection,
+// such as the current database, session variables, an open transaction or table locks.
+func changesSessionState(query string) bool {
+	switch leadingKeyword(query) {
+	case "USE", "SET", "BEGIN", "START", "LOCK", "UNLOCK", "PREPARE", "DEALLOCATE":
+		return true
+	}
+	return false
+}
+
+// InjectLimit appends LIMIT n to a single SELECT (or WITH ... SELECT) statement whose outer query has no
+// LIMIT or FETCH clause, and reports whether it did. Clauses that must follow LIMIT (INTO, FOR UPDATE,
+// LOCK IN SHARE MODE) also leave the statement alone. Only the top nesting level counts, so a LIMIT in
+// a subquery or a parenthesized UNION member doesn't stop the outer query from being limited.
+func InjectLimit(query string, n int) (string, bool) {
+	if n <= 0 {
+		return query, false
+	}
+	return appendLimit(query, n)
+}
+
+// appendLimit is InjectLimit without the check for a positive limit, so it can append LIMIT 0.
+func appendLimit(query string, n int) (string, bool) {
+	switch leadingKeyword(query) {
+	case "SELECT", "WITH":
+	default:
+		return query, false
+	}
+
+	depth := 0
+	end := 0 // Index just past the last token of the statement
+	terminated := false
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == '#' || (c == '-' && strings.HasPrefix(query[i:], "-- ")):
+			next := strings.IndexByte(query[i:], '\n')
+			if next < 0 {
+				next = len(query) - i
+			}
+			i += next
+			continue
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			next := strings.Index(query[i+2:], "*/")
+			if next < 0 {
+				return query, false
+			}
+			i += next + 4
+			continue
+		case unicode.IsSpace(rune(c)):
+			i++
+			continue
+		}
+
+		// Anything but whitespace and comments after a top-level semicolon is a second statement
+		if terminated && c != ';' {
+			return query, false
+		}
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			i = skipQuoted(query, i)
+			end = i
+		case c == '(':
+			depth++
+			i++
+			end = i
+		case c == ')':
+			depth--
+			i++
+			end = i
+		case c == ';':
+			if depth == 0 {
+				terminated = true
+			}
+			i++
+		case isNameStart(c):
+			start := i
+			for i < len(query) && isNameChar(query[i]) {
+				i++
+			}
+			if depth == 0 {
+				switch strings.ToUpper(query[start:i]) {
+				case "LIMIT", "FETCH", "INTO", "FOR", "LOCK", "PROCEDURE",
+					"UPDATE", "DELETE", "INSERT", "REPLACE":
+					return query, false
+				}
+			}
+			end = i
+		default:
+			i++
+			end = i
+		}
+	}
+	return query[:end] + fmt.Sprintf(" LIMIT %d", n) + query[end:], true
+}
+
+// UnguardedWriteKind returns "UPDATE" or "DELETE" if the statement is one of those without a WHERE
+// clause of its own, and an empty string otherwise. WHERE clauses inside subqueries, string literals,
+// quoted identifiers and comments don't count.
+func UnguardedWriteKind(query string) string {
+	verb := ""
+	depth := 0
+	for _, tok := range sqlTokens(query) {
+		switch {
+		case tok.text == "(":
+			depth++
+		case tok.text == ")":
+			depth--
+		case depth != 0 || !tok.ident || tok.quoted:
+		case verb == "":
+			// The first top-level keyword decides, after any WITH clause
+			switch word := strings.ToUpper(tok.text); word {
+			case "UPDATE", "DELETE":
+				verb = word
+			case "SELECT", "TABLE", "VALUES", "INSERT", "REPLACE":
+				return ""
+			default:
+				if leadingKeyword(query) != "WITH" {
+					return ""
+				}
+			}
+		case strings.EqualFold(tok.text, "WHERE"):
+			return ""
+		}
+	}
+	return verb
+}
+
+// BindNamedParams rewrites :name placeholders in a query to positional ? placeholders and returns the
+// matching args in order. A name used several times is bound once per occurrence. Colons inside string
+// literals, quoted identifiers and comments are left alone, as are :: casts and := assignments.
+func BindNamedParams(query string, params map[string]any) (string, []any, error) {
+	var b strings.Builder
+	args := make([]any, 0)
+	var missing []string
+
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end := skipQuoted(query, i)
+			b.WriteString(query[i:end])
+			i = end
+		case c == '#' || (c == '-' && strings.HasPrefix(query[i:], "-- ")):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				end = len(query) - i
+			}
+			b.WriteString(query[i : i+end])
+			i += end
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				end = len(query) - i
+			} else {
+				end += 4
+			}
+			b.WriteString(query[i : i+end])
+			i += end
+		case c == ':' && i+1 < len(query) && (query[i+1] == ':' || query[i+1] == '='):
+			b.WriteString(query[i : i+2])
+			i += 2
+		case c == ':' && i+1 < len(query) && isNameStart(query[i+1]):
+			end := i + 1
+			for end < len(query) && isNameChar(query[end]) {
+				end++
+			}
+			name := query[i+1 : end]
+			value, ok := params[name]
+			if !ok && !slices.Contains(missing, name) {
+				missing = append(missing, name)
+			}
+			args = append(args, value)
+			b.WriteByte('?')
+			i = end
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+
+	if len(missing) > 0 {
+		return "", nil, fmt.Errorf("missing values for parameters: %s", strings.Join(missing, ", "))
+	}
+	return b.String(), args, nil
+}
+
+// skipQuoted returns the index just past the quoted literal or identifier starting at start,
+// honoring backslash escapes and doubled quotes.
+func skipQuoted(query string, start int) int {
+	quote := query[start]
+	for i := start + 1; i < len(query); i++ {
+		switch {
+		case query[i] == '\\' && quote != '`':
+			i++
+		case query[i] == quote:
+			if i+1 < len(query) && query[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(query)
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || (c >= '0' && c <= '9')
+}
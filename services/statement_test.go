@@ -0,0 +1,155 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBindNamedParams(t *testing.T) {
+	params := map[string]any{"id": 7, "name": "ann", "empty": nil}
+	tests := []struct {
+		name      string
+		query     string
+		wantQuery string
+		wantArgs  []any
+	}{
+		{
+			name:      "single",
+			query:     "SELECT * FROM t WHERE id = :id",
+			wantQuery: "SELECT * FROM t WHERE id = ?",
+			wantArgs:  []any{7},
+		},
+		{
+			name:      "repeated name binds once per occurrence",
+			query:     "SELECT * FROM t WHERE id = :id OR parent_id = :id AND name = :name",
+			wantQuery: "SELECT * FROM t WHERE id = ? OR parent_id = ? AND name = ?",
+			wantArgs:  []any{7, 7, "ann"},
+		},
+		{
+			name:      "nil value is bound",
+			query:     "UPDATE t SET note = :empty WHERE id = :id",
+			wantQuery: "UPDATE t SET note = ? WHERE id = ?",
+			wantArgs:  []any{nil, 7},
+		},
+		{
+			name:      "string literals are left alone",
+			query:     `SELECT ':id', ":name", 'it''s :id', 'a\':id' FROM t WHERE id = :id`,
+			wantQuery: `SELECT ':id', ":name", 'it''s :id', 'a\':id' FROM t WHERE id = ?`,
+			wantArgs:  []any{7},
+		},
+		{
+			name:      "quoted identifiers are left alone",
+			query:     "SELECT `a:id` FROM t WHERE id = :id",
+			wantQuery: "SELECT `a:id` FROM t WHERE id = ?",
+			wantArgs:  []any{7},
+		},
+		{
+			name:      "comments are left alone",
+			query:     "SELECT 1 -- :name\n# :name\nFROM t /* :name */ WHERE id = :id",
+			wantQuery: "SELECT 1 -- :name\n# :name\nFROM t /* :name */ WHERE id = ?",
+			wantArgs:  []any{7},
+		},
+		{
+			name:      "casts and assignments",
+			query:     "SELECT @x := :id, col::text, '10:30' FROM t",
+			wantQuery: "SELECT @x := ?, col::text, '10:30' FROM t",
+			wantArgs:  []any{7},
+		},
+		{
+			name:      "colon before a digit is not a name",
+			query:     "SELECT TIME('10:30:00')::time, a :1",
+			wantQuery: "SELECT TIME('10:30:00')::time, a :1",
+			wantArgs:  []any{},
+		},
+		{
+			name:      "no placeholders",
+			query:     "SELECT 1",
+			wantQuery: "SELECT 1",
+			wantArgs:  []any{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args, err := BindNamedParams(tt.query, params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if query != tt.wantQuery {
+				t.Errorf("query = %q, want %q", query, tt.wantQuery)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args = %#v, want %#v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestBindNamedParamsMissing(t *testing.T) {
+	_, _, err := BindNamedParams("SELECT * FROM t WHERE a = :a AND b = :b OR a2 = :a AND c = ':c'", map[string]any{"b": 1})
+	if err == nil {
+		t.Fatal("expected an error for missing parameters")
+	}
+	if want := "missing values for parameters: a"; err.Error() != want {
+		t.Errorf("err = %q, want %q", err, want)
+	}
+}
+
+func TestInjectLimit(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string // Empty when the query must be left alone
+	}{
+		{name: "plain select", query: "SELECT * FROM t", want: "SELECT * FROM t LIMIT 100"},
+		{name: "existing limit", query: "SELECT * FROM t LIMIT 5"},
+		{name: "existing limit lowercase", query: "select * from t limit 5 offset 10"},
+		{name: "existing fetch", query: "SELECT * FROM t ORDER BY id FETCH FIRST 5 ROWS ONLY"},
+		{name: "limit only in subquery", query: "SELECT * FROM (SELECT * FROM t LIMIT 5) s", want: "SELECT * FROM (SELECT * FROM t LIMIT 5) s LIMIT 100"},
+		{name: "limit only in IN subquery", query: "SELECT * FROM t WHERE id IN (SELECT id FROM u LIMIT 1)", want: "SELECT * FROM t WHERE id IN (SELECT id FROM u LIMIT 1) LIMIT 100"},
+		{name: "limit only in CTE", query: "WITH c AS (SELECT * FROM t LIMIT 5) SELECT * FROM c", want: "WITH c AS (SELECT * FROM t LIMIT 5) SELECT * FROM c LIMIT 100"},
+		{name: "limit in union member", query: "(SELECT a FROM t LIMIT 1) UNION (SELECT a FROM u)", want: "(SELECT a FROM t LIMIT 1) UNION (SELECT a FROM u) LIMIT 100"},
+		{name: "CTE with limit", query: "WITH c AS (SELECT 1) SELECT * FROM c LIMIT 3"},
+		{name: "trailing semicolon", query: "SELECT * FROM t;", want: "SELECT * FROM t LIMIT 100;"},
+		{name: "trailing semicolon and space", query: "SELECT * FROM t ;  \n", want: "SELECT * FROM t LIMIT 100 ;  \n"},
+		{name: "trailing line comment", query: "SELECT * FROM t -- all rows", want: "SELECT * FROM t LIMIT 100 -- all rows"},
+		{name: "trailing hash comment", query: "SELECT * FROM t # all rows", want: "SELECT * FROM t LIMIT 100 # all rows"},
+		{name: "trailing block comment", query: "SELECT * FROM t /* all */;", want: "SELECT * FROM t LIMIT 100 /* all */;"},
+		{name: "limit in comment", query: "SELECT * FROM t /* LIMIT 5 */", want: "SELECT * FROM t LIMIT 100 /* LIMIT 5 */"},
+		{name: "limit in string", query: "SELECT * FROM t WHERE a = 'LIMIT 5'", want: "SELECT * FROM t WHERE a = 'LIMIT 5' LIMIT 100"},
+		{name: "limit as quoted identifier", query: "SELECT `limit` FROM t", want: "SELECT `limit` FROM t LIMIT 100"},
+		{name: "leading comment", query: "/* report */ SELECT * FROM t", want: "/* report */ SELECT * FROM t LIMIT 100"},
+		{name: "select into", query: "SELECT * FROM t INTO OUTFILE '/tmp/t.csv'"},
+		{name: "for update", query: "SELECT * FROM t WHERE id = 1 FOR UPDATE"},
+		{name: "lock in share mode", query: "SELECT * FROM t LOCK IN SHARE MODE"},
+		{name: "unterminated comment", query: "SELECT * FROM t /* open"},
+		{name: "two statements", query: "SELECT 1; SELECT 2"},
+		{name: "insert select", query: "INSERT INTO t SELECT * FROM u"},
+		{name: "update", query: "UPDATE t SET a = 1"},
+		{name: "delete", query: "DELETE FROM t"},
+		{name: "show", query: "SHOW TABLES"},
+		{name: "explain", query: "EXPLAIN SELECT * FROM t"},
+		{name: "CTE update", query: "WITH c AS (SELECT id FROM u) UPDATE t SET a = 1 WHERE id IN (SELECT id FROM c)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, injected := InjectLimit(tt.query, 100)
+			if tt.want == "" {
+				if injected || got != tt.query {
+					t.Errorf("InjectLimit changed the query to %q", got)
+				}
+				return
+			}
+			if !injected || got != tt.want {
+				t.Errorf("InjectLimit = %q, %v, want %q", got, injected, tt.want)
+			}
+		})
+	}
+}
+
+func TestInjectLimitNonPositive(t *testing.T) {
+	for _, n := range []int{0, -1} {
+		if got, injected := InjectLimit("SELECT * FROM t", n); injected || got != "SELECT * FROM t" {
+			t.Errorf("InjectLimit(%d) = %q, %v, want the query unchanged", n, got, injected)
+		}
+	}
+}
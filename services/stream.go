@@ -0,0 +1,232 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ColumnTypeInfo describes a result column as reported by the driver
+type ColumnTypeInfo struct {
+	Name             string `json:"name"`
+	DatabaseTypeName string `json:"databaseTypeName"`   // e.g. DECIMAL, UNSIGNED BIGINT, DATETIME
+	ScanType         string `json:"scanType,omitempty"` // Go type the driver scans into, e.g. int64, sql.NullTime
+	Nullable         *bool  `json:"nullable,omitempty"` // nil when unknown
+	Length           *int64 `json:"length,omitempty"`   // For variable-length text and binary types
+	Precision        *int64 `json:"precision,omitempty"`
+	Scale            *int64 `json:"scale,omitempty"`
+}
+
+// columnTypeInfos describes each result column.
+func columnTypeInfos(rows *sql.Rows) ([]ColumnTypeInfo, error) {
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get column types: %w", err)
+	}
+	infos := make([]ColumnTypeInfo, len(columnTypes))
+	for i, ct := range columnTypes {
+		info := ColumnTypeInfo{Name: ct.Name(), DatabaseTypeName: ct.DatabaseTypeName()}
+		if scanType := ct.ScanType(); scanType != nil {
+			info.ScanType = scanType.String()
+		}
+		if nullable, ok := ct.Nullable(); ok {
+			info.Nullable = &nullable
+		}
+		if length, ok := ct.Length(); ok {
+			info.Length = &length
+		}
+		if precision, scale, ok := ct.DecimalSize(); ok {
+			info.Precision = &precision
+			info.Scale = &scale
+		}
+		infos[i] = info
+	}
+	return infos, nil
+}
+
+// StreamSummary describes a query whose rows were streamed instead of returned
+type StreamSummary struct {
+	Columns     []string         `json:"columns"`
+	ColumnTypes []ColumnTypeInfo `json:"columnTypes"` // Parallel to Columns
+	RowCount    int64            `json:"rowCount"`
+	DurationMs  int64            `json:"durationMs"`
+}
+
+// scanRows reads every remaining row into a fresh map and passes it to onRow. Values the driver returns
+// as []byte are converted by their column's database type, see typedValue, so numbers and dates keep
+// their types whichever protocol the driver used. NULL stays nil and only non-nil byte slices become
+// strings, so an empty string is never confused with NULL. The scan buffers are reused between rows.
+func scanRows(rows *sql.Rows, columns []string, onRow func(row map[string]any) error) error {
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return fmt.Errorf("failed to get column types: %w", err)
+	}
+	values := make([]any, len(columns))
+	scanArgs := make([]any, len(columns))
+	for i := range values {
+		scanArgs[i] = &values[i]
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(scanArgs...); err != nil {
+			return fmt.Errorf("failed to scan row: %w", err)
+		}
+		row := make(map[string]any, len(columns))
+		for i, col := range columns {
+			switch b := values[i].(type) {
+			case nil:
+				row[col] = nil // SQL NULL, kept distinct from ''
+			case []byte:
+				if b == nil {
+					row[col] = nil
+				} else {
+					row[col] = typedValue(columnTypes[i].DatabaseTypeName(), b)
+				}
+			default:
+				row[col] = values[i]
+			}
+		}
+		if err := onRow(row); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating rows: %w", err)
+	}
+	return nil
+}
+
+// typedValue converts the text of a non-NULL value by its column's database type: integers become int64
+// (uint64 for unsigned columns, so BIGINT UNSIGNED keeps its full range), FLOAT and DOUBLE become
+// float64 and DATE, DATETIME and TIMESTAMP become time.Time. DECIMAL stays a string, as a float64 would
+// lose its precision. Anything else, and any value that doesn't parse (such as the zero date), stays a
+// string.
+func typedValue(databaseType string, b []byte) any {
+	text := string(b)
+	unsigned := strings.HasPrefix(databaseType, "UNSIGNED ")
+	switch strings.TrimPrefix(databaseType, "UNSIGNED ") {
+	case "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "BIGINT", "YEAR":
+		if unsigned {
+			if v, err := strconv.ParseUint(text, 10, 64); err == nil {
+				return v
+			}
+		} else if v, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return v
+		}
+	case "FLOAT", "DOUBLE":
+		if v, err := strconv.ParseFloat(text, 64); err == nil {
+			return v
+		}
+	case "DATE":
+		if v, err := time.Parse(time.DateOnly, text); err == nil {
+			return v
+		}
+	case "DATETIME", "TIMESTAMP":
+		if v, err := time.Parse("2006-01-02 15:04:05.999999", text); err == nil {
+			return v
+		}
+	}
+	return text
+}
+
+// ExecuteSQLStream runs a query and calls onRow for each row as it is read, so results don't have to fit
+// in memory. An error returned by onRow stops the query and is returned as is. Statements without a
+// result set are rejected; use ExecuteSQL for those.
+func (s *DatabaseService) ExecuteSQLStream(ctx context.Context, details ConnectionDetails, query string, onRow func(row map[string]any) error, args ...any) (*StreamSummary, error) {
+	return s.streamQuery(ctx, details, query, nil, onRow, args...)
+}
+
+// streamQuery is ExecuteSQLStream with an optional onColumns callback, called once with the ordered
+// column names before the first row. Exports use it to write headers.
+func (s *DatabaseService) streamQuery(ctx context.Context, details ConnectionDetails, query string, onColumns func(columns []string) error, onRow func(row map[string]any) error, args ...any) (*StreamSummary, error) {
+	LogInfo("Streaming SQL query: %s", query)
+
+	db, err := s.getDB(endpointFor(details, query))
+	if err != nil {
+		return nil, fmt.Errorf("connection setup failed: %w", err)
+	}
+
+	start := time.Now()
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get columns: %w", err)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("statement returned no result set")
+	}
+	if onColumns != nil {
+		if err := onColumns(columns); err != nil {
+			return nil, err
+		}
+	}
+
+	columnTypes, err := columnTypeInfos(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &StreamSummary{Columns: columns, ColumnTypes: columnTypes}
+	err = scanRows(rows, columns, func(row map[string]any) error {
+		summary.RowCount++
+		return onRow(row)
+	})
+	if err != nil {
+		return nil, err
+	}
+	summary.DurationMs = time.Since(start).Milliseconds()
+	return summary, nil
+}
+
+// NullSentinel replaces SQL NULLs in result rows when nulls-as-sentinel mode is on. It marshals to
+// {"$null":true}, which can't be mistaken for any scalar column value.
+type NullSentinel struct {
+	Null bool `json:"$null"`
+}
+
+// isNullSentinel reports whether an input value is the NULL sentinel, either a NullSentinel or its
+// decoded JSON form {"$null": true}.
+func isNullSentinel(v any) bool {
+	switch val := v.(type) {
+	case NullSentinel:
+		return val.Null
+	case *NullSentinel:
+		return val != nil && val.Null
+	case map[string]any:
+		null, ok := val["$null"].(bool)
+		return ok && null && len(val) == 1
+	}
+	return false
+}
+
+// resolveNullSentinels returns a copy of a column/value map with NULL sentinels replaced by nil, so an
+// edit can tell "set to NULL" apart from "set to an empty string".
+func resolveNullSentinels(values map[string]any) map[string]any {
+	resolved := make(map[string]any, len(values))
+	for col, v := range values {
+		if isNullSentinel(v) {
+			v = nil
+		}
+		resolved[col] = v
+	}
+	return resolved
+}
+
+// MarkNulls replaces every NULL value in rows with a NullSentinel.
+func MarkNulls(rows []map[string]any) {
+	for _, row := range rows {
+		for col, v := range row {
+			if v == nil {
+				row[col] = NullSentinel{Null: true}
+			}
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+)
+
+// nullableNames answers every query with a nullable VARCHAR holding NULL, an empty string and a name.
+func nullableNames(context.Context, string, []any) fakeResponse {
+	return fakeResponse{
+		columns: []string{"name"},
+		types:   []string{"VARCHAR"},
+		rows:    [][]driver.Value{{nil}, {[]byte("")}, {[]byte("ann")}},
+	}
+}
+
+func TestRunSQLKeepsNullApartFromEmptyString(t *testing.T) {
+	db, _ := newFakeDB(t, nullableNames)
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	result, err := NewDatabaseService().runSQL(ctx, conn, ConnectionDetails{}, "SELECT name FROM people")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Rows) != 3 {
+		t.Fatalf("%d rows, want 3", len(result.Rows))
+	}
+	if v, ok := result.Rows[0]["name"]; !ok || v != nil {
+		t.Errorf("NULL = %#v, want nil", v)
+	}
+	if v := result.Rows[1]["name"]; v != "" {
+		t.Errorf("empty string = %#v, want \"\"", v)
+	}
+
+	encoded, err := json.Marshal(result.Rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `[{"name":null},{"name":""},{"name":"ann"}]`; string(encoded) != want {
+		t.Errorf("rows = %s, want %s", encoded, want)
+	}
+
+	MarkNulls(result.Rows)
+	encoded, err = json.Marshal(result.Rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `[{"name":{"$null":true}},{"name":""},{"name":"ann"}]`; string(encoded) != want {
+		t.Errorf("rows with sentinels = %s, want %s", encoded, want)
+	}
+}
+
+func TestResolveNullSentinels(t *testing.T) {
+	// An edit as sent back by the frontend, with the sentinel decoded from JSON
+	var values map[string]any
+	if err := json.Unmarshal([]byte(`{"a":{"$null":true},"b":"","c":{"$null":false},"d":{"$null":true,"x":1}}`), &values); err != nil {
+		t.Fatal(err)
+	}
+	values["e"] = NullSentinel{Null: true}
+
+	resolved := resolveNullSentinels(values)
+	if resolved["a"] != nil || resolved["e"] != nil {
+		t.Errorf("sentinels resolved to %#v and %#v, want nil", resolved["a"], resolved["e"])
+	}
+	if resolved["b"] != "" {
+		t.Errorf("empty string resolved to %#v", resolved["b"])
+	}
+	for _, col := range []string{"c", "d"} {
+		if resolved[col] == nil {
+			t.Errorf("%s resolved to NULL, but isn't a sentinel", col)
+		}
+	}
+	if values["a"] == nil {
+		t.Error("resolveNullSentinels changed its input")
+	}
+}
+
+func TestTypedValue(t *testing.T) {
+	tests := []struct {
+		databaseType string
+		text         string
+		want         any
+	}{
+		{"DECIMAL", "12345678901234567890.123456789012345678", "12345678901234567890.123456789012345678"},
+		{"DECIMAL", "0.10", "0.10"},
+		{"UNSIGNED DECIMAL", "99999999999999999.99", "99999999999999999.99"},
+		{"UNSIGNED BIGINT", "18446744073709551615", uint64(math.MaxUint64)},
+		{"BIGINT", "-9223372036854775808", int64(math.MinInt64)},
+		{"INT", "42", int64(42)},
+		{"UNSIGNED TINYINT", "255", uint64(255)},
+		{"YEAR", "2024", int64(2024)},
+		{"DOUBLE", "1.5e10", 1.5e10},
+		{"FLOAT", "0.25", 0.25},
+		{"DATE", "2024-02-29", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"DATETIME", "2024-02-29 13:14:15.123456", time.Date(2024, 2, 29, 13, 14, 15, 123456000, time.UTC)},
+		{"TIMESTAMP", "2024-02-29 13:14:15", time.Date(2024, 2, 29, 13, 14, 15, 0, time.UTC)},
+		// Values that don't parse and types without a safe conversion stay strings
+		{"DATETIME", "0000-00-00 00:00:00", "0000-00-00 00:00:00"},
+		{"BIGINT", "not a number", "not a number"},
+		{"TIME", "838:59:59", "838:59:59"},
+		{"JSON", `{"a":1}`, `{"a":1}`},
+		{"VARCHAR", "", ""},
+		{"", "42", "42"},
+	}
+	for _, tt := range tests {
+		if got := typedValue(tt.databaseType, []byte(tt.text)); got != tt.want {
+			t.Errorf("typedValue(%s, %q) = %#v, want %#v", tt.databaseType, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestRunSQLPreservesDecimalPrecision(t *testing.T) {
+	const price = "98765432109876543210.000000000000000001"
+	db, _ := newFakeDB(t, func(context.Context, string, []any) fakeResponse {
+		return fakeResponse{
+			columns: []string{"price", "units"},
+			types:   []string{"DECIMAL", "UNSIGNED BIGINT"},
+			rows:    [][]driver.Value{{[]byte(price), []byte("18446744073709551615")}},
+		}
+	})
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	result, err := NewDatabaseService().runSQL(ctx, conn, ConnectionDetails{}, "SELECT price, units FROM stock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	row := result.Rows[0]
+	if row["price"] != price {
+		t.Errorf("price = %#v, want %s", row["price"], price)
+	}
+	if row["units"] != uint64(math.MaxUint64) {
+		t.Errorf("units = %#v, want %d", row["units"], uint64(math.MaxUint64))
+	}
+	encoded, err := json.Marshal(row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"price":"` + price + `","units":18446744073709551615}`; string(encoded) != want {
+		t.Errorf("row = %s, want %s", encoded, want)
+	}
+}
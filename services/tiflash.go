@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// TiFlashReplica is the TiFlash replica state of a single table
+type TiFlashReplica struct {
+	TableName    string  `json:"tableName"`
+	ReplicaCount int64   `json:"replicaCount"`
+	Available    bool    `json:"available"`
+	Progress     float64 `json:"progress"` // 0 to 1
+}
+
+// TiFlashStatus lists the TiFlash replicas of a database.
+// Supported is false, with Reason explaining why, when the server is not TiDB or has no TiFlash nodes.
+type TiFlashStatus struct {
+	Supported  bool             `json:"supported"`
+	Reason     string           `json:"reason,omitempty"`
+	StoreCount int              `json:"storeCount"` // Number of TiFlash nodes, -1 if it couldn't be determined
+	Replicas   []TiFlashReplica `json:"replicas"`
+}
+
+// countTiFlashStores returns the number of TiFlash nodes in the cluster.
+// supported is false when the server doesn't provide TiFlash information at all.
+func countTiFlashStores(ctx context.Context, db *sql.DB) (count int, supported bool, err error) {
+	if _, err := db.ExecContext(ctx, "SELECT 1 FROM information_schema.TIFLASH_REPLICA LIMIT 1"); err != nil {
+		if isMissingTableError(err) {
+			return 0, false, nil
+		}
+		return 0, false, fmt.Errorf("failed to query TiFlash replicas: %w", err)
+	}
+
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM information_schema.CLUSTER_INFO WHERE TYPE = 'tiflash'").Scan(&count); err != nil {
+		// CLUSTER_INFO may be restricted to privileged users
+		LogWarning("Unable to count TiFlash nodes: %v", err)
+		return -1, true, nil
+	}
+	return count, true, nil
+}
+
+// GetTiFlashReplicaStatus returns the TiFlash replica state of each table with replicas in a database.
+// On servers without TiFlash an empty, unsupported status is returned instead of an error.
+func (s *DatabaseService) GetTiFlashReplicaStatus(ctx context.Context, details ConnectionDetails, dbName string) (*TiFlashStatus, error) {
+	targetDB := dbName
+	if targetDB == "" {
+		targetDB = details.DBName
+	}
+	if targetDB == "" {
+		return nil, fmt.Errorf("database name is required either explicitly or in connection details")
+	}
+
+	db, err := s.getDB(details)
+	if err != nil {
+		return nil, fmt.Errorf("connection setup failed for GetTiFlashReplicaStatus: %w", err)
+	}
+
+	status := &TiFlashStatus{Replicas: make([]TiFlashReplica, 0)}
+	storeCount, supported, err := countTiFlashStores(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	status.StoreCount = storeCount
+	switch {
+	case !supported:
+		status.Reason = "server does not support TiFlash (not a TiDB server)"
+		return status, nil
+	case storeCount == 0:
+		status.Reason = "cluster has no TiFlash nodes"
+		return status, nil
+	}
+	status.Supported = true
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT TABLE_NAME, REPLICA_COUNT, AVAILABLE, PROGRESS
+		FROM information_schema.TIFLASH_REPLICA
+		WHERE TABLE_SCHEMA = ?
+		ORDER BY TABLE_NAME`, targetDB)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query TiFlash replicas for database '%s': %w", targetDB, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var replica TiFlashReplica
+		var progress sql.NullFloat64
+		if err := rows.Scan(&replica.TableName, &replica.ReplicaCount, &replica.Available, &progress); err != nil {
+			return nil, fmt.Errorf("failed to scan TiFlash replica: %w", err)
+		}
+		replica.Progress = progress.Float64
+		status.Replicas = append(status.Replicas, replica)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating TiFlash replicas: %w", err)
+	}
+
+	return status, nil
+}
+
+// SetTiFlashReplica sets the number of TiFlash replicas of a table, 0 removes them.
+func (s *DatabaseService) SetTiFlashReplica(ctx context.Context, details ConnectionDetails, dbName string, tableName string, count int) error {
+	targetDB := dbName
+	if targetDB == "" {
+		targetDB = details.DBName
+	}
+	if targetDB == "" {
+		return fmt.Errorf("database name is required either explicitly or in connection details")
+	}
+	if tableName == "" {
+		return fmt.Errorf("table name is required")
+	}
+	if count < 0 {
+		return fmt.Errorf("replica count must not be negative, got %d", count)
+	}
+
+	db, err := s.getDB(details)
+	if err != nil {
+		return fmt.Errorf("connection setup failed for SetTiFlashReplica: %w", err)
+	}
+
+	storeCount, supported, err := countTiFlashStores(ctx, db)
+	if err != nil {
+		return err
+	}
+	if !supported {
+		return fmt.Errorf("server does not support TiFlash")
+	}
+	if count > 0 && storeCount == 0 {
+		return fmt.Errorf("cluster has no TiFlash nodes")
+	}
+	if storeCount > 0 && count > storeCount {
+		return fmt.Errorf("replica count %d exceeds the number of TiFlash nodes (%d)", count, storeCount)
+	}
+
+	query := fmt.Sprintf("ALTER TABLE %s.%s SET TIFLASH REPLICA %d", quoteIdentifier(targetDB), quoteIdentifier(tableName), count)
+	if _, err := db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to set TiFlash replica for '%s.%s': %w", targetDB, tableName, err)
+	}
+
+	LogInfo("Set TiFlash replica count of %s.%s to %d", targetDB, tableName, count)
+	return nil
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// TableTimestamps holds when a table was created and last modified
+type TableTimestamps struct {
+	TableName  string     `json:"tableName"`
+	CreateTime *time.Time `json:"createTime,omitempty"` // nil when the server doesn't report it
+	UpdateTime *time.Time `json:"updateTime,omitempty"` // nil when the server doesn't report it
+}
+
+// GetTableTimestamps returns the creation and last update time of every table in a database.
+// TiDB leaves UPDATE_TIME (and for some tables CREATE_TIME) NULL, which is returned as nil.
+func (s *DatabaseService) GetTableTimestamps(ctx context.Context, details ConnectionDetails, dbName string) ([]TableTimestamps, error) {
+	targetDB := dbName
+	if targetDB == "" {
+		targetDB = details.DBName
+	}
+	if targetDB == "" {
+		return nil, fmt.Errorf("database name is required either explicitly or in connection details")
+	}
+
+	result, err := s.ExecuteSQL(ctx, details, `
+		SELECT TABLE_NAME, CREATE_TIME, UPDATE_TIME
+		FROM information_schema.TABLES
+		WHERE TABLE_SCHEMA = ?
+		ORDER BY TABLE_NAME`, targetDB)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get table timestamps for database '%s': %w", targetDB, err)
+	}
+
+	timestamps := make([]TableTimestamps, 0, len(result.Rows))
+	for _, row := range result.Rows {
+		timestamps = append(timestamps, TableTimestamps{
+			TableName:  valueString(row["TABLE_NAME"]),
+			CreateTime: timeValue(row["CREATE_TIME"]),
+			UpdateTime: timeValue(row["UPDATE_TIME"]),
+		})
+	}
+	return timestamps, nil
+}
+
+// timeValue converts a scanned DATETIME result value to a time, or nil for NULL and zero dates.
+func timeValue(v any) *time.Time {
+	switch val := v.(type) {
+	case time.Time:
+		if val.IsZero() {
+			return nil
+		}
+		return &val
+	case string:
+		t, err := time.Parse(time.DateTime, val)
+		if err != nil || t.IsZero() {
+			return nil
+		}
+		return &t
+	default:
+		return nil
+	}
+}
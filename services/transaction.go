@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// TxIdleTimeout is how long a transaction may sit unused before it is rolled back
+const TxIdleTimeout = 5 * time.Minute
+
+// AutoCommitEnabled reports whether statements on the connection commit immediately, see
+// ConnectionDetails.AutoCommit.
+func AutoCommitEnabled(details ConnectionDetails) bool {
+	return details.AutoCommit == nil || *details.AutoCommit
+}
+
+// liveTx is an open transaction spanning several calls
+type liveTx struct {
+	tx      *sql.Tx
+	details ConnectionDetails
+	timer   *time.Timer
+}
+
+// BeginTx starts a transaction on the connection and returns its ID for use with ExecuteInTx,
+// CommitTx and RollbackTx. Transactions left unused for TxIdleTimeout are rolled back.
+func (s *DatabaseService) BeginTx(ctx context.Context, details ConnectionDetails) (string, error) {
+	db, err := s.getDB(details)
+	if err != nil {
+		return "", fmt.Errorf("connection setup failed for BeginTx: %w", err)
+	}
+
+	// The transaction outlives this call, so it must not be bound to the caller's context
+	tx, err := db.BeginTx(context.Background(), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	idBytes := make([]byte, 8)
+	if _, err := rand.Read(idBytes); err != nil {
+		tx.Rollback()
+		return "", fmt.Errorf("failed to generate transaction ID: %w", err)
+	}
+	txID := hex.EncodeToString(idBytes)
+
+	s.txMu.Lock()
+	s.txs[txID] = &liveTx{
+		tx:      tx,
+		details: details,
+		timer:   time.AfterFunc(s.txIdleTimeout, func() { s.expireTx(txID) }),
+	}
+	s.txMu.Unlock()
+
+	LogInfo("Transaction %s started on %s", txID, details.Host)
+	return txID, nil
+}
+
+// ExecuteInTx runs a statement inside an open transaction. The idle timeout is paused while the statement
+// runs, so a long statement can't have its transaction rolled back underneath it.
+func (s *DatabaseService) ExecuteInTx(ctx context.Context, txID string, query string, args ...any) (*SQLResult, error) {
+	live, err := s.pauseTx(txID)
+	if err != nil {
+		return nil, err
+	}
+	defer s.resumeTx(txID, live)
+
+	LogInfo("Executing SQL in transaction %s: %s", txID, query)
+	return s.runSQL(ctx, live.tx, live.details, query, args...)
+}
+
+// pauseTx returns an open transaction with its idle timeout stopped until resumeTx.
+func (s *DatabaseService) pauseTx(txID string) (*liveTx, error) {
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	live, ok := s.txs[txID]
+	// A timer that already fired is rolling the transaction back
+	if !ok || !live.timer.Stop() {
+		return nil, fmt.Errorf("transaction %s not found (it may have been committed, rolled back or timed out)", txID)
+	}
+	return live, nil
+}
+
+// resumeTx restarts the idle timeout of a transaction paused by pauseTx, unless it finished meanwhile.
+func (s *DatabaseService) resumeTx(txID string, live *liveTx) {
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	if s.txs[txID] == live {
+		live.timer.Reset(s.txIdleTimeout)
+	}
+}
+
+// CommitTx commits an open transaction.
+func (s *DatabaseService) CommitTx(txID string) error {
+	live, err := s.takeTx(txID)
+	if err != nil {
+		return err
+	}
+	if err := live.tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction %s: %w", txID, err)
+	}
+	LogInfo("Transaction %s committed", txID)
+	return nil
+}
+
+// RollbackTx rolls back an open transaction.
+func (s *DatabaseService) RollbackTx(txID string) error {
+	live, err := s.takeTx(txID)
+	if err != nil {
+		return err
+	}
+	if err := live.tx.Rollback(); err != nil {
+		return fmt.Errorf("failed to roll back transaction %s: %w", txID, err)
+	}
+	LogInfo("Transaction %s rolled back", txID)
+	return nil
+}
+
+// RollbackAllTx rolls back every open transaction, e.g. on disconnect or shutdown.
+func (s *DatabaseService) RollbackAllTx() {
+	s.txMu.Lock()
+	txs := s.txs
+	s.txs = make(map[string]*liveTx)
+	s.txMu.Unlock()
+
+	for txID, live := range txs {
+		live.timer.Stop()
+		if err := live.tx.Rollback(); err != nil {
+			LogError("Failed to roll back transaction %s: %v", txID, err)
+			continue
+		}
+		LogInfo("Transaction %s rolled back", txID)
+	}
+}
+
+// takeTx removes an open transaction from the registry and stops its timeout.
+func (s *DatabaseService) takeTx(txID string) (*liveTx, error) {
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	live, ok := s.txs[txID]
+	if !ok {
+		return nil, fmt.Errorf("transaction %s not found (it may have been committed, rolled back or timed out)", txID)
+	}
+	live.timer.Stop()
+	delete(s.txs, txID)
+	return live, nil
+}
+
+// expireTx rolls back a transaction that was abandoned.
+func (s *DatabaseService) expireTx(txID string) {
+	live, err := s.takeTx(txID)
+	if err != nil {
+		return // Already finished
+	}
+	if err := live.tx.Rollback(); err != nil {
+		LogError("Failed to roll back abandoned transaction %s: %v", txID, err)
+	}
+	LogWarning("Transaction %s was idle for %v and has been rolled back", txID, s.txIdleTimeout)
+	if s.OnTxExpired != nil {
+		s.OnTxExpired(txID)
+	}
+}
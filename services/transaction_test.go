@@ -0,0 +1,202 @@
+package services
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestExecuteInTxPausesIdleTimeout(t *testing.T) {
+	db, fake := newFakeDB(t, func(_ context.Context, query string, _ []any) fakeResponse {
+		if strings.HasPrefix(query, "UPDATE") {
+			time.Sleep(150 * time.Millisecond) // Longer than the idle timeout
+		}
+		return fakeResponse{affected: 1}
+	})
+	details := ConnectionDetails{ID: "tx", Host: "127.0.0.1"}
+	s := NewDatabaseService()
+	s.txIdleTimeout = 50 * time.Millisecond
+	useFakeDB(s, details, db)
+	expired := make(chan string, 1)
+	s.OnTxExpired = func(txID string) { expired <- txID }
+
+	ctx := context.Background()
+	txID, err := s.BeginTx(ctx, details)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ExecuteInTx(ctx, txID, "UPDATE t SET a = 1"); err != nil {
+		t.Fatalf("long statement: %v", err)
+	}
+	if _, err := s.ExecuteInTx(ctx, txID, "SELECT 1"); err != nil {
+		t.Fatalf("transaction rolled back while a statement ran: %v", err)
+	}
+
+	// Left idle, the transaction is rolled back
+	select {
+	case got := <-expired:
+		if got != txID {
+			t.Errorf("expired %s, want %s", got, txID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("idle transaction was not rolled back")
+	}
+	if _, err := s.ExecuteInTx(ctx, txID, "SELECT 1"); err == nil {
+		t.Error("statement ran in an expired transaction")
+	}
+	statements := fake.Statements()
+	if last := statements[len(statements)-1].query; last != "ROLLBACK" {
+		t.Errorf("last statement = %s, want ROLLBACK", last)
+	}
+}
+
+func TestExecuteInTxAfterCommit(t *testing.T) {
+	db, _ := newFakeDB(t, func(context.Context, string, []any) fakeResponse {
+		return fakeResponse{affected: 1}
+	})
+	details := ConnectionDetails{ID: "tx", Host: "127.0.0.1"}
+	s := NewDatabaseService()
+	useFakeDB(s, details, db)
+
+	ctx := context.Background()
+	txID, err := s.BeginTx(ctx, details)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CommitTx(txID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ExecuteInTx(ctx, txID, "SELECT 1"); err == nil {
+		t.Error("statement ran in a committed transaction")
+	}
+	if err := s.RollbackTx(txID); err == nil {
+		t.Error("rolled back a committed transaction")
+	}
+}
+
+// fakeRowCounter is a table of the fake database that only counts its rows. Inserts made in a transaction
+// are seen by their own connection alone until COMMIT.
+type fakeRowCounter struct {
+	mu        sync.Mutex
+	committed int
+	pending   map[int]int // Uncommitted inserts by connection
+}
+
+func (c *fakeRowCounter) respond(ctx context.Context, query string, _ []any) fakeResponse {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	conn := fakeConnID(ctx)
+	switch {
+	case query == "BEGIN":
+		c.pending[conn] = 0
+	case query == "COMMIT":
+		c.committed += c.pending[conn]
+		delete(c.pending, conn)
+	case query == "ROLLBACK":
+		delete(c.pending, conn)
+	case strings.HasPrefix(query, "INSERT"):
+		if _, inTx := c.pending[conn]; inTx {
+			c.pending[conn]++
+		} else {
+			c.committed++
+		}
+		return fakeResponse{affected: 1}
+	case strings.HasPrefix(query, "SELECT COUNT(*)"):
+		return fakeResponse{columns: []string{"n"}, types: []string{"BIGINT"}, rows: [][]driver.Value{{int64(c.committed + c.pending[conn])}}}
+	}
+	return fakeResponse{}
+}
+
+// newRowCounterDB returns a DatabaseService whose connection "tx" holds a fakeRowCounter.
+func newRowCounterDB(t *testing.T) (*DatabaseService, ConnectionDetails) {
+	t.Helper()
+	counter := &fakeRowCounter{pending: make(map[int]int)}
+	db, _ := newFakeDB(t, counter.respond)
+	details := ConnectionDetails{ID: "tx", Host: "127.0.0.1"}
+	s := NewDatabaseService()
+	useFakeDB(s, details, db)
+	return s, details
+}
+
+// countRows returns the row count a session outside any transaction sees.
+func countRows(t *testing.T, s *DatabaseService, details ConnectionDetails) int64 {
+	t.Helper()
+	result, err := s.ExecuteSQL(context.Background(), details, "SELECT COUNT(*) FROM t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result.Rows[0]["n"].(int64)
+}
+
+func TestExecuteScriptInTxUncommittedUntilCommit(t *testing.T) {
+	s, details := newRowCounterDB(t)
+	ctx := context.Background()
+	txID, err := s.BeginTx(ctx, details)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := s.ExecuteScriptInTx(ctx, txID, "INSERT INTO t VALUES (1);\nINSERT INTO t VALUES (2);\nSELECT COUNT(*) FROM t", ScriptOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := results[2].Result.Rows[0]["n"]; n != int64(2) {
+		t.Errorf("script sees %v rows, want its own 2 inserts", n)
+	}
+	if n := countRows(t, s, details); n != 0 {
+		t.Fatalf("other sessions see %d rows before commit, want 0", n)
+	}
+
+	if err := s.CommitTx(txID); err != nil {
+		t.Fatal(err)
+	}
+	if n := countRows(t, s, details); n != 2 {
+		t.Errorf("other sessions see %d rows after commit, want 2", n)
+	}
+}
+
+func TestExecuteScriptInTxRejectsSessionChanges(t *testing.T) {
+	s, details := newRowCounterDB(t)
+	ctx := context.Background()
+	txID, err := s.BeginTx(ctx, details)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ExecuteScriptInTx(ctx, txID, "INSERT INTO t VALUES (1);\nUSE other", ScriptOptions{}); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Fatalf("err = %v, want the USE on line 2 rejected", err)
+	}
+	if _, err := s.ExecuteInTx(ctx, txID, "SELECT COUNT(*) FROM t"); err != nil {
+		t.Errorf("transaction unusable after a rejected script: %v", err)
+	}
+}
+
+func TestRollbackTxDiscardsInsert(t *testing.T) {
+	s, details := newRowCounterDB(t)
+	ctx := context.Background()
+	txID, err := s.BeginTx(ctx, details)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.ExecuteInTx(ctx, txID, "INSERT INTO t VALUES (1)"); err != nil {
+		t.Fatal(err)
+	}
+	result, err := s.ExecuteInTx(ctx, txID, "SELECT COUNT(*) FROM t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := result.Rows[0]["n"]; n != int64(1) {
+		t.Fatalf("transaction sees %v rows after its insert, want 1", n)
+	}
+
+	if err := s.RollbackTx(txID); err != nil {
+		t.Fatal(err)
+	}
+	if n := countRows(t, s, details); n != 0 {
+		t.Errorf("%d rows after rolling back the insert, want 0", n)
+	}
+}
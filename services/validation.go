@@ -0,0 +1,112 @@
+package services
+
+import (
+	"strconv"
+	"strings"
+)
+
+// FieldProblem is one invalid field of a connection
+type FieldProblem struct {
+	Field   string `json:"field"` // JSON name of the field, e.g. "port" or "sshTunnel.host"
+	Message string `json:"message"`
+}
+
+// ValidationError lists every problem found with a connection, so all of them can be shown at once
+type ValidationError struct {
+	Problems []FieldProblem `json:"problems"`
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		messages[i] = p.Message
+	}
+	return "invalid connection: " + strings.Join(messages, "; ")
+}
+
+func (e *ValidationError) add(field, message string) {
+	e.Problems = append(e.Problems, FieldProblem{Field: field, Message: message})
+}
+
+// errOrNil returns e if it holds any problems, else a nil error.
+func (e *ValidationError) errOrNil() error {
+	if len(e.Problems) == 0 {
+		return nil
+	}
+	return e
+}
+
+// ValidateConnectionDetails checks the fields needed to connect: hosts are set and ports are numbers in range.
+// TLS files aren't checked for existence, as they may live on a drive that is mounted later.
+// It returns a *ValidationError listing every problem, or nil.
+func ValidateConnectionDetails(details ConnectionDetails) error {
+	problems := &ValidationError{}
+	validateConnectionFields(details, problems)
+	return problems.errOrNil()
+}
+
+func validateConnectionFields(details ConnectionDetails, problems *ValidationError) {
+	if strings.TrimSpace(details.Host) == "" {
+		problems.add("host", "host is required")
+	}
+	validatePort("port", details.Port, problems)
+	validatePort("readPort", details.ReadPort, problems)
+	if details.ResourceGroup != "" && !resourceGroupNamePattern.MatchString(details.ResourceGroup) {
+		problems.add("resourceGroup", "resource group name may only contain letters, digits and underscores")
+	}
+	if (details.TLSCertFile == "") != (details.TLSKeyFile == "") {
+		problems.add("tlsCertFile", "client certificate and key files must be given together")
+	}
+	if tunnel := details.SSHTunnel; tunnel != nil {
+		if strings.TrimSpace(tunnel.Host) == "" {
+			problems.add("sshTunnel.host", "SSH host is required")
+		}
+		validatePort("sshTunnel.port", tunnel.Port, problems)
+	}
+}
+
+// validatePort accepts an empty port (the default is used) or a number from 1 to 65535.
+func validatePort(field, port string, problems *ValidationError) {
+	if port == "" {
+		return
+	}
+	n, err := strconv.Atoi(port)
+	if err != nil {
+		problems.add(field, "port must be a number")
+	} else if n < 1 || n > 65535 {
+		problems.add(field, "port must be between 1 and 65535")
+	}
+}
+
+// sameConnectionName reports whether two connection names clash: they are equal ignoring case and
+// surrounding spaces.
+func sameConnectionName(a, b string) bool {
+	return strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b))
+}
+
+// validateConnectionForSave runs ValidateConnectionDetails and also checks the name is set and not used,
+// ignoring case, by another saved connection. Caller must hold the lock.
+func (s *ConfigService) validateConnectionForSave(details ConnectionDetails) error {
+	problems := &ValidationError{}
+	name := strings.TrimSpace(details.Name)
+	if name == "" {
+		problems.add("name", "connection name cannot be empty")
+	} else {
+		for id, existing := range s.config.Connections {
+			if id != details.ID && sameConnectionName(existing.Name, name) {
+				problems.add("name", "connection name '"+details.Name+"' already exists")
+				break
+			}
+		}
+	}
+	validateConnectionFields(details, problems)
+	return problems.errOrNil()
+}
+
+// ValidateConnectionForSave runs the checks AddOrUpdateConnection makes before saving details.
+func (s *ConfigService) ValidateConnectionForSave(details ConnectionDetails) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.validateConnectionForSave(details)
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// problemFields returns the fields err reports problems with, failing unless err is a *ValidationError.
+func problemFields(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("err = %v (%T), want a *ValidationError", err, err)
+	}
+	fields := make([]string, len(validationErr.Problems))
+	for i, p := range validationErr.Problems {
+		fields[i] = p.Field
+	}
+	return fields
+}
+
+func TestValidateConnectionDetails(t *testing.T) {
+	certFile := filepath.Join(t.TempDir(), "not-mounted", "client.pem") // Checked when connecting, not here
+	valid := ConnectionDetails{Name: "local", Host: "127.0.0.1", Port: "4000"}
+
+	tests := []struct {
+		name       string
+		modify     func(d *ConnectionDetails)
+		wantFields []string
+	}{
+		{"valid", func(d *ConnectionDetails) {}, nil},
+		{"default port", func(d *ConnectionDetails) { d.Port = "" }, nil},
+		{"empty host", func(d *ConnectionDetails) { d.Host = "" }, []string{"host"}},
+		{"blank host", func(d *ConnectionDetails) { d.Host = "  " }, []string{"host"}},
+		{"non-numeric port", func(d *ConnectionDetails) { d.Port = "4000a" }, []string{"port"}},
+		{"port zero", func(d *ConnectionDetails) { d.Port = "0" }, []string{"port"}},
+		{"port too large", func(d *ConnectionDetails) { d.Port = "65536" }, []string{"port"}},
+		{"bad read port", func(d *ConnectionDetails) { d.ReadHost, d.ReadPort = "replica", "-1" }, []string{"readPort"}},
+		{"resource group", func(d *ConnectionDetails) { d.ResourceGroup = "rg_batch1" }, nil},
+		{"resource group with a quote", func(d *ConnectionDetails) { d.ResourceGroup = "rg'; DROP" }, []string{"resourceGroup"}},
+		{"resource group with a space", func(d *ConnectionDetails) { d.ResourceGroup = "rg batch" }, []string{"resourceGroup"}},
+		{"CA file not present yet", func(d *ConnectionDetails) { d.TLSCAFile = certFile }, nil},
+		{"client certificate and key", func(d *ConnectionDetails) { d.TLSCertFile, d.TLSKeyFile = certFile, certFile }, nil},
+		{"certificate without key", func(d *ConnectionDetails) { d.TLSCertFile = certFile }, []string{"tlsCertFile"}},
+		{"key without certificate", func(d *ConnectionDetails) { d.TLSKeyFile = certFile }, []string{"tlsCertFile"}},
+		{"SSH tunnel without host", func(d *ConnectionDetails) { d.SSHTunnel = &SSHTunnel{User: "ops", Port: "ssh"} }, []string{"sshTunnel.host", "sshTunnel.port"}},
+		{
+			"every problem at once",
+			func(d *ConnectionDetails) { d.Host, d.Port, d.TLSKeyFile = "", "x", certFile },
+			[]string{"host", "port", "tlsCertFile"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			details := valid
+			tt.modify(&details)
+			if fields := problemFields(t, ValidateConnectionDetails(details)); !slices.Equal(fields, tt.wantFields) {
+				t.Errorf("problems with %v, want %v", fields, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestValidateConnectionForSave(t *testing.T) {
+	s := newTestConfigService(t, ConnectionDetails{ID: "prod-id", Name: "Prod", Host: "prod.example.com", Port: "4000"})
+
+	tests := []struct {
+		name       string
+		details    ConnectionDetails
+		wantFields []string
+	}{
+		{"new name", ConnectionDetails{Name: "staging", Host: "staging"}, nil},
+		{"empty name", ConnectionDetails{Name: " ", Host: "staging"}, []string{"name"}},
+		{"duplicate name", ConnectionDetails{Name: "Prod", Host: "staging"}, []string{"name"}},
+		{"duplicate name in another case", ConnectionDetails{Name: " prod ", Host: "staging"}, []string{"name"}},
+		{"same connection keeps its name", ConnectionDetails{ID: "prod-id", Name: "prod", Host: "prod.example.com"}, nil},
+		{"duplicate name and empty host", ConnectionDetails{Name: "PROD"}, []string{"name", "host"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if fields := problemFields(t, s.ValidateConnectionForSave(tt.details)); !slices.Equal(fields, tt.wantFields) {
+				t.Errorf("problems with %v, want %v", fields, tt.wantFields)
+			}
+		})
+	}
+
+	// Saving runs the same checks and leaves the config alone when they fail
+	if _, err := s.AddOrUpdateConnection(ConnectionDetails{Name: "prod", Host: "staging"}); problemFields(t, err) == nil {
+		t.Error("saving a duplicate name succeeded")
+	}
+	if connections, _ := s.GetAllConnections(); len(connections) != 1 {
+		t.Errorf("config holds %d connections after a rejected save, want 1", len(connections))
+	}
+}
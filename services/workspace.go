@@ -0,0 +1,153 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+const workspaceMetadataPrefix = MetadataDirName + "/"
+
+// ExportWorkspace writes a zip archive containing config.json and all metadata files.
+// Passwords and API keys are stripped from the config unless includeSecrets is set.
+func (s *MetadataService) ExportWorkspace(writer io.Writer, includeSecrets bool) error {
+	configData, err := s.configService.exportConfigData(includeSecrets)
+	if err != nil {
+		return err
+	}
+
+	connections, err := s.configService.GetAllConnections()
+	if err != nil {
+		return fmt.Errorf("failed to list connections: %w", err)
+	}
+
+	zw := zip.NewWriter(writer)
+
+	fw, err := zw.Create(ConfigFileName)
+	if err != nil {
+		return fmt.Errorf("failed to add config to archive: %w", err)
+	}
+	if _, err := fw.Write(configData); err != nil {
+		return fmt.Errorf("failed to write config to archive: %w", err)
+	}
+
+	for connectionID := range connections {
+		data, err := s.readMetadataFile(connectionID)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue // Never extracted, nothing to export
+			}
+			return fmt.Errorf("failed to read metadata for connection %s: %w", connectionID, err)
+		}
+
+		fw, err := zw.Create(workspaceMetadataPrefix + connectionID + ".json")
+		if err != nil {
+			return fmt.Errorf("failed to add metadata to archive: %w", err)
+		}
+		if _, err := fw.Write(data); err != nil {
+			return fmt.Errorf("failed to write metadata to archive: %w", err)
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to finalize archive: %w", err)
+	}
+
+	LogInfo("Exported workspace with %d connections", len(connections))
+	return nil
+}
+
+// ImportWorkspace restores config and metadata from an archive created by ExportWorkspace.
+// Imported connections get fresh IDs and their metadata files are renamed to match. With overwrite,
+// existing connections and settings are replaced; otherwise imported connections are merged in.
+// The whole archive is validated before anything is written.
+func (s *MetadataService) ImportWorkspace(reader io.Reader, overwrite bool) error {
+	raw, err := io.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("failed to read archive: %w", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(raw), int64(len(raw)))
+	if err != nil {
+		return fmt.Errorf("invalid workspace archive: %w", err)
+	}
+
+	var imported *ConfigData
+	metadataFiles := make(map[string]*ConnectionMetadata)
+
+	for _, f := range zr.File {
+		data, err := readZipFile(f)
+		if err != nil {
+			return fmt.Errorf("invalid workspace archive: failed to read %s: %w", f.Name, err)
+		}
+
+		switch {
+		case f.Name == ConfigFileName:
+			var cfg ConfigData
+			if err := json.Unmarshal(data, &cfg); err != nil {
+				return fmt.Errorf("invalid workspace archive: corrupt %s: %w", ConfigFileName, err)
+			}
+			imported = &cfg
+
+		case strings.HasPrefix(f.Name, workspaceMetadataPrefix) && path.Ext(f.Name) == ".json":
+			connectionID := strings.TrimSuffix(path.Base(f.Name), ".json")
+			var metadata ConnectionMetadata
+			if err := json.Unmarshal(data, &metadata); err != nil {
+				return fmt.Errorf("invalid workspace archive: corrupt metadata %s: %w", f.Name, err)
+			}
+			metadataFiles[connectionID] = &metadata
+
+		default:
+			return fmt.Errorf("invalid workspace archive: unexpected entry %s", f.Name)
+		}
+	}
+
+	if imported == nil {
+		return fmt.Errorf("invalid workspace archive: missing %s", ConfigFileName)
+	}
+	for connectionID := range metadataFiles {
+		if _, exists := imported.Connections[connectionID]; !exists {
+			return fmt.Errorf("invalid workspace archive: metadata for unknown connection %s", connectionID)
+		}
+	}
+
+	idMapping, removedIDs, err := s.configService.importConfigData(*imported, overwrite)
+	if err != nil {
+		return fmt.Errorf("failed to import config: %w", err)
+	}
+
+	for _, connectionID := range removedIDs {
+		if err := s.DeleteConnectionMetadata(connectionID); err != nil {
+			LogInfo("Warning: Failed to delete metadata for replaced connection %s: %v", connectionID, err)
+		}
+	}
+
+	for oldID, metadata := range metadataFiles {
+		newID := idMapping[oldID]
+		metadata.ConnectionID = newID
+		data, err := json.MarshalIndent(metadata, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal metadata: %w", err)
+		}
+		if err := s.writeMetadataFile(newID, data); err != nil {
+			return fmt.Errorf("failed to write metadata file: %w", err)
+		}
+	}
+
+	LogInfo("Imported workspace with %d connections (overwrite: %v)", len(idMapping), overwrite)
+	return nil
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}